@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/services"
+)
+
+const usage = `usage:
+  tasksrestapi [-config file] [-set path=value]... [command]
+
+commands:
+  (none)                        run the server
+  backup [-dir d]               write a backup of the running database
+  restore <file>                replace the database with a backup (stop the server first)
+  config print                  show the effective config and where each setting came from,
+                                also when it fails validation
+
+Settings are read from the defaults, the config file, TASKS_* environment variables and -set
+flags, each overriding the ones before. TASKS_SERVER_PORT sets serverConfig.port; durations
+accept seconds or strings such as "90s" or "1h30m". On SIGHUP the server loads the config again
+and applies the checker delay, job intervals and log level; GET /admin/config shows the active
+version and the changes still waiting for a restart.
+`
+
+func runCommand(args []string, cfg config.Config, sources config.Sources, log *slog.Logger) int {
+	var err error
+	switch args[0] {
+	case "backup":
+		err = backupCommand(args[1:], cfg, log)
+	case "restore":
+		err = restoreCommand(args[1:], cfg)
+	case "config":
+		err = configCommand(args[1:], cfg, sources)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func backupCommand(args []string, cfg config.Config, log *slog.Logger) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	dir := fs.String("dir", cfg.Backup.Dir, "directory to write the backup to")
+	keep := fs.Int("keep", cfg.Backup.Keep, "number of backups to keep, 0 keeps all")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		return fmt.Errorf("no backup directory configured, pass -dir")
+	}
+	// Opening another driver would compact or snapshot its files on Close.
+	if cfg.Storage.Driver != "sqlite" && cfg.Storage.Driver != "" {
+		return fmt.Errorf("backup is only supported for the sqlite driver")
+	}
+
+	idgen, err := id.NewGenerator(cfg.ID.Generator, cfg.ID.NodeID)
+	if err != nil {
+		return err
+	}
+	st, err := newStorage(cfg, idgen, log)
+	if err != nil {
+		return err
+	}
+	if closer, ok := st.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	backup, err := services.CreateBackup(*dir, *keep, time.Now(), st, log)
+	if err != nil {
+		return err
+	}
+	fmt.Println(backup.Path)
+	return nil
+}
+
+func restoreCommand(args []string, cfg config.Config) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected exactly one backup file")
+	}
+	if cfg.Storage.Driver != "sqlite" && cfg.Storage.Driver != "" {
+		return fmt.Errorf("restore is only supported for the sqlite driver")
+	}
+	return restoreSqlite(cfg.Sql, args[0])
+}
+
+func configCommand(args []string, cfg config.Config, sources config.Sources) error {
+	if len(args) != 1 || args[0] != "print" {
+		return fmt.Errorf("expected \"config print\"")
+	}
+
+	settings, err := config.Settings(cfg, sources)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+	for _, s := range settings {
+		value := s.Value
+		if s.Secret && value != `""` {
+			value = `"******"`
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Path, value, s.Source)
+	}
+	return w.Flush()
+}
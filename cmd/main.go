@@ -2,41 +2,105 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gintokos/tasksrestapi/internal/app"
 	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
 	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
-	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+	"github.com/gintokos/tasksrestapi/internal/notify"
 )
 
 func main() {
-	cfg := config.MustLoad("config.json")
-	log := slog.New(slog.NewTextHandler(os.Stdout,
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	configPath := flags.String("config", "", "config file, defaults to $TASKS_CONFIG or config.json")
+	var sets setFlags
+	flags.Var(&sets, "set", "override a setting as path=value, may be repeated")
+	flags.Parse(os.Args[1:])
+
+	// Subcommands print their output to stdout, so their logs go to stderr.
+	out := io.Writer(os.Stdout)
+	if flags.NArg() > 0 {
+		out = os.Stderr
+	}
+	level := new(slog.LevelVar)
+	log := slog.New(slog.NewTextHandler(out,
 		&slog.HandlerOptions{
-			Level: slog.LevelDebug,
+			Level: level,
 		},
 	))
+
+	opts := config.LoadOptions{Path: *configPath, Environ: os.Environ(), Sets: sets, Logger: log}
+	cfg, sources, err := config.Load(opts)
+	args := flags.Args()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error on loading config:")
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintln(os.Stderr, "  "+line)
+		}
+		// A config that only failed validation can still be printed to find the bad setting.
+		var fieldErr *config.FieldError
+		if !errors.As(err, &fieldErr) || len(args) == 0 || args[0] != "config" {
+			os.Exit(1)
+		}
+	}
+	level.Set(cfg.Log.SlogLevel())
 	log.Info("Config and logger was inited")
 
-	storage, err := sqllite.NewStorage(cfg.Sql.Storagepath)
+	if len(args) > 0 {
+		code := runCommand(args, cfg, sources, log)
+		if err != nil && code == 0 {
+			code = 1
+		}
+		os.Exit(code)
+	}
+
+	idgen, err := id.NewGenerator(cfg.ID.Generator, cfg.ID.NodeID)
+	if err != nil {
+		log.Error("error on creating id generator", sl.Err(err))
+		os.Exit(1)
+	}
+
+	storage, err := newStorage(cfg, idgen, log)
 	if err != nil {
 		log.Error("error on getting storage", sl.Err(err))
 		os.Exit(1)
 	}
 	log.Info("Storage was inited")
 
-	app := app.NewApp(storage, log, cfg)
+	notifier, err := notify.New(cfg.Notifier, log)
+	if err != nil {
+		log.Error("error on creating notifier", sl.Err(err))
+		os.Exit(1)
+	}
+
+	app := app.NewApp(storage, notifier, log, cfg)
 	go app.MustStart()
 	log.Info("App have started his work")
 
 	canceled := make(chan os.Signal, 1)
 	signal.Notify(canceled, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-	<-canceled
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+wait:
+	for {
+		select {
+		case <-hangup:
+			reloadConfig(&app, opts, level, log)
+		case <-canceled:
+			break wait
+		}
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -47,4 +111,41 @@ func main() {
 	} else {
 		log.Info("App stopped his work succesfully")
 	}
+
+	if closer, ok := storage.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Error("error on closing storage", sl.Err(err))
+		}
+	}
+}
+
+// reloadConfig leaves the running config in place when the new one is invalid.
+func reloadConfig(a *app.App, opts config.LoadOptions, level *slog.LevelVar, log *slog.Logger) {
+	cfg, _, err := config.Load(opts)
+	if err != nil {
+		log.Error("config was not reloaded", sl.Err(err))
+		return
+	}
+
+	level.Set(cfg.Log.SlogLevel())
+	status, err := a.Reload(cfg)
+	if err != nil {
+		log.Error("error on reloading config", sl.Err(err))
+		return
+	}
+	log.Info("config reloaded", slog.Int("version", status.Version), slog.String("checksum", status.Checksum))
+	if len(status.RestartRequired) > 0 {
+		log.Warn("config changes need a restart to take effect", slog.Any("settings", status.RestartRequired))
+	}
+}
+
+type setFlags []string
+
+func (s *setFlags) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *setFlags) Set(value string) error {
+	*s = append(*s, value)
+	return nil
 }
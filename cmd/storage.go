@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+	"github.com/gintokos/tasksrestapi/internal/storage/journal"
+	"github.com/gintokos/tasksrestapi/internal/storage/memory"
+)
+
+func newStorage(cfg config.Config, idgen id.Generator, log *slog.Logger) (storage.Storage, error) {
+	switch cfg.Storage.Driver {
+	case "sqlite", "":
+		return newSqliteStorage(cfg.Sql, idgen)
+	case "memory":
+		interval := cfg.Storage.Memory.SnapshotInterval.Duration()
+		return memory.NewStorage(idgen, cfg.Storage.Memory.SnapshotPath, interval, log)
+	case "journal":
+		return journal.NewStorage(cfg.Storage.Journal.Dir, idgen, journal.Options{
+			Fsync:           cfg.Storage.Journal.Fsync,
+			FsyncInterval:   cfg.Storage.Journal.FsyncInterval.Duration(),
+			CompactInterval: cfg.Storage.Journal.CompactInterval.Duration(),
+		}, log)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !cgo
+
+package main
+
+import (
+	"errors"
+
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+func newSqliteStorage(cfg config.SqlConfig, idgen id.Generator) (storage.Storage, error) {
+	return nil, errors.New("sqlite driver requires a cgo build, use the memory or journal driver")
+}
+
+func restoreSqlite(cfg config.SqlConfig, backupPath string) error {
+	return errors.New("sqlite driver requires a cgo build")
+}
@@ -0,0 +1,28 @@
+//go:build cgo
+
+package main
+
+import (
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+)
+
+func newSqliteStorage(cfg config.SqlConfig, idgen id.Generator) (storage.Storage, error) {
+	return sqllite.NewStorage(cfg.Storagepath, idgen, sqllite.Options{
+		JournalMode:     cfg.JournalMode,
+		Synchronous:     cfg.Synchronous,
+		BusyTimeout:     time.Duration(cfg.BusyTimeout) * time.Millisecond,
+		ForeignKeys:     cfg.ForeignKeys,
+		MaxOpenConns:    cfg.MaxOpenConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime.Duration(),
+	})
+}
+
+func restoreSqlite(cfg config.SqlConfig, backupPath string) error {
+	return sqllite.Restore(backupPath, cfg.Storagepath)
+}
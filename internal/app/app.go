@@ -2,32 +2,74 @@ package app
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os"
+	"slices"
 
+	"github.com/gintokos/tasksrestapi/internal/app/backuper"
 	"github.com/gintokos/tasksrestapi/internal/app/checker"
 	hhttpserver "github.com/gintokos/tasksrestapi/internal/app/hhttp-server"
+	"github.com/gintokos/tasksrestapi/internal/app/leader"
 	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/jobs"
 	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/notify"
+	"github.com/gintokos/tasksrestapi/internal/queue"
+	"github.com/gintokos/tasksrestapi/internal/services"
 	"github.com/gintokos/tasksrestapi/internal/storage"
 )
 
 type App struct {
-	checker     checker.Checker
+	elector     *leader.Elector
+	scheduler   *jobs.Scheduler
+	queue       *queue.Pool
+	checker     *checker.Checker
+	backuper    *backuper.Backuper
 	hhttpserver hhttpserver.HttpServer
+	config      *configState
 	logger      *slog.Logger
 }
 
-func NewApp(storage storage.Storage, logger *slog.Logger, cfg config.Config) App {
+func NewApp(storage storage.Storage, notifier notify.Notifier, logger *slog.Logger, cfg config.Config) App {
+	configs := newConfigState(cfg, services.ClockOf(storage))
+
+	elector := leader.NewElector(logger, storage, cfg.Leader)
+	// The server reports leadership in /readyz by comparing the lease holder with this id.
+	cfg.Leader.ID = elector.ID()
+
+	ch := checker.NewChecker(logger, storage, notifier, cfg.Checker)
+	pool := queue.NewPool(logger, storage, elector.ID(), cfg.Queue)
+	ch.RegisterHandlers(pool)
+	backups := backuper.NewBackuper(logger, storage, cfg.Backup)
+
+	scheduler := jobs.NewScheduler(services.ClockOf(storage), elector, logger)
+	for _, job := range slices.Concat(ch.Jobs(), pool.Jobs(), backups.Jobs()) {
+		job, enabled := jobs.Configure(job, cfg.Jobs)
+		if !enabled {
+			logger.Info("job disabled by config", slog.String("job", job.Name))
+			continue
+		}
+		if err := scheduler.Add(job); err != nil {
+			logger.Error("error on adding job", slog.String("job", job.Name), sl.Err(err))
+		}
+	}
+
 	return App{
-		checker:     checker.NewChecker(logger, storage, cfg.Checker),
-		hhttpserver: hhttpserver.NewHttpServer(logger, storage, cfg.Server),
+		elector:     elector,
+		scheduler:   scheduler,
+		queue:       pool,
+		checker:     ch,
+		backuper:    backups,
+		hhttpserver: hhttpserver.NewHttpServer(logger, storage, scheduler, configs, cfg),
+		config:      configs,
 		logger:      logger,
 	}
 }
 
 func (a *App) MustStart() {
-	a.checker.StartCheking()
+	a.StartJobs()
 
 	err := a.hhttpserver.RunServer()
 	if err != nil {
@@ -36,10 +78,23 @@ func (a *App) MustStart() {
 	}
 }
 
+// StartJobs starts everything MustStart does except the server.
+func (a *App) StartJobs() {
+	a.elector.StartElection()
+	a.scheduler.Start()
+	a.queue.Start()
+}
+
+func (a *App) JobStatuses() []models.JobStatus {
+	return a.scheduler.Statuses()
+}
+
 func (a *App) GraceFullShutdown(ctx context.Context) error {
-	err := a.checker.GraceFullShutdown()
-	if err != nil {
-		return err
+	err := errors.Join(a.scheduler.Shutdown(ctx), a.queue.Shutdown(ctx))
+	if err == nil && a.elector.IsLeader() {
+		err = a.checker.GraceFullShutdown()
 	}
-	return a.hhttpserver.GraceFullShutdown(ctx)
+	a.elector.GraceFullShutdown()
+
+	return errors.Join(err, a.hhttpserver.GraceFullShutdown(ctx))
 }
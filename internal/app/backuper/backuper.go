@@ -0,0 +1,59 @@
+package backuper
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/jobs"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+const JobBackup = "backup"
+
+// Backuper takes a rotated backup every interval; it has no job when the storage cannot be backed up online.
+type Backuper struct {
+	storage  storage.Storage
+	logger   *slog.Logger
+	dir      string
+	keep     int
+	interval time.Duration
+}
+
+func NewBackuper(logger *slog.Logger, storage storage.Storage, cfg config.BackupConfig) *Backuper {
+	return &Backuper{
+		storage:  storage,
+		logger:   logger,
+		dir:      cfg.Dir,
+		keep:     cfg.Keep,
+		interval: cfg.Interval.Duration(),
+	}
+}
+
+func (b *Backuper) Jobs() []jobs.Job {
+	if _, ok := b.storage.(storage.Backuper); !ok || b.dir == "" || b.interval <= 0 {
+		return nil
+	}
+	return []jobs.Job{{Name: JobBackup, Interval: b.interval, LeaderOnly: true, Run: b.backup}}
+}
+
+// backup skips a run when the newest backup is younger than the interval, so a restart does not take another one.
+func (b *Backuper) backup(ctx context.Context) error {
+	now := services.ClockOf(b.storage).Now()
+	backups, err := services.ListBackups(b.dir)
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 && now.Sub(backups[0].CreatedAt) < b.interval {
+		return nil
+	}
+
+	backup, err := services.CreateBackup(b.dir, b.keep, now, b.storage, b.logger)
+	if err != nil {
+		return err
+	}
+	b.logger.Info("created backup", slog.String("path", backup.Path), slog.Int64("size", backup.Size))
+	return nil
+}
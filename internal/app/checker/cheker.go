@@ -1,58 +1,210 @@
 package checker
 
 import (
+	"context"
+	"encoding/json"
 	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/jobs"
+	"github.com/gintokos/tasksrestapi/internal/lib/clock"
 	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/notify"
+	"github.com/gintokos/tasksrestapi/internal/queue"
+	"github.com/gintokos/tasksrestapi/internal/services"
 	"github.com/gintokos/tasksrestapi/internal/storage"
 )
 
+const (
+	JobOverdue          = "overdue"
+	JobReminders        = "reminders"
+	JobIdempotencyPurge = "idempotency-purge"
+	JobTrashPurge       = "trash-purge"
+)
+
 type Checker struct {
-	storage storage.Storage
-	logger  *slog.Logger
-	delay   int64
+	storage        storage.Storage
+	logger         *slog.Logger
+	trashRetention time.Duration
+	notifier       notify.Notifier
+	clock          clock.Clock
+	// delay holds a time.Duration.
+	delay            atomic.Int64
+	queued           bool
+	delivery         notify.Notifier
+	lastOverdueCheck time.Time
 }
 
-func NewChecker(logger *slog.Logger, storage storage.Storage, cfg config.CheckerConfig) Checker {
-	return Checker{
-		storage: storage,
-		logger:  logger,
-		delay:   cfg.Delay,
+func NewChecker(logger *slog.Logger, storage storage.Storage, notifier notify.Notifier, cfg config.CheckerConfig) *Checker {
+	ch := &Checker{
+		storage:        storage,
+		logger:         logger,
+		notifier:       notifier,
+		clock:          services.ClockOf(storage),
+		trashRetention: cfg.TrashRetention.Duration(),
 	}
+	ch.SetDelay(cfg.Delay.Duration())
+	return ch
 }
 
-func (ch *Checker) StartCheking() {
-	go func() {
-		ticker := time.NewTicker(time.Duration(ch.delay) * time.Second)
+func (ch *Checker) SetDelay(delay time.Duration) {
+	ch.delay.Store(int64(delay))
+}
 
-		for {
-			<-ticker.C
-			ch.checkStorage()
-		}
-	}()
+func (ch *Checker) Jobs() []jobs.Job {
+	delay := time.Duration(ch.delay.Load())
+	list := []jobs.Job{
+		{Name: JobOverdue, Interval: delay, LeaderOnly: true, Run: ch.checkOverdue},
+		{Name: JobReminders, Interval: delay, LeaderOnly: true, Run: ch.fireReminders},
+		{Name: JobIdempotencyPurge, Interval: delay, LeaderOnly: true, Run: ch.purgeIdempotencyKeys},
+	}
+	if ch.trashRetention > 0 {
+		list = append(list, jobs.Job{Name: JobTrashPurge, Interval: delay, LeaderOnly: true, Run: ch.purgeTrash})
+	}
+	return list
+}
+
+// RegisterHandlers must be called before the jobs start.
+func (ch *Checker) RegisterHandlers(pool *queue.Pool) {
+	if !pool.Enabled() {
+		return
+	}
+	pool.Handle(services.JobSendReminder, ch.sendReminder)
+	pool.Handle(services.JobPurgeTrash, ch.runTrashPurge)
+	ch.delivery = ch.notifier
+	if _, ok := ch.notifier.(*notify.WebhookNotifier); ok {
+		pool.Handle(services.JobDeliverWebhook, ch.deliverWebhook)
+		ch.delivery = services.NewWebhookQueue(ch.storage, ch.logger)
+	}
+	ch.queued = true
 }
 
 func (ch *Checker) GraceFullShutdown() error {
-	return ch.checkStorage()
+	return ch.checkStorage(context.Background(), false)
+}
+
+// checkOverdue does a full pass after a gap, e.g. when this instance takes over as leader. The gap
+// is measured against the job's own schedule, which jobsConfig may set apart from the delay.
+func (ch *Checker) checkOverdue(ctx context.Context) error {
+	now := ch.clock.Now()
+	stale := 2 * time.Duration(ch.delay.Load())
+	if interval, jitter, ok := jobs.Schedule(ctx); ok {
+		stale = 2*interval + jitter
+	}
+	full := ch.lastOverdueCheck.IsZero() || now.Sub(ch.lastOverdueCheck) > stale
+
+	if err := ch.checkStorage(ctx, full); err != nil {
+		return err
+	}
+	ch.lastOverdueCheck = now
+	return nil
 }
 
-func (ch *Checker) checkStorage() error {
-	tasks, err := ch.storage.GetAllTasks(ch.logger)
+func (ch *Checker) checkStorage(ctx context.Context, full bool) error {
+	var (
+		tasks []models.Task
+		err   error
+	)
+	now := ch.clock.Now()
+	if index, ok := ch.storage.(storage.DueIndex); ok && !full {
+		// A task is overdue from the instant it is due, so include the ones due exactly now.
+		tasks, err = index.GetTasksDueBefore(now.Add(time.Nanosecond), ch.logger)
+	} else {
+		tasks, err = ch.storage.GetAllTasks(ch.logger)
+	}
 	if err != nil {
-		ch.logger.Error("error on getting all tasks", sl.Err(err))
 		return err
 	}
 
 	for _, task := range tasks {
-		if task.DueDate == nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if task.OverDue == services.IsOverdue(task, now) {
 			continue
 		}
-		if task.DueDate.Before(time.Now()) {
-			task.OverDue = true
-			ch.storage.UpdateTask(task, ch.logger)
+		if _, err := services.RefreshOverdue(task.ID, now, services.ActorChecker, ch.storage, ch.logger); err != nil {
+			ch.logger.Error("error on updating overdue state", slog.Int64("id", task.ID), sl.Err(err))
+		}
+	}
+	return nil
+}
+
+func (ch *Checker) fireReminders(ctx context.Context) error {
+	if ch.queued {
+		queued, err := services.QueueDueReminders(ch.clock.Now(), ch.storage, ch.logger)
+		if queued > 0 {
+			ch.logger.Info("queued reminders", slog.Int("count", queued))
 		}
+		return err
+	}
+
+	sent, err := services.FireDueReminders(ctx, ch.clock.Now(), ch.notifier, ch.storage, ch.logger)
+	if sent > 0 {
+		ch.logger.Info("sent reminders", slog.Int("count", sent))
+	}
+	return err
+}
+
+func (ch *Checker) sendReminder(ctx context.Context, job models.QueuedJob) error {
+	var payload services.ReminderJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return queue.Permanent(err)
+	}
+	return services.SendReminder(ctx, payload, ch.clock.Now(), ch.delivery, ch.storage, ch.logger)
+}
+
+func (ch *Checker) deliverWebhook(ctx context.Context, job models.QueuedJob) error {
+	var n notify.Notification
+	if err := json.Unmarshal(job.Payload, &n); err != nil {
+		return queue.Permanent(err)
+	}
+	return ch.notifier.Notify(ctx, n)
+}
+
+func (ch *Checker) purgeIdempotencyKeys(ctx context.Context) error {
+	store, ok := ch.storage.(storage.IdempotencyStore)
+	if !ok {
+		return nil
+	}
+
+	purged, err := store.PurgeIdempotencyKeys(ch.clock.Now(), ch.logger)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		ch.logger.Info("purged expired idempotency keys", slog.Int64("count", purged))
+	}
+	return nil
+}
+
+func (ch *Checker) purgeTrash(ctx context.Context) error {
+	before := ch.clock.Now().Add(-ch.trashRetention)
+	if ch.queued {
+		_, _, err := services.QueuePurgeTrash(before, ch.storage, ch.logger)
+		return err
+	}
+	return ch.purgeTrashBefore(before)
+}
+
+func (ch *Checker) runTrashPurge(ctx context.Context, job models.QueuedJob) error {
+	var payload services.PurgeTrashJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return queue.Permanent(err)
+	}
+	return ch.purgeTrashBefore(payload.Before)
+}
+
+func (ch *Checker) purgeTrashBefore(before time.Time) error {
+	purged, err := services.PurgeTrash(before, services.ActorChecker, ch.storage, ch.logger)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		ch.logger.Info("purged trashed tasks", slog.Int("count", purged))
 	}
 	return nil
 }
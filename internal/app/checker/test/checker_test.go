@@ -0,0 +1,437 @@
+package checker_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/app/checker"
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/jobs"
+	"github.com/gintokos/tasksrestapi/internal/lib/clock/fakeclock"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/notify"
+	"github.com/gintokos/tasksrestapi/internal/queue"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+	"github.com/gintokos/tasksrestapi/internal/storage/memory"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+)
+
+var (
+	logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	start  = time.Date(2026, time.March, 9, 12, 0, 0, 0, time.UTC)
+)
+
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []notify.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification notify.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+func (n *recordingNotifier) count() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return len(n.sent)
+}
+
+// startJobs runs the checker's jobs on fc until the test ends. It returns once every job has
+// finished its first run, and the returned advance moves the clock and waits the same way, so
+// assertions after it see every pass that was due. others counts the tickers that stay on fc
+// besides the jobs, such as the one of a started queue pool.
+func startJobs(t *testing.T, fc *fakeclock.Clock, ch *checker.Checker, others int) (advance func(time.Duration)) {
+	t.Helper()
+
+	scheduler := jobs.NewScheduler(fc, nil, logger)
+	list := ch.Jobs()
+	for _, job := range list {
+		if err := scheduler.Add(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	scheduler.Start()
+	t.Cleanup(func() {
+		if err := scheduler.Shutdown(context.Background()); err != nil {
+			t.Errorf("error on stopping jobs: %v", err)
+		}
+	})
+
+	fc.WaitForTickers(len(list) + others)
+	return func(d time.Duration) {
+		fc.Advance(d)
+		fc.WaitForTickers(len(list) + others)
+	}
+}
+
+func TestChecker_FlagsDeadlinesOverAWeek(t *testing.T) {
+	fc := fakeclock.New(start)
+	st, err := memory.NewStorage(id.NewRandomGenerator(), "", 0, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.SetClock(fc)
+
+	tomorrow := start.Add(24 * time.Hour)
+	allDay := time.Date(2026, time.March, 11, 0, 0, 0, 0, time.UTC)
+	nextWeek := start.Add(5*24*time.Hour + 30*time.Minute)
+	var ids []int64
+	for _, task := range []models.Task{
+		{Title: "Tomorrow", DueDate: &tomorrow},
+		{Title: "All day in Auckland", DueDate: &allDay, DueKind: models.DueAllDay, TimeZone: "Pacific/Auckland"},
+		{Title: "Next week", DueDate: &nextWeek},
+		{Title: "Someday"},
+	} {
+		created, err := services.CreateNewTask(task, "alice", st, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, created.ID)
+	}
+
+	advance := startJobs(t, fc, checker.NewChecker(logger, st, &recordingNotifier{}, config.CheckerConfig{Delay: 3600}), 0)
+
+	for hour := 1; hour <= 7*24; hour++ {
+		advance(time.Hour)
+		for _, id := range ids {
+			task, err := st.GetTask(id, logger)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if task.OverDue != services.IsOverdue(task, fc.Now()) {
+				t.Fatalf("expected %q to have overdue %v at %v", task.Title, !task.OverDue, fc.Now())
+			}
+		}
+	}
+
+	for _, id := range ids[:3] {
+		if task, _ := st.GetTask(id, logger); !task.OverDue {
+			t.Fatalf("expected %q to be overdue after a week, got %+v", task.Title, task)
+		}
+	}
+}
+
+func TestChecker_FiresRemindersOnSchedule(t *testing.T) {
+	fc := fakeclock.New(start)
+	st, err := sqllite.NewStorage(filepath.Join(t.TempDir(), "storage.db"), id.NewRandomGenerator(), sqllite.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	st.SetClock(fc)
+
+	due := start.Add(48 * time.Hour)
+	task, err := services.CreateNewTask(models.Task{Title: "Ship it", DueDate: &due}, "alice", st, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, lead := range []int64{24 * 3600, 3600} {
+		if _, err := services.CreateReminder(task.ID, lead, fc.Now(), st, logger); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	notifier := &recordingNotifier{}
+	advance := startJobs(t, fc, checker.NewChecker(logger, st, notifier, config.CheckerConfig{Delay: 60}), 0)
+
+	// Each entry is how many reminders must have gone out once the clock reaches start+after.
+	steps := []struct {
+		after time.Duration
+		want  int
+	}{
+		{23*time.Hour + 59*time.Minute, 0},
+		{24 * time.Hour, 1},
+		{46*time.Hour + 59*time.Minute, 1},
+		{47 * time.Hour, 2},
+		{72 * time.Hour, 2},
+	}
+	for _, step := range steps {
+		for fc.Now().Before(start.Add(step.after)) {
+			advance(time.Minute)
+		}
+		if got := notifier.count(); got != step.want {
+			t.Fatalf("expected %d reminders sent at %v, got %d", step.want, step.after, got)
+		}
+	}
+}
+
+type flakyNotifier struct {
+	recordingNotifier
+	failures atomic.Int32
+}
+
+func (n *flakyNotifier) Notify(ctx context.Context, notification notify.Notification) error {
+	if n.failures.Add(-1) >= 0 {
+		return errors.New("smtp server unavailable")
+	}
+	return n.recordingNotifier.Notify(ctx, notification)
+}
+
+func TestChecker_QueuesRemindersForRetriedDelivery(t *testing.T) {
+	fc := fakeclock.New(start)
+	st, err := sqllite.NewStorage(filepath.Join(t.TempDir(), "storage.db"), id.NewRandomGenerator(), sqllite.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	st.SetClock(fc)
+
+	due := start.Add(2 * time.Hour)
+	task, err := services.CreateNewTask(models.Task{Title: "Ship it", DueDate: &due}, "alice", st, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := services.CreateReminder(task.ID, 3600, fc.Now(), st, logger); err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := &flakyNotifier{}
+	notifier.failures.Store(1)
+	ch := checker.NewChecker(logger, st, notifier, config.CheckerConfig{Delay: 60})
+	pool := queue.NewPool(logger, st, "worker-1", config.QueueConfig{PollInterval: 1, BackoffBase: 60, MaxAttempts: 3})
+	ch.RegisterHandlers(pool)
+	pool.Start()
+	defer pool.Shutdown(context.Background())
+	advance := startJobs(t, fc, ch, 1)
+
+	// waitForJob waits in real time for the pool, which runs beside the scheduled jobs.
+	waitForJob := func(want string, cond func(models.QueuedJob) bool) models.QueuedJob {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			queued, err := services.GetQueuedJobs(models.QueuedJobFilter{Kind: services.JobSendReminder}, st, logger)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(queued) > 1 {
+				t.Fatalf("expected the reminder to be queued once, got %+v", queued)
+			}
+			if len(queued) == 1 && queued[0].LeaseHolder == "" && cond(queued[0]) {
+				return queued[0]
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected the reminder job to be %s, got %+v", want, queued)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	for fc.Now().Before(start.Add(time.Hour)) {
+		advance(time.Minute)
+	}
+	fc.Advance(time.Second)
+	failed := waitForJob("retrying", func(job models.QueuedJob) bool {
+		return job.State == models.JobPending && job.Attempts == 1
+	})
+	if failed.LastError != "smtp server unavailable" || notifier.count() != 0 {
+		t.Fatalf("expected the first delivery to fail, got %+v sent=%d", failed, notifier.count())
+	}
+
+	advance(time.Minute)
+	waitForJob("done", func(job models.QueuedJob) bool { return job.State == models.JobDone })
+	if notifier.count() != 1 {
+		t.Fatalf("expected the reminder to be delivered on the retry, got %d", notifier.count())
+	}
+
+	for i := 0; i < 5; i++ {
+		advance(time.Minute)
+	}
+	waitForJob("done", func(job models.QueuedJob) bool { return job.State == models.JobDone })
+	if notifier.count() != 1 {
+		t.Fatalf("expected the reminder to be delivered once, got %d", notifier.count())
+	}
+}
+
+func TestChecker_QueuesTrashPurgesAndWebhooks(t *testing.T) {
+	fc := fakeclock.New(start)
+	st, err := sqllite.NewStorage(filepath.Join(t.TempDir(), "storage.db"), id.NewRandomGenerator(), sqllite.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	st.SetClock(fc)
+
+	var delivered atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered.Add(1)
+	}))
+	defer srv.Close()
+
+	due := start.Add(2 * time.Hour)
+	task, err := services.CreateNewTask(models.Task{Title: "Ship it", DueDate: &due}, "alice", st, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := services.CreateReminder(task.ID, 3600, fc.Now(), st, logger); err != nil {
+		t.Fatal(err)
+	}
+	old, err := services.CreateNewTask(models.Task{Title: "Old"}, "alice", st, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := services.DeleteTask(old.ID, "alice", st, logger); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := checker.NewChecker(logger, st, notify.NewWebhookNotifier(srv.URL, time.Second), config.CheckerConfig{Delay: 60, TrashRetention: 1800})
+	pool := queue.NewPool(logger, st, "worker-1", config.QueueConfig{PollInterval: 1, BackoffBase: 60, MaxAttempts: 3})
+	ch.RegisterHandlers(pool)
+	pool.Start()
+	defer pool.Shutdown(context.Background())
+	advance := startJobs(t, fc, ch, 1)
+
+	for fc.Now().Before(start.Add(time.Hour)) {
+		advance(time.Minute)
+	}
+
+	// The pool works in real time, so keep its ticker going until every job is done.
+	done := func(kind string) bool {
+		queued, err := services.GetQueuedJobs(models.QueuedJobFilter{Kind: kind}, st, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, job := range queued {
+			if job.State != models.JobDone {
+				return false
+			}
+		}
+		return len(queued) > 0
+	}
+	purged := func() bool {
+		_, err := st.GetTask(old.ID, logger)
+		return errors.Is(err, storage.ErrNotFound)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !purged() || !done(services.JobSendReminder) || !done(services.JobDeliverWebhook) || !done(services.JobPurgeTrash) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the trashed task to be purged and the reminder, webhook and trash purge jobs to finish, purged=%v", purged())
+		}
+		advance(time.Second)
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if delivered.Load() != 1 {
+		t.Errorf("expected the webhook to be called once, got %d", delivered.Load())
+	}
+}
+
+// racingStorage lets a user update land between the checker reading tasks and writing them.
+type racingStorage struct {
+	*sqllite.Storage
+	race func()
+}
+
+func (st *racingStorage) GetAllTasks(logger *slog.Logger) ([]models.Task, error) {
+	tasks, err := st.Storage.GetAllTasks(logger)
+	if st.race != nil {
+		st.race()
+		st.race = nil
+	}
+	return tasks, err
+}
+
+func TestChecker_KeepsConcurrentUpdates(t *testing.T) {
+	fc := fakeclock.New(start)
+	inner, err := sqllite.NewStorage(filepath.Join(t.TempDir(), "storage.db"), id.NewRandomGenerator(), sqllite.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inner.Close()
+	inner.SetClock(fc)
+
+	due := start.Add(time.Hour)
+	task, err := services.CreateNewTask(models.Task{Title: "Draft", DueDate: &due}, "alice", inner, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.Advance(2 * time.Hour)
+
+	st := &racingStorage{Storage: inner, race: func() {
+		edited := task
+		edited.Title = "Final"
+		if _, err := services.UpdateTask(edited, "alice", inner, logger); err != nil {
+			t.Fatal(err)
+		}
+	}}
+	ch := checker.NewChecker(logger, st, &recordingNotifier{}, config.CheckerConfig{Delay: 60})
+	if err := ch.GraceFullShutdown(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := inner.GetTask(task.ID, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Final" || !got.OverDue {
+		t.Fatalf("expected the user's title and the overdue flag, got %+v", got)
+	}
+
+	events, err := inner.GetTaskEvents(task.ID, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range events {
+		if event.Actor == services.ActorChecker && (len(event.Changes) != 1 || event.Changes[0].Field != "overDue") {
+			t.Fatalf("expected the checker to only change overDue, got %+v", event.Changes)
+		}
+	}
+}
+
+type countingStorage struct {
+	*memory.Storage
+	fullPasses atomic.Int64
+}
+
+func (st *countingStorage) GetAllTasks(logger *slog.Logger) ([]models.Task, error) {
+	st.fullPasses.Add(1)
+	return st.Storage.GetAllTasks(logger)
+}
+
+func TestChecker_StaleWindowFollowsJobInterval(t *testing.T) {
+	fc := fakeclock.New(start)
+	inner, err := memory.NewStorage(id.NewRandomGenerator(), "", 0, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner.SetClock(fc)
+	st := &countingStorage{Storage: inner}
+
+	ch := checker.NewChecker(logger, st, &recordingNotifier{}, config.CheckerConfig{Delay: 60})
+	scheduler := jobs.NewScheduler(fc, nil, logger)
+	for _, job := range ch.Jobs() {
+		if job.Name != checker.JobOverdue {
+			continue
+		}
+		job, _ = jobs.Configure(job, config.JobsConfig{checker.JobOverdue: {Interval: 3600}})
+		if err := scheduler.Add(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	scheduler.Start()
+	t.Cleanup(func() { scheduler.Shutdown(context.Background()) })
+
+	fc.WaitForTickers(1)
+	for i := 0; i < 3; i++ {
+		fc.Advance(time.Hour)
+		fc.WaitForTickers(1)
+	}
+	if passes := st.fullPasses.Load(); passes != 1 {
+		t.Fatalf("expected only the first run to be a full pass, got %d", passes)
+	}
+}
@@ -2,52 +2,146 @@ package hhttpserver
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
 	"time"
 
 	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/lib/certreload"
+	"github.com/gintokos/tasksrestapi/internal/services"
 	"github.com/gintokos/tasksrestapi/internal/storage"
 	"github.com/gintokos/tasksrestapi/internal/transport/hhttp"
+	"github.com/gintokos/tasksrestapi/internal/transport/hhttp/handlers"
 )
 
+const defaultCertReloadInterval = time.Minute
+
 type HttpServer struct {
 	storage storage.Storage
+	jobs    handlers.JobLister
+	configs handlers.ConfigStatuser
 	logger  *slog.Logger
 	server  *http.Server
+	cfg     config.Config
+	stop    chan struct{}
 }
 
-func NewHttpServer(logger *slog.Logger, storage storage.Storage, cfg config.ServerConfig) HttpServer {
+func NewHttpServer(logger *slog.Logger, storage storage.Storage, jobs handlers.JobLister, configs handlers.ConfigStatuser, cfg config.Config) HttpServer {
 	srv := http.Server{
-		Addr: "0.0.0.0:8080",
+		Addr:              cfg.Server.Port,
 		ErrorLog:          log.New(io.Discard, "", 0),
-		ReadTimeout:       time.Duration(cfg.ReadTimeout) * time.Second,
-		WriteTimeout:      time.Duration(cfg.WriteTimeout) * time.Second,
-		IdleTimeout:       time.Duration(cfg.IdleTimeout) * time.Second,
-		ReadHeaderTimeout: time.Duration(cfg.ReadHeaderTimeout) * time.Second,
+		ReadTimeout:       cfg.Server.ReadTimeout.Duration(),
+		WriteTimeout:      cfg.Server.WriteTimeout.Duration(),
+		IdleTimeout:       cfg.Server.IdleTimeout.Duration(),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout.Duration(),
 	}
 	return HttpServer{
 		server:  &srv,
 		storage: storage,
+		jobs:    jobs,
+		configs: configs,
 		logger:  logger,
+		cfg:     cfg,
+		stop:    make(chan struct{}),
 	}
 }
 
 func (s *HttpServer) RunServer() error {
-	router := hhttp.NewRouter(s.storage, s.logger)
+	router := hhttp.NewRouter(s.storage, s.jobs, s.configs, s.logger, s.cfg)
 
 	s.server.Handler = router
 
-	err := s.server.ListenAndServe()
-	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+	listeners, err := s.listen()
+	if err != nil {
 		return err
 	}
-	return nil
+
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func() {
+			err := s.server.Serve(l)
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				// Take the other listeners down too, so the failure is not hidden behind them.
+				s.server.Close()
+				errs <- fmt.Errorf("serving on %s: %w", l.Addr(), err)
+				return
+			}
+			errs <- nil
+		}()
+	}
+
+	var result []error
+	for range listeners {
+		if err := <-errs; err != nil {
+			result = append(result, err)
+		}
+	}
+	return errors.Join(result...)
 }
 
 func (s *HttpServer) GraceFullShutdown(ctx context.Context) error {
+	close(s.stop)
 	return s.server.Shutdown(ctx)
 }
+
+func (s *HttpServer) listen() ([]net.Listener, error) {
+	configs := listenerConfigs(s.cfg.Server)
+
+	var tlsConfig *tls.Config
+	for _, lc := range configs {
+		if lc.TLS {
+			var err error
+			if tlsConfig, err = s.setupTLS(); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	listeners := make([]net.Listener, 0, len(configs))
+	for _, lc := range configs {
+		l, err := listen(lc)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("listening on %s %s: %w", lc.Network, lc.Address, err)
+		}
+		if lc.TLS {
+			l = tls.NewListener(l, tlsConfig)
+		}
+		listeners = append(listeners, l)
+		s.logger.Info("listening", slog.String("network", l.Addr().Network()), slog.String("address", l.Addr().String()), slog.Bool("tls", lc.TLS))
+	}
+	return listeners, nil
+}
+
+func (s *HttpServer) setupTLS() (*tls.Config, error) {
+	cfg := s.cfg.Server.TLS
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("tls listeners require certFile and keyFile")
+	}
+
+	certs, err := certreload.New(cfg.CertFile, cfg.KeyFile, s.logger)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := newTLSConfig(cfg, certs)
+	if err != nil {
+		return nil, err
+	}
+	s.server.TLSConfig = tlsConfig
+
+	interval := cfg.ReloadInterval.Duration()
+	if interval <= 0 {
+		interval = defaultCertReloadInterval
+	}
+	go certs.Watch(services.ClockOf(s.storage), interval, s.stop)
+	return tlsConfig, nil
+}
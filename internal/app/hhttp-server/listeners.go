@@ -0,0 +1,98 @@
+package hhttpserver
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/lib/certreload"
+)
+
+func listenerConfigs(cfg config.ServerConfig) []config.ListenerConfig {
+	if len(cfg.Listeners) > 0 {
+		return cfg.Listeners
+	}
+	return []config.ListenerConfig{{Network: "tcp", Address: cfg.Port, TLS: cfg.TLS.CertFile != ""}}
+}
+
+func listen(lc config.ListenerConfig) (net.Listener, error) {
+	switch lc.Network {
+	case "tcp", "":
+		return net.Listen("tcp", lc.Address)
+	case "unix":
+		return listenUnix(lc)
+	default:
+		return nil, fmt.Errorf("network must be tcp or unix, got %q", lc.Network)
+	}
+}
+
+// listenUnix replaces a stale socket, but not one that still accepts connections.
+func listenUnix(lc config.ListenerConfig) (net.Listener, error) {
+	if info, err := os.Stat(lc.Address); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", lc.Address); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("socket is in use by another process")
+		}
+		if err := os.Remove(lc.Address); err != nil {
+			return nil, err
+		}
+	}
+
+	if lc.Mode == "" {
+		return net.Listen("unix", lc.Address)
+	}
+	mode, err := strconv.ParseUint(lc.Mode, 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("setting mode %q: %w", lc.Mode, err)
+	}
+
+	// The umask keeps the socket from being created with looser permissions than the chmod sets.
+	var l net.Listener
+	err = withUmask(int(^mode&0o777), func() (err error) {
+		l, err = net.Listen("unix", lc.Address)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(lc.Address, os.FileMode(mode)); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("setting mode %q: %w", lc.Mode, err)
+	}
+	return l, nil
+}
+
+func newTLSConfig(cfg config.TLSConfig, certs *certreload.Reloader) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		GetCertificate: certs.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+
+	switch cfg.MinVersion {
+	case "", "1.2":
+	case "1.3":
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("tls minVersion must be 1.2 or 1.3, got %q", cfg.MinVersion)
+	}
+
+	if len(cfg.CipherSuites) > 0 {
+		// Only the suites crypto/tls considers secure can be picked.
+		ids := make(map[string]uint16)
+		for _, suite := range tls.CipherSuites() {
+			ids[suite.Name] = suite.ID
+		}
+		for _, name := range cfg.CipherSuites {
+			id, ok := ids[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown or insecure tls cipher suite %q", name)
+			}
+			tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, id)
+		}
+	}
+	return tlsConfig, nil
+}
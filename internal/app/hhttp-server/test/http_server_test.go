@@ -0,0 +1,237 @@
+package hhttpserver_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	hhttpserver "github.com/gintokos/tasksrestapi/internal/app/hhttp-server"
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	mocks "github.com/gintokos/tasksrestapi/internal/storage/mock"
+)
+
+var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// writeCert writes a self-signed certificate for localhost and returns a pool that trusts it.
+func writeCert(t *testing.T, certFile, keyFile string) *x509.CertPool {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf)
+	return roots
+}
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func unixClient(path string, tlsConfig *tls.Config) *http.Client {
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", path)
+	}
+	return &http.Client{Transport: &http.Transport{DialContext: dial, TLSClientConfig: tlsConfig}}
+}
+
+// getReadyz retries until the server is up, since RunServer gives no signal when it is listening.
+func getReadyz(t *testing.T, client *http.Client, url string) *http.Response {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := client.Get(url)
+		if err == nil {
+			return resp
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("error on requesting %s: %v", url, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRunServer_ServesEveryListener(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	roots := writeCert(t, certFile, keyFile)
+	plainSocket, tlsSocket := filepath.Join(dir, "plain.sock"), filepath.Join(dir, "tls.sock")
+	tcpAddr := freeAddr(t)
+
+	cfg := config.Config{Server: config.ServerConfig{
+		Listeners: []config.ListenerConfig{
+			{Network: "tcp", Address: tcpAddr},
+			{Network: "unix", Address: plainSocket, Mode: "0600"},
+			{Network: "unix", Address: tlsSocket, TLS: true},
+		},
+		TLS: config.TLSConfig{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3"},
+	}}
+	srv := hhttpserver.NewHttpServer(logger, mocks.NewMockStorage(nil), nil, nil, cfg)
+	done := make(chan error, 1)
+	go func() { done <- srv.RunServer() }()
+
+	resp := getReadyz(t, http.DefaultClient, "http://"+tcpAddr+"/readyz")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d over tcp, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	resp = getReadyz(t, unixClient(plainSocket, nil), "http://tasks/readyz")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d over the unix socket, got %d", http.StatusOK, resp.StatusCode)
+	}
+	if info, err := os.Stat(plainSocket); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("expected the socket to have mode 0600, got %v err=%v", info.Mode(), err)
+	}
+
+	resp = getReadyz(t, unixClient(tlsSocket, &tls.Config{RootCAs: roots, ServerName: "localhost"}), "https://localhost/readyz")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.TLS == nil || resp.TLS.Version != tls.VersionTLS13 {
+		t.Fatalf("expected a TLS 1.3 response, got %d %+v", resp.StatusCode, resp.TLS)
+	}
+
+	old := unixClient(tlsSocket, &tls.Config{RootCAs: roots, ServerName: "localhost", MaxVersion: tls.VersionTLS12})
+	if _, err := old.Get("https://localhost/readyz"); err == nil {
+		t.Fatal("expected a TLS 1.2 client to be refused")
+	}
+
+	if err := srv.GraceFullShutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("expected RunServer to return cleanly after shutdown, got %v", err)
+	}
+	if _, err := os.Stat(plainSocket); !os.IsNotExist(err) {
+		t.Fatalf("expected the socket file to be removed, got %v", err)
+	}
+}
+
+func TestRunServer_HonorsPort(t *testing.T) {
+	addr := freeAddr(t)
+	srv := hhttpserver.NewHttpServer(logger, mocks.NewMockStorage(nil), nil, nil, config.Config{Server: config.ServerConfig{Port: addr}})
+	done := make(chan error, 1)
+	go func() { done <- srv.RunServer() }()
+
+	resp := getReadyz(t, http.DefaultClient, "http://"+addr+"/readyz")
+	resp.Body.Close()
+
+	if err := srv.GraceFullShutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunServer_RejectsBadSettings(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCert(t, certFile, keyFile)
+
+	busy := filepath.Join(dir, "busy.sock")
+	l, err := net.Listen("unix", busy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	tests := []struct {
+		name string
+		cfg  config.ServerConfig
+		want string
+	}{
+		{
+			name: "tls without certificate",
+			cfg:  config.ServerConfig{Listeners: []config.ListenerConfig{{Network: "tcp", Address: "127.0.0.1:0", TLS: true}}},
+			want: "certFile",
+		},
+		{
+			name: "old tls version",
+			cfg:  config.ServerConfig{Port: "127.0.0.1:0", TLS: config.TLSConfig{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.0"}},
+			want: "minVersion",
+		},
+		{
+			name: "insecure cipher suite",
+			cfg: config.ServerConfig{Port: "127.0.0.1:0", TLS: config.TLSConfig{CertFile: certFile, KeyFile: keyFile,
+				CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}}},
+			want: "cipher suite",
+		},
+		{
+			name: "unknown network",
+			cfg:  config.ServerConfig{Listeners: []config.ListenerConfig{{Network: "udp", Address: "127.0.0.1:0"}}},
+			want: "network",
+		},
+		{
+			name: "socket in use",
+			cfg:  config.ServerConfig{Listeners: []config.ListenerConfig{{Network: "unix", Address: busy}}},
+			want: "in use",
+		},
+		{
+			name: "bad socket mode",
+			cfg:  config.ServerConfig{Listeners: []config.ListenerConfig{{Network: "unix", Address: filepath.Join(dir, "mode.sock"), Mode: "rw"}}},
+			want: "mode",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := hhttpserver.NewHttpServer(logger, mocks.NewMockStorage(nil), nil, nil, config.Config{Server: tt.cfg})
+			err := srv.RunServer()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error about %s, got %v", tt.want, err)
+			}
+			srv.GraceFullShutdown(context.Background())
+		})
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package hhttpserver
+
+func withUmask(mask int, fn func() error) error {
+	return fn()
+}
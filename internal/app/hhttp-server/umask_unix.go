@@ -0,0 +1,23 @@
+//go:build unix
+
+package hhttpserver
+
+import (
+	"sync"
+	"syscall"
+)
+
+var umaskMu sync.Mutex
+
+// withUmask adds mask to the process umask while fn runs. The umask is process wide, so files
+// other goroutines create meanwhile can only come out stricter, never looser.
+func withUmask(mask int, fn func() error) error {
+	umaskMu.Lock()
+	defer umaskMu.Unlock()
+
+	old := syscall.Umask(0o777)
+	syscall.Umask(old | mask)
+	defer syscall.Umask(old)
+
+	return fn()
+}
@@ -0,0 +1,134 @@
+package leader
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/lib/clock"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+const (
+	defaultLeaseTTL      = 15 * time.Second
+	defaultRenewInterval = 5 * time.Second
+)
+
+// Elector always leads on storages without a LeaseStore.
+type Elector struct {
+	store  storage.LeaseStore
+	logger *slog.Logger
+	clock  clock.Clock
+	id     string
+	ttl    time.Duration
+	renew  time.Duration
+	leader atomic.Bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func NewElector(logger *slog.Logger, st storage.Storage, cfg config.LeaderConfig) *Elector {
+	e := &Elector{
+		logger: logger,
+		clock:  services.ClockOf(st),
+		id:     cfg.ID,
+		ttl:    cfg.LeaseTTL.Duration(),
+		renew:  cfg.RenewInterval.Duration(),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if e.id == "" {
+		e.id = DefaultID()
+	}
+	if e.ttl <= 0 {
+		e.ttl = defaultLeaseTTL
+	}
+	if e.renew <= 0 || e.renew >= e.ttl {
+		e.renew = min(defaultRenewInterval, e.ttl/3)
+	}
+
+	store, ok := st.(storage.LeaseStore)
+	if ok {
+		e.store = store
+	} else {
+		e.leader.Store(true)
+	}
+	return e
+}
+
+// DefaultID names this process so lease holders can be told apart in /readyz.
+func DefaultID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+func (e *Elector) ID() string {
+	return e.id
+}
+
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// StartElection makes the first attempt before returning.
+func (e *Elector) StartElection() {
+	if e.store == nil {
+		close(e.done)
+		return
+	}
+
+	e.heartbeat()
+	ticker := e.clock.NewTicker(e.renew)
+	go func() {
+		defer close(e.done)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-e.stop:
+				return
+			case <-ticker.C():
+				e.heartbeat()
+			}
+		}
+	}()
+}
+
+func (e *Elector) GraceFullShutdown() {
+	if e.store == nil {
+		return
+	}
+	close(e.stop)
+	<-e.done
+
+	if e.leader.Swap(false) {
+		if err := e.store.ReleaseLease(services.CheckerLease, e.id, e.logger); err != nil {
+			e.logger.Error("error on releasing leader lease", sl.Err(err))
+		}
+	}
+}
+
+func (e *Elector) heartbeat() {
+	now := e.clock.Now()
+	lease, acquired, err := e.store.AcquireLease(services.CheckerLease, e.id, now, now.Add(e.ttl), e.logger)
+	if err != nil {
+		e.logger.Error("error on renewing leader lease", sl.Err(err))
+		acquired = false
+	}
+
+	if was := e.leader.Swap(acquired); was != acquired {
+		if acquired {
+			e.logger.Info("became leader", slog.String("id", e.id))
+		} else {
+			e.logger.Info("lost leadership", slog.String("id", e.id), slog.String("holder", lease.Holder))
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package leader_test
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/app/leader"
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/lib/clock/fakeclock"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	mocks "github.com/gintokos/tasksrestapi/internal/storage/mock"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+)
+
+var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func openShared(t *testing.T, path string, fc *fakeclock.Clock) *sqllite.Storage {
+	t.Helper()
+
+	st, err := sqllite.NewStorage(path, id.NewRandomGenerator(), sqllite.Options{JournalMode: "WAL", BusyTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.SetClock(fc)
+	return st
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestElector_FailsOverWhenLeaderDies(t *testing.T) {
+	fc := fakeclock.New(time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC))
+	path := filepath.Join(t.TempDir(), "storage.db")
+	cfg := config.LeaderConfig{LeaseTTL: 15, RenewInterval: 5}
+
+	stA := openShared(t, path, fc)
+	stB := openShared(t, path, fc)
+	defer stB.Close()
+
+	cfg.ID = "a"
+	a := leader.NewElector(logger, stA, cfg)
+	cfg.ID = "b"
+	b := leader.NewElector(logger, stB, cfg)
+	defer b.GraceFullShutdown()
+
+	a.StartElection()
+	b.StartElection()
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("expected a to lead and b to follow, got a=%v b=%v", a.IsLeader(), b.IsLeader())
+	}
+
+	// Heartbeats keep a in charge for as long as it keeps renewing.
+	for i := 0; i < 10; i++ {
+		fc.Advance(5 * time.Second)
+		renewedUntil := fc.Now().Add(15 * time.Second)
+		waitFor(t, "a to renew", func() bool {
+			lease, err := stB.GetLease(services.CheckerLease, logger)
+			return err == nil && lease.Holder == "a" && lease.ExpiresAt.Equal(renewedUntil)
+		})
+		if b.IsLeader() {
+			t.Fatalf("expected b to stay a follower while a renews")
+		}
+	}
+
+	// a dies without releasing: its storage goes away, so renewals fail and the lease runs out.
+	stA.Close()
+	fc.Advance(20 * time.Second)
+	waitFor(t, "b to take over", b.IsLeader)
+	waitFor(t, "a to step down", func() bool { return !a.IsLeader() })
+
+	leadership, err := services.GetLeadership("b", fc.Now(), stB, logger)
+	if err != nil || !leadership.Leader || leadership.Holder != "b" {
+		t.Fatalf("expected the storage to report b as leader, got %+v (err %v)", leadership, err)
+	}
+}
+
+func TestElector_ReleasesOnShutdown(t *testing.T) {
+	fc := fakeclock.New(time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC))
+	path := filepath.Join(t.TempDir(), "storage.db")
+	stA := openShared(t, path, fc)
+	defer stA.Close()
+	stB := openShared(t, path, fc)
+	defer stB.Close()
+
+	a := leader.NewElector(logger, stA, config.LeaderConfig{ID: "a", LeaseTTL: 15, RenewInterval: 5})
+	b := leader.NewElector(logger, stB, config.LeaderConfig{ID: "b", LeaseTTL: 15, RenewInterval: 5})
+	defer b.GraceFullShutdown()
+
+	a.StartElection()
+	b.StartElection()
+	a.GraceFullShutdown()
+
+	// b does not have to wait out the TTL, its next heartbeat finds the lease free.
+	fc.WaitForTickers(1)
+	fc.Advance(5 * time.Second)
+	waitFor(t, "b to take over after a released", b.IsLeader)
+}
+
+func TestElector_AlwaysLeadsWithoutLeases(t *testing.T) {
+	e := leader.NewElector(logger, mocks.NewMockStorage(nil), config.LeaderConfig{})
+	e.StartElection()
+	defer e.GraceFullShutdown()
+
+	if !e.IsLeader() || e.ID() == "" {
+		t.Fatalf("expected a storage without leases to make this instance leader, got leader=%v id=%q", e.IsLeader(), e.ID())
+	}
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/jobs"
+	"github.com/gintokos/tasksrestapi/internal/lib/clock"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+)
+
+type configState struct {
+	clock   clock.Clock
+	mu      sync.Mutex
+	started config.Config
+	active  config.Config
+	status  models.ConfigStatus
+}
+
+func newConfigState(cfg config.Config, clk clock.Clock) *configState {
+	return &configState{
+		clock:   clk,
+		started: cfg,
+		active:  cfg,
+		status: models.ConfigStatus{
+			Version:         1,
+			Checksum:        cfg.Checksum(),
+			LoadedAt:        clk.Now(),
+			RestartRequired: []string{},
+		},
+	}
+}
+
+func (s *configState) ConfigStatus() models.ConfigStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.snapshot()
+}
+
+func (s *configState) snapshot() models.ConfigStatus {
+	status := s.status
+	status.RestartRequired = slices.Clone(status.RestartRequired)
+	return status
+}
+
+// liveSetting: logConfig.level is left to whoever owns the logger.
+func liveSetting(path string) bool {
+	switch path {
+	case "checkerConfig.delay", "logConfig.level":
+		return true
+	}
+	if rest, ok := strings.CutPrefix(path, "jobsConfig."); ok {
+		return strings.HasSuffix(rest, ".interval") || strings.HasSuffix(rest, ".jitter")
+	}
+	return false
+}
+
+// Reload applies the checker delay and job schedules; cfg should already be validated.
+func (a *App) Reload(cfg config.Config) (models.ConfigStatus, error) {
+	s := a.config
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed, err := config.Changed(s.active, cfg)
+	if err != nil {
+		return s.snapshot(), err
+	}
+	if len(changed) == 0 {
+		return s.snapshot(), nil
+	}
+	sinceStart, err := config.Changed(s.started, cfg)
+	if err != nil {
+		return s.snapshot(), err
+	}
+
+	if cfg.Checker.Delay != s.active.Checker.Delay {
+		a.checker.SetDelay(cfg.Checker.Delay.Duration())
+	}
+	for _, job := range slices.Concat(a.checker.Jobs(), a.queue.Jobs(), a.backuper.Jobs()) {
+		job, enabled := jobs.Configure(job, cfg.Jobs)
+		if !enabled || job.Interval <= 0 {
+			continue
+		}
+		if err := a.scheduler.Reschedule(job.Name, job.Interval, job.Jitter); err != nil {
+			a.logger.Info("job was not rescheduled", slog.String("job", job.Name), sl.Err(err))
+		}
+	}
+
+	restart := make([]string, 0)
+	for _, path := range sinceStart {
+		if !liveSetting(path) {
+			restart = append(restart, path)
+		}
+	}
+
+	s.active = cfg
+	s.status = models.ConfigStatus{
+		Version:         s.status.Version + 1,
+		Checksum:        cfg.Checksum(),
+		LoadedAt:        s.clock.Now(),
+		RestartRequired: restart,
+	}
+	return s.snapshot(), nil
+}
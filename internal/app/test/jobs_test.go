@@ -0,0 +1,64 @@
+package app_test
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/app"
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/lib/clock/fakeclock"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/notify"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+)
+
+func TestApp_Jobs(t *testing.T) {
+	fc := fakeclock.New(time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC))
+	st, err := sqllite.NewStorage(filepath.Join(t.TempDir(), "storage.db"), id.NewRandomGenerator(), sqllite.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+	st.SetClock(fc)
+
+	dir := t.TempDir()
+	cfg := config.Default()
+	cfg.Backup = config.BackupConfig{Dir: dir, Keep: 2, Interval: 3600}
+	cfg.Checker.TrashRetention = 86400
+	cfg.Queue.Retention = 86400
+	a := app.NewApp(st, notify.NewLogNotifier(logger), logger, cfg)
+	a.StartJobs()
+	t.Cleanup(func() { a.GraceFullShutdown(context.Background()) })
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		backups, err := services.ListBackups(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(backups) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the leader to take a backup, got %d", len(backups))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	statuses := a.JobStatuses()
+	if len(statuses) != len(config.JobNames) {
+		t.Fatalf("expected every job in config.JobNames to run, got %+v", statuses)
+	}
+	for _, status := range statuses {
+		if !slices.Contains(config.JobNames, status.Name) {
+			t.Errorf("expected %s in config.JobNames so jobsConfig accepts it", status.Name)
+		}
+		if status.Name == "backup" && (!status.LeaderOnly || status.Interval != 3600) {
+			t.Errorf("expected a leader-only hourly backup job, got %+v", status)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package app_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/app"
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/lib/clock/fakeclock"
+	"github.com/gintokos/tasksrestapi/internal/notify"
+	mocks "github.com/gintokos/tasksrestapi/internal/storage/mock"
+)
+
+var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestApp_Reload(t *testing.T) {
+	fc := fakeclock.New(time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC))
+	st := mocks.NewMockStorage(nil)
+	st.SetClock(fc)
+	cfg := config.Default()
+	a := app.NewApp(st, notify.NewLogNotifier(logger), logger, cfg)
+	a.StartJobs()
+	t.Cleanup(func() { a.GraceFullShutdown(context.Background()) })
+	waitForSchedule(t, a, fc.Now(), map[string]time.Duration{
+		"overdue":   60 * time.Second,
+		"reminders": 60 * time.Second,
+	})
+
+	status, err := a.Reload(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != 1 || len(status.RestartRequired) != 0 {
+		t.Fatalf("expected an unchanged config to keep version 1, got %+v", status)
+	}
+
+	live := cfg
+	live.Checker.Delay = 30
+	live.Log.Level = "info"
+	live.Jobs = config.JobsConfig{"overdue": {Interval: 120, Jitter: 5}}
+	status, err = a.Reload(live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != 2 || len(status.RestartRequired) != 0 || status.Checksum != live.Checksum() {
+		t.Fatalf("expected the live changes to apply without a restart, got %+v", status)
+	}
+	waitForSchedule(t, a, fc.Now(), map[string]time.Duration{
+		"overdue":   120 * time.Second,
+		"reminders": 30 * time.Second,
+	})
+
+	restart := live
+	restart.Server.Port = ":9090"
+	restart.Jobs = config.JobsConfig{"overdue": {Interval: 120, Jitter: 5, Disabled: true}}
+	if _, err := a.Reload(restart); err != nil {
+		t.Fatal(err)
+	}
+	// Going back to the old port still leaves the disabled job waiting for a restart.
+	back := restart
+	back.Server.Port = cfg.Server.Port
+	status, err = a.Reload(back)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != 4 || len(status.RestartRequired) != 1 || status.RestartRequired[0] != "jobsConfig.overdue.disabled" {
+		t.Fatalf("expected only the disabled job to need a restart, got %+v", status)
+	}
+}
+
+// waitForSchedule waits until every job in want shows its interval and a next run that interval
+// after now, allowing for up to 5s of jitter. The jobs pick up a reload on their own goroutines.
+func waitForSchedule(t *testing.T, a app.App, now time.Time, want map[string]time.Duration) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var pending []string
+		for _, st := range a.JobStatuses() {
+			interval, ok := want[st.Name]
+			if !ok {
+				continue
+			}
+			min := now.Add(interval)
+			if st.Interval != int64(interval/time.Second) || st.NextRun == nil || st.NextRun.Before(min) || st.NextRun.After(min.Add(5*time.Second)) {
+				pending = append(pending, fmt.Sprintf("%s: interval %d, next run %v", st.Name, st.Interval, st.NextRun))
+			}
+		}
+		if len(pending) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the reloaded schedule to apply, got %v", pending)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -1,45 +1,153 @@
 package config
 
-import (
-	"encoding/json"
-	"log"
-	"os"
-)
+import "log/slog"
 
 type Config struct {
-	Sql     SqlConfig     `json:"sqlConfig"`
-	Server  ServerConfig  `json:"serverConfig"`
-	Checker CheckerConfig `json:"checkerConfig"`
+	Storage  StorageConfig  `json:"storage"`
+	Sql      SqlConfig      `json:"sqlConfig"`
+	Server   ServerConfig   `json:"serverConfig"`
+	Checker  CheckerConfig  `json:"checkerConfig"`
+	ID       IDConfig       `json:"idConfig"`
+	Backup   BackupConfig   `json:"backupConfig"`
+	Notifier NotifierConfig `json:"notifierConfig"`
+	Leader   LeaderConfig   `json:"leaderConfig"`
+	Jobs     JobsConfig     `json:"jobsConfig"`
+	Queue    QueueConfig    `json:"queueConfig"`
+	Log      LogConfig      `json:"logConfig"`
+}
+
+type LogConfig struct {
+	Level string `json:"level"`
+}
+
+// SlogLevel falls back to debug for a level Validate would reject.
+func (c LogConfig) SlogLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+		return slog.LevelDebug
+	}
+	return level
+}
+
+// QueueConfig: a Retention of zero keeps finished jobs forever.
+type QueueConfig struct {
+	Workers      int     `json:"workers"`
+	PollInterval Seconds `json:"pollInterval"`
+	LeaseTTL     Seconds `json:"leaseTtl"`
+	MaxAttempts  int     `json:"maxAttempts"`
+	BackoffBase  Seconds `json:"backoffBase"`
+	BackoffMax   Seconds `json:"backoffMax"`
+	Retention    Seconds `json:"retention"`
+}
+
+type JobsConfig map[string]JobConfig
+
+// JobNames lists the jobs the app registers, the only keys jobsConfig accepts.
+var JobNames = []string{"overdue", "reminders", "idempotency-purge", "trash-purge", "queue-purge", "backup"}
+
+type JobConfig struct {
+	Interval Seconds `json:"interval"`
+	Jitter   Seconds `json:"jitter"`
+	Disabled bool    `json:"disabled"`
+}
+
+// LeaderConfig: an empty ID is filled from the host name and process id.
+type LeaderConfig struct {
+	ID            string  `json:"id"`
+	LeaseTTL      Seconds `json:"leaseTtl"`
+	RenewInterval Seconds `json:"renewInterval"`
+}
+
+type NotifierConfig struct {
+	Kind    string        `json:"kind"`
+	Webhook WebhookConfig `json:"webhook"`
+	SMTP    SMTPConfig    `json:"smtp"`
+}
+
+type WebhookConfig struct {
+	URL     string  `json:"url"`
+	Timeout Seconds `json:"timeout"`
+}
+
+type SMTPConfig struct {
+	Addr     string   `json:"addr"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Username string   `json:"username"`
+	Password string   `json:"password" secret:"true"`
+	Timeout  Seconds  `json:"timeout"`
+}
+
+type BackupConfig struct {
+	Dir      string  `json:"dir"`
+	Keep     int     `json:"keep"`
+	Interval Seconds `json:"interval"`
+}
+
+type IDConfig struct {
+	Generator string `json:"generator"`
+	NodeID    int64  `json:"nodeId"`
 }
 
 type CheckerConfig struct {
-	Delay int64 `json:"delay"`
+	Delay          Seconds `json:"delay"`
+	TrashRetention Seconds `json:"trashRetention"`
 }
 
-type SqlConfig struct {
-	Storagepath string `json:"storagepath"`
+type StorageConfig struct {
+	Driver  string        `json:"driver"`
+	Memory  MemoryConfig  `json:"memory"`
+	Journal JournalConfig `json:"journal"`
 }
 
-type ServerConfig struct {
-	Port              string `json:"port"`
-	ReadTimeout       int    `json:"readTimeout"`
-	WriteTimeout      int    `json:"writeTimeout"`
-	IdleTimeout       int    `json:"idleTimeout"`
-	ReadHeaderTimeout int    `json:"readHeaderTimeout"`
+type JournalConfig struct {
+	Dir             string  `json:"dir"`
+	Fsync           string  `json:"fsync"`
+	FsyncInterval   Seconds `json:"fsyncInterval"`
+	CompactInterval Seconds `json:"compactInterval"`
 }
 
-func MustLoad(configPath string) Config {
-	var cfg Config
+type MemoryConfig struct {
+	SnapshotPath     string  `json:"snapshotPath"`
+	SnapshotInterval Seconds `json:"snapshotInterval"`
+}
 
-	file, err := os.OpenFile(configPath, os.O_RDONLY, 0644)
-	if err != nil {
-		log.Fatalln("err on opening config file, err: ", err)
-	}
+type SqlConfig struct {
+	Storagepath     string  `json:"storagepath"`
+	JournalMode     string  `json:"journalMode"`
+	Synchronous     string  `json:"synchronous"`
+	BusyTimeout     int64   `json:"busyTimeoutMs"`
+	ForeignKeys     bool    `json:"foreignKeys"`
+	MaxOpenConns    int     `json:"maxOpenConns"`
+	MaxIdleConns    int     `json:"maxIdleConns"`
+	ConnMaxLifetime Seconds `json:"connMaxLifetime"`
+}
 
-	err = json.NewDecoder(file).Decode(&cfg)
-	if err != nil {
-		log.Fatalln("err on decoding config file, err: ", err)
-	}
+// ServerConfig listens on Port unless Listeners is set.
+type ServerConfig struct {
+	Port              string           `json:"port"`
+	Listeners         []ListenerConfig `json:"listeners"`
+	TLS               TLSConfig        `json:"tls"`
+	ReadTimeout       Seconds          `json:"readTimeout"`
+	WriteTimeout      Seconds          `json:"writeTimeout"`
+	IdleTimeout       Seconds          `json:"idleTimeout"`
+	ReadHeaderTimeout Seconds          `json:"readHeaderTimeout"`
+	IdempotencyTTL    Seconds          `json:"idempotencyTTL"`
+}
+
+// ListenerConfig: Mode is an octal string such as "0660" for Unix sockets.
+type ListenerConfig struct {
+	Network string `json:"network"`
+	Address string `json:"address"`
+	Mode    string `json:"mode"`
+	TLS     bool   `json:"tls"`
+}
 
-	return cfg
+// TLSConfig: CipherSuites only apply to TLS 1.2.
+type TLSConfig struct {
+	CertFile       string   `json:"certFile"`
+	KeyFile        string   `json:"keyFile"`
+	MinVersion     string   `json:"minVersion"`
+	CipherSuites   []string `json:"cipherSuites"`
+	ReloadInterval Seconds  `json:"reloadInterval"`
 }
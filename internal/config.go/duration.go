@@ -0,0 +1,49 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Seconds is a duration configured in whole seconds. Besides a number it accepts a duration string
+// such as "90s" or "1h30m".
+type Seconds int64
+
+func (s Seconds) Duration() time.Duration {
+	return time.Duration(s) * time.Second
+}
+
+func (s *Seconds) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return s.UnmarshalText([]byte(text))
+	}
+
+	var n int64
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf(`must be a number of seconds or a duration such as "60s", got %s`, data)
+	}
+	*s = Seconds(n)
+	return nil
+}
+
+func (s *Seconds) UnmarshalText(text []byte) error {
+	raw := strings.TrimSpace(string(text))
+	if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		*s = Seconds(n)
+		return nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return fmt.Errorf(`must be a number of seconds or a duration such as "60s", got %q`, raw)
+	}
+	if d%time.Second != 0 {
+		return fmt.Errorf("must be a whole number of seconds, got %q", raw)
+	}
+	*s = Seconds(d / time.Second)
+	return nil
+}
@@ -0,0 +1,552 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// DefaultPath may be missing, unlike a file named by -config or TASKS_CONFIG.
+	DefaultPath = "config.json"
+
+	EnvPrefix     = "TASKS_"
+	EnvConfigPath = "TASKS_CONFIG"
+)
+
+// Sources maps the dotted JSON path of a setting to the layer that set it.
+type Sources map[string]string
+
+// LoadOptions: TASKS_* names in Environ that match no setting are ignored and reported to Logger.
+type LoadOptions struct {
+	Path    string
+	Environ []string
+	Sets    []string
+	Logger  *slog.Logger
+}
+
+type Setting struct {
+	Path   string
+	Value  string
+	Source string
+	Secret bool
+}
+
+var (
+	configType  = reflect.TypeOf(Config{})
+	secondsType = reflect.TypeOf(Seconds(0))
+)
+
+func Default() Config {
+	return Config{
+		Storage: StorageConfig{
+			Driver:  "sqlite",
+			Memory:  MemoryConfig{SnapshotPath: "./storage/memory/snapshot.json", SnapshotInterval: 30},
+			Journal: JournalConfig{Dir: "./storage/journal", Fsync: "always", FsyncInterval: 1, CompactInterval: 300},
+		},
+		Sql: SqlConfig{
+			Storagepath:     "./storage/sqlLite/storage.db",
+			JournalMode:     "WAL",
+			Synchronous:     "NORMAL",
+			BusyTimeout:     5000,
+			ForeignKeys:     true,
+			MaxOpenConns:    8,
+			MaxIdleConns:    8,
+			ConnMaxLifetime: 3600,
+		},
+		Server: ServerConfig{
+			Port:              ":8080",
+			Listeners:         []ListenerConfig{},
+			TLS:               TLSConfig{MinVersion: "1.2", CipherSuites: []string{}, ReloadInterval: 60},
+			ReadTimeout:       10,
+			WriteTimeout:      10,
+			IdleTimeout:       60,
+			ReadHeaderTimeout: 10,
+			IdempotencyTTL:    86400,
+		},
+		Checker:  CheckerConfig{Delay: 60, TrashRetention: 2592000},
+		ID:       IDConfig{Generator: "snowflake"},
+		Backup:   BackupConfig{Dir: "./storage/backups", Keep: 7, Interval: 86400},
+		Notifier: NotifierConfig{Kind: "log", Webhook: WebhookConfig{Timeout: 10}, SMTP: SMTPConfig{To: []string{}, Timeout: 10}},
+		Leader:   LeaderConfig{LeaseTTL: 15, RenewInterval: 5},
+		Jobs:     JobsConfig{},
+		Queue: QueueConfig{
+			Workers:      4,
+			PollInterval: 1,
+			LeaseTTL:     300,
+			MaxAttempts:  5,
+			BackoffBase:  10,
+			BackoffMax:   3600,
+			Retention:    604800,
+		},
+		Log: LogConfig{Level: "debug"},
+	}
+}
+
+// Load layers the defaults, the file, TASKS_* env and -set flags. A config that fails Validate is
+// still returned with the errors.
+func Load(opts LoadOptions) (Config, Sources, error) {
+	sources := Sources{}
+	tree, err := toTree(Default())
+	if err != nil {
+		return Config{}, nil, err
+	}
+	markLeaves(tree, "", "default", sources)
+
+	env := make(map[string]string)
+	for _, kv := range opts.Environ {
+		if name, value, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(name, EnvPrefix) {
+			env[name] = value
+		}
+	}
+
+	path, required := opts.Path, true
+	if path == "" {
+		path = env[EnvConfigPath]
+	}
+	if path == "" {
+		path, required = DefaultPath, false
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		layer, err := decodeTree(data)
+		if err != nil {
+			return Config{}, nil, fmt.Errorf("decoding config file %s: %w", path, err)
+		}
+		if err := checkLayer(layer, configType, ""); err != nil {
+			return Config{}, nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+		mergeTree(tree, layer, "", "file "+path, sources)
+	case errors.Is(err, fs.ErrNotExist) && !required:
+	default:
+		return Config{}, nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		if name != EnvConfigPath {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		segments, ok := envPath(name)
+		if !ok {
+			if opts.Logger != nil {
+				opts.Logger.Warn("ignoring environment variable that matches no setting", slog.String("name", name))
+			}
+			continue
+		}
+		if err := set(tree, segments, env[name], "env "+name, sources); err != nil {
+			return Config{}, nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	for _, kv := range opts.Sets {
+		path, value, ok := strings.Cut(kv, "=")
+		if !ok || path == "" {
+			return Config{}, nil, fmt.Errorf("-set %q: expected path=value", kv)
+		}
+		if err := set(tree, strings.Split(path, "."), value, "flag -set", sources); err != nil {
+			return Config{}, nil, fmt.Errorf("-set %s: %w", path, err)
+		}
+	}
+
+	data, err = json.Marshal(tree)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, nil, fmt.Errorf("decoding config: %w", err)
+	}
+	return cfg, sources, cfg.Validate()
+}
+
+// LoadFile reads the config at path on top of the defaults, without environment variables or
+// flags.
+func LoadFile(path string) (Config, error) {
+	cfg, _, err := Load(LoadOptions{Path: path})
+	return cfg, err
+}
+
+func Settings(cfg Config, sources Sources) ([]Setting, error) {
+	tree, err := toTree(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var settings []Setting
+	err = walkLeaves(tree, "", func(path string, value any) error {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		settings = append(settings, Setting{Path: path, Value: string(data), Source: sources.of(path), Secret: isSecret(path)})
+		return nil
+	})
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Path < settings[j].Path })
+	return settings, err
+}
+
+// Changed treats a setting missing on one side as its zero value.
+func Changed(a, b Config) ([]string, error) {
+	before, err := Settings(a, nil)
+	if err != nil {
+		return nil, err
+	}
+	after, err := Settings(b, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(before))
+	for _, s := range before {
+		values[s.Path] = s.Value
+	}
+	var changed []string
+	for _, s := range after {
+		value, ok := values[s.Path]
+		if (ok && value != s.Value) || (!ok && !zeroJSON(s.Value)) {
+			changed = append(changed, s.Path)
+		}
+		delete(values, s.Path)
+	}
+	for path, value := range values {
+		if !zeroJSON(value) {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+func zeroJSON(value string) bool {
+	switch value {
+	case "null", "false", "0", `""`, "[]", "{}":
+		return true
+	}
+	return false
+}
+
+func (c Config) Checksum() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// of falls back to the closest parent that was set whole.
+func (s Sources) of(path string) string {
+	for p := path; p != ""; {
+		if source, ok := s[p]; ok {
+			return source
+		}
+		i := strings.LastIndex(p, ".")
+		if i < 0 {
+			break
+		}
+		p = p[:i]
+	}
+	return "default"
+}
+
+func (s Sources) record(path, source string) {
+	for p := range s {
+		if strings.HasPrefix(p, path+".") || strings.HasPrefix(path, p+".") {
+			delete(s, p)
+		}
+	}
+	s[path] = source
+}
+
+func toTree(cfg Config) (map[string]any, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTree(data)
+}
+
+func decodeTree(data []byte) (map[string]any, error) {
+	var tree map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func walkLeaves(tree map[string]any, prefix string, fn func(path string, value any) error) error {
+	keys := make([]string, 0, len(tree))
+	for k := range tree {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		path := joinPath(prefix, k)
+		if m, ok := tree[k].(map[string]any); ok && len(m) > 0 {
+			if err := walkLeaves(m, path, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(path, tree[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func markLeaves(tree map[string]any, prefix, source string, sources Sources) {
+	walkLeaves(tree, prefix, func(path string, _ any) error {
+		sources.record(path, source)
+		return nil
+	})
+}
+
+func mergeTree(dst, src map[string]any, prefix, source string, sources Sources) {
+	for k, v := range src {
+		path := joinPath(prefix, k)
+		if m, ok := v.(map[string]any); ok {
+			sub, ok := dst[k].(map[string]any)
+			if !ok {
+				sub = make(map[string]any)
+				dst[k] = sub
+			}
+			mergeTree(sub, m, path, source, sources)
+			continue
+		}
+		dst[k] = v
+		sources.record(path, source)
+	}
+}
+
+func childType(t reflect.Type, key string) (reflect.Type, bool) {
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name == key {
+				return f.Type, true
+			}
+		}
+	case reflect.Map:
+		if key != "" {
+			return t.Elem(), true
+		}
+	}
+	return nil, false
+}
+
+func isSecret(path string) bool {
+	t := configType
+	segments := strings.Split(path, ".")
+	for i, key := range segments {
+		if i == len(segments)-1 && t.Kind() == reflect.Struct {
+			for j := 0; j < t.NumField(); j++ {
+				f := t.Field(j)
+				if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name == key {
+					return f.Tag.Get("secret") == "true"
+				}
+			}
+			return false
+		}
+		next, ok := childType(t, key)
+		if !ok {
+			return false
+		}
+		t = next
+	}
+	return false
+}
+
+func isObject(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct || t.Kind() == reflect.Map
+}
+
+func checkLayer(layer map[string]any, t reflect.Type, prefix string) error {
+	keys := make([]string, 0, len(layer))
+	for k := range layer {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		path := joinPath(prefix, k)
+		ft, ok := childType(t, k)
+		if !ok {
+			return fmt.Errorf("unknown setting %s", path)
+		}
+		if m, ok := layer[k].(map[string]any); ok && isObject(ft) {
+			if err := checkLayer(m, ft, path); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := checkValue(layer[k], ft); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func checkValue(value any, t reflect.Type) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(reflect.New(t).Interface())
+}
+
+func set(tree map[string]any, segments []string, raw, source string, sources Sources) error {
+	t := configType
+	for _, s := range segments {
+		var ok bool
+		if t, ok = childType(t, s); !ok {
+			return fmt.Errorf("unknown setting %s", strings.Join(segments, "."))
+		}
+	}
+	value, err := parseValue(raw, t)
+	if err != nil {
+		return err
+	}
+	if err := checkValue(value, t); err != nil {
+		return err
+	}
+
+	node := tree
+	for _, s := range segments[:len(segments)-1] {
+		next, ok := node[s].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			node[s] = next
+		}
+		node = next
+	}
+	node[segments[len(segments)-1]] = value
+	sources.record(strings.Join(segments, "."), source)
+	return nil
+}
+
+// parseValue: lists of strings are comma separated, other lists and objects are JSON.
+func parseValue(raw string, t reflect.Type) (any, error) {
+	switch {
+	case t == secondsType:
+		var s Seconds
+		if err := s.UnmarshalText([]byte(raw)); err != nil {
+			return nil, err
+		}
+		return int64(s), nil
+	case t.Kind() == reflect.String:
+		return raw, nil
+	case t.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("must be true or false, got %q", raw)
+		}
+		return b, nil
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, t.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("must be an integer, got %q", raw)
+		}
+		return n, nil
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String:
+		items := []any{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	default:
+		var value any
+		dec := json.NewDecoder(strings.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&value); err != nil {
+			return nil, fmt.Errorf("must be JSON: %w", err)
+		}
+		return value, nil
+	}
+}
+
+// envPath maps e.g. TASKS_JOBS_TRASH_PURGE_INTERVAL to jobsConfig.trash-purge.interval.
+func envPath(name string) ([]string, bool) {
+	return matchEnv(strings.TrimPrefix(name, EnvPrefix), configType)
+}
+
+func matchEnv(rest string, t reflect.Type) ([]string, bool) {
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			name := envSegment(key)
+			if rest == name {
+				return []string{key}, true
+			}
+			if after, ok := strings.CutPrefix(rest, name+"_"); ok && isObject(t.Field(i).Type) {
+				if tail, ok := matchEnv(after, t.Field(i).Type); ok {
+					return append([]string{key}, tail...), true
+				}
+			}
+		}
+	case reflect.Map:
+		for i := strings.Index(rest, "_"); i > 0; i = nextUnderscore(rest, i) {
+			if tail, ok := matchEnv(rest[i+1:], t.Elem()); ok {
+				key := strings.ReplaceAll(strings.ToLower(rest[:i]), "_", "-")
+				return append([]string{key}, tail...), true
+			}
+		}
+	}
+	return nil, false
+}
+
+func nextUnderscore(s string, i int) int {
+	j := strings.Index(s[i+1:], "_")
+	if j < 0 {
+		return -1
+	}
+	return i + 1 + j
+}
+
+func envSegment(key string) string {
+	key = strings.TrimSuffix(key, "Config")
+	runes := []rune(key)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := !unicode.IsUpper(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
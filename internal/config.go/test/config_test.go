@@ -0,0 +1,351 @@
+package config_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeConfig(t, `{
+		"serverConfig": {"port": ":8081", "readTimeout": "30s", "tls": {"reloadInterval": "2m"}},
+		"checkerConfig": {"delay": 120},
+		"jobsConfig": {"overdue": {"interval": "2m"}}
+	}`)
+
+	cfg, sources, err := config.Load(config.LoadOptions{
+		Path: path,
+		Environ: []string{
+			"HOME=/root",
+			"TASKS_SERVER_PORT=:8082",
+			"TASKS_CHECKER_DELAY=1m30s",
+			"TASKS_SERVER_TLS_CIPHER_SUITES=TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+			"TASKS_JOBS_TRASH_PURGE_DISABLED=true",
+		},
+		Sets: []string{"serverConfig.port=:8083", "sqlConfig.maxOpenConns=2", "sqlConfig.maxIdleConns=2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Server.Port != ":8083" {
+		t.Errorf("expected the flag to win for the port, got %s", cfg.Server.Port)
+	}
+	if cfg.Checker.Delay.Duration() != 90*time.Second {
+		t.Errorf("expected the env delay of 90s, got %v", cfg.Checker.Delay.Duration())
+	}
+	if cfg.Server.ReadTimeout != 30 || cfg.Server.TLS.ReloadInterval != 120 {
+		t.Errorf("expected the file to set readTimeout and reloadInterval, got %d %d", cfg.Server.ReadTimeout, cfg.Server.TLS.ReloadInterval)
+	}
+	if cfg.Server.WriteTimeout != 10 || cfg.Sql.Storagepath != "./storage/sqlLite/storage.db" {
+		t.Errorf("expected defaults for settings nobody set, got %d %s", cfg.Server.WriteTimeout, cfg.Sql.Storagepath)
+	}
+	if len(cfg.Server.TLS.CipherSuites) != 2 || cfg.Server.TLS.CipherSuites[1] != "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256" {
+		t.Errorf("expected two cipher suites from the env, got %v", cfg.Server.TLS.CipherSuites)
+	}
+	if cfg.Jobs["overdue"].Interval != 120 || !cfg.Jobs["trash-purge"].Disabled {
+		t.Errorf("expected job overrides from the file and env, got %+v", cfg.Jobs)
+	}
+	if cfg.Sql.MaxOpenConns != 2 {
+		t.Errorf("expected maxOpenConns from the flag, got %d", cfg.Sql.MaxOpenConns)
+	}
+
+	want := map[string]string{
+		"serverConfig.port":               "flag -set",
+		"sqlConfig.maxOpenConns":          "flag -set",
+		"checkerConfig.delay":             "env TASKS_CHECKER_DELAY",
+		"serverConfig.tls.cipherSuites":   "env TASKS_SERVER_TLS_CIPHER_SUITES",
+		"jobsConfig.trash-purge.disabled": "env TASKS_JOBS_TRASH_PURGE_DISABLED",
+		"serverConfig.readTimeout":        "file " + path,
+		"serverConfig.tls.reloadInterval": "file " + path,
+		"jobsConfig.overdue.interval":     "file " + path,
+		"checkerConfig.trashRetention":    "default",
+		"serverConfig.idempotencyTTL":     "default",
+		"storage.journal.compactInterval": "default",
+	}
+
+	settings, err := config.Settings(cfg, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, s := range settings {
+		got[s.Path] = s.Source
+		if secret := s.Path == "notifierConfig.smtp.password"; s.Secret != secret {
+			t.Errorf("expected %s to have Secret %v", s.Path, secret)
+		}
+	}
+	for path, source := range want {
+		if got[path] != source {
+			t.Errorf("expected %s to come from %q, got %q", path, source, got[path])
+		}
+	}
+}
+
+func TestLoad_ConfigPath(t *testing.T) {
+	path := writeConfig(t, `{"serverConfig": {"port": ":7000"}}`)
+
+	cfg, _, err := config.Load(config.LoadOptions{Environ: []string{"TASKS_CONFIG=" + path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != ":7000" {
+		t.Errorf("expected TASKS_CONFIG to be read, got port %s", cfg.Server.Port)
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.json")
+	if _, _, err := config.Load(config.LoadOptions{Path: missing}); err == nil {
+		t.Error("expected an error for a missing -config file")
+	}
+	if _, _, err := config.Load(config.LoadOptions{Environ: []string{"TASKS_CONFIG=" + missing}}); err == nil {
+		t.Error("expected an error for a missing TASKS_CONFIG file")
+	}
+
+	// Without a file named anywhere the defaults are enough.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	cfg, _, err = config.Load(config.LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg, config.Default()) {
+		t.Errorf("expected the defaults without a config file, got %+v", cfg)
+	}
+}
+
+func TestLoad_RejectsBadSettings(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		opts config.LoadOptions
+		want string
+	}{
+		{
+			name: "unknown key in file",
+			file: `{"serverConfig": {"prot": ":80"}}`,
+			want: "unknown setting serverConfig.prot",
+		},
+		{
+			name: "wrong type in file",
+			file: `{"sqlConfig": {"maxOpenConns": "many"}}`,
+			want: "sqlConfig.maxOpenConns",
+		},
+		{
+			name: "bad duration in file",
+			file: `{"checkerConfig": {"delay": "soon"}}`,
+			want: "checkerConfig.delay",
+		},
+		{
+			name: "bad env value",
+			opts: config.LoadOptions{Environ: []string{"TASKS_SQL_FOREIGN_KEYS=maybe"}},
+			want: "TASKS_SQL_FOREIGN_KEYS",
+		},
+		{
+			name: "fractional seconds",
+			opts: config.LoadOptions{Sets: []string{"serverConfig.readTimeout=1.5s"}},
+			want: "whole number of seconds",
+		},
+		{
+			name: "unknown flag path",
+			opts: config.LoadOptions{Sets: []string{"serverConfig.tls.cert=x"}},
+			want: "unknown setting serverConfig.tls.cert",
+		},
+		{
+			name: "flag without value",
+			opts: config.LoadOptions{Sets: []string{"serverConfig.port"}},
+			want: "path=value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			if tt.file != "" {
+				opts.Path = writeConfig(t, tt.file)
+			} else {
+				opts.Path = writeConfig(t, `{}`)
+			}
+			_, _, err := config.Load(opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error about %s, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	cfg, err := config.LoadFile(writeConfig(t, `{"serverConfig": {"port": ":7001"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != ":7001" || cfg.Storage.Driver != "sqlite" {
+		t.Errorf("expected the file on top of the defaults, got port %s and driver %s", cfg.Server.Port, cfg.Storage.Driver)
+	}
+
+	cfg, err = config.LoadFile(writeConfig(t, `{"serverConfig": {"port": ""}}`))
+	var fieldErr *config.FieldError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if cfg.Storage.Driver != "sqlite" {
+		t.Errorf("expected the config along with the validation error, got %+v", cfg)
+	}
+}
+
+func TestLoad_IgnoresUnknownEnv(t *testing.T) {
+	var logs bytes.Buffer
+	cfg, _, err := config.Load(config.LoadOptions{
+		Path:    writeConfig(t, `{}`),
+		Environ: []string{"TASKS_SERVER_PROT=:80", "TASKS_SERVER_PORT=:81"},
+		Logger:  slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+	if err != nil {
+		t.Fatalf("expected unknown env names to be ignored, got %v", err)
+	}
+	if cfg.Server.Port != ":81" {
+		t.Errorf("expected the known env name to apply, got port %s", cfg.Server.Port)
+	}
+	if !strings.Contains(logs.String(), "TASKS_SERVER_PROT") {
+		t.Errorf("expected a warning about TASKS_SERVER_PROT, got %q", logs.String())
+	}
+}
+
+func TestSeconds_Unmarshal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{in: `60`, want: time.Minute},
+		{in: `"45"`, want: 45 * time.Second},
+		{in: `"60s"`, want: time.Minute},
+		{in: `"1h30m"`, want: 90 * time.Minute},
+	}
+	for _, tt := range tests {
+		var s config.Seconds
+		if err := s.UnmarshalJSON([]byte(tt.in)); err != nil {
+			t.Fatalf("%s: %v", tt.in, err)
+		}
+		if s.Duration() != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.in, tt.want, s.Duration())
+		}
+	}
+
+	for _, in := range []string{`"1500ms"`, `"later"`, `true`} {
+		var s config.Seconds
+		if err := s.UnmarshalJSON([]byte(in)); err == nil {
+			t.Errorf("%s: expected an error", in)
+		}
+	}
+}
+
+func TestValidate_ReportsEveryProblem(t *testing.T) {
+	if err := config.Default().Validate(); err != nil {
+		t.Fatalf("expected the defaults to be valid, got %v", err)
+	}
+
+	dir := t.TempDir()
+	cfg := config.Default()
+	cfg.Checker.Delay = 0
+	cfg.Server.WriteTimeout = 0
+	cfg.Server.Listeners = []config.ListenerConfig{
+		{Network: "tcp", Address: ":8443", Mode: "0600", TLS: true},
+		{Network: "unix", Address: filepath.Join(dir, "missing", "tasks.sock")},
+	}
+	cfg.Server.TLS.MinVersion = "1.3"
+	cfg.Server.TLS.CipherSuites = []string{"TLS_RSA_WITH_RC4_128_SHA"}
+	cfg.Sql.Storagepath = dir
+	cfg.Sql.MaxIdleConns = 20
+	cfg.Notifier.Kind = "smtp"
+	cfg.Leader.RenewInterval = cfg.Leader.LeaseTTL
+	cfg.Queue.BackoffMax = 1
+	cfg.Jobs = config.JobsConfig{"overdeu": {Interval: 60}}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	var fieldErr *config.FieldError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("expected field errors, got %T", err)
+	}
+
+	for _, path := range []string{
+		"checkerConfig.delay",
+		"serverConfig.writeTimeout",
+		"serverConfig.listeners[0].mode",
+		"serverConfig.listeners[1].address",
+		"serverConfig.tls: certFile and keyFile are required",
+		"serverConfig.tls.cipherSuites:",
+		"serverConfig.tls.cipherSuites[0]",
+		"sqlConfig.storagepath",
+		"sqlConfig.maxIdleConns",
+		"notifierConfig.smtp.addr",
+		"notifierConfig.smtp.to",
+		"leaderConfig.renewInterval",
+		"queueConfig.backoffMax",
+		"jobsConfig.overdeu: is not a known job",
+	} {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("expected a problem with %s in:\n%v", path, err)
+		}
+	}
+}
+
+func TestLoad_Validates(t *testing.T) {
+	path := writeConfig(t, `{"checkerConfig": {"delay": 0}, "storage": {"driver": "disk"}}`)
+
+	_, _, err := config.Load(config.LoadOptions{Path: path})
+	if err == nil {
+		t.Fatal("expected an invalid config to be rejected")
+	}
+	for _, want := range []string{"checkerConfig.delay", "storage.driver"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected a problem with %s, got %v", want, err)
+		}
+	}
+}
+
+func TestChanged(t *testing.T) {
+	a := config.Default()
+	b := config.Default()
+	b.Checker.Delay = 30
+	b.Server.Listeners = []config.ListenerConfig{{Network: "tcp", Address: ":8443"}}
+	b.Jobs = config.JobsConfig{"overdue": {Interval: 120}}
+
+	changed, err := config.Changed(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The job's jitter and disabled flag are zero, the same as leaving the job out.
+	want := []string{"checkerConfig.delay", "jobsConfig.overdue.interval", "serverConfig.listeners"}
+	if !reflect.DeepEqual(changed, want) {
+		t.Fatalf("expected %v, got %v", want, changed)
+	}
+	if a.Checksum() == b.Checksum() || a.Checksum() != config.Default().Checksum() {
+		t.Fatal("expected the checksum to follow the settings")
+	}
+}
@@ -0,0 +1,275 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+)
+
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) fail(path, format string, args ...any) {
+	v.errs = append(v.errs, &FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) positive(path string, s Seconds) {
+	if s <= 0 {
+		v.fail(path, "must be greater than zero")
+	}
+}
+
+func (v *validator) notNegative(path string, n int64) {
+	if n < 0 {
+		v.fail(path, "must not be negative")
+	}
+}
+
+func (v *validator) oneOf(path, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.fail(path, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+}
+
+func (v *validator) file(path, value string) {
+	if value == "" {
+		v.fail(path, "must be set")
+		return
+	}
+	if info, err := os.Stat(value); err == nil && info.IsDir() {
+		v.fail(path, "%s is a directory", value)
+	}
+}
+
+func (v *validator) dir(path, value string) {
+	if value == "" {
+		v.fail(path, "must be set")
+		return
+	}
+	if info, err := os.Stat(value); err == nil && !info.IsDir() {
+		v.fail(path, "%s is not a directory", value)
+	}
+}
+
+func (v *validator) existing(path, value string) {
+	if _, err := os.Stat(value); err != nil {
+		v.fail(path, "cannot read %s: %v", value, errors.Unwrap(err))
+	}
+}
+
+// Validate reports all problems at once as joined FieldErrors.
+func (c Config) Validate() error {
+	var v validator
+	c.validateStorage(&v)
+	c.validateServer(&v)
+
+	v.positive("checkerConfig.delay", c.Checker.Delay)
+	v.notNegative("checkerConfig.trashRetention", int64(c.Checker.TrashRetention))
+
+	v.oneOf("idConfig.generator", c.ID.Generator, "", id.GeneratorSnowflake, id.GeneratorRandom)
+	if c.ID.NodeID < 0 || c.ID.NodeID > id.MaxNodeID {
+		v.fail("idConfig.nodeId", "must be between 0 and %d", id.MaxNodeID)
+	}
+
+	v.notNegative("backupConfig.keep", int64(c.Backup.Keep))
+	v.notNegative("backupConfig.interval", int64(c.Backup.Interval))
+	if c.Backup.Interval > 0 || c.Backup.Dir != "" {
+		v.dir("backupConfig.dir", c.Backup.Dir)
+	}
+
+	c.validateNotifier(&v)
+
+	v.positive("leaderConfig.leaseTtl", c.Leader.LeaseTTL)
+	v.positive("leaderConfig.renewInterval", c.Leader.RenewInterval)
+	if c.Leader.RenewInterval >= c.Leader.LeaseTTL && c.Leader.LeaseTTL > 0 {
+		v.fail("leaderConfig.renewInterval", "must be shorter than leaseTtl, or the lease runs out between renewals")
+	}
+
+	names := make([]string, 0, len(c.Jobs))
+	for name := range c.Jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !slices.Contains(JobNames, name) {
+			v.fail("jobsConfig."+name, "is not a known job, must be one of %s", strings.Join(JobNames, ", "))
+			continue
+		}
+		v.notNegative("jobsConfig."+name+".interval", int64(c.Jobs[name].Interval))
+		v.notNegative("jobsConfig."+name+".jitter", int64(c.Jobs[name].Jitter))
+	}
+
+	q := c.Queue
+	if q.Workers <= 0 {
+		v.fail("queueConfig.workers", "must be greater than zero")
+	}
+	v.positive("queueConfig.pollInterval", q.PollInterval)
+	v.positive("queueConfig.leaseTtl", q.LeaseTTL)
+	if q.MaxAttempts <= 0 {
+		v.fail("queueConfig.maxAttempts", "must be greater than zero")
+	}
+	v.positive("queueConfig.backoffBase", q.BackoffBase)
+	if q.BackoffMax < q.BackoffBase {
+		v.fail("queueConfig.backoffMax", "must not be shorter than backoffBase")
+	}
+	v.notNegative("queueConfig.retention", int64(q.Retention))
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		v.fail("logConfig.level", "must be one of debug, info, warn, error, got %q", c.Log.Level)
+	}
+
+	return errors.Join(v.errs...)
+}
+
+func (c Config) validateStorage(v *validator) {
+	switch c.Storage.Driver {
+	case "sqlite", "":
+		s := c.Sql
+		v.file("sqlConfig.storagepath", s.Storagepath)
+		v.oneOf("sqlConfig.journalMode", strings.ToUpper(s.JournalMode), "", "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF")
+		v.oneOf("sqlConfig.synchronous", strings.ToUpper(s.Synchronous), "", "OFF", "NORMAL", "FULL", "EXTRA", "0", "1", "2", "3")
+		v.notNegative("sqlConfig.busyTimeoutMs", s.BusyTimeout)
+		v.notNegative("sqlConfig.maxOpenConns", int64(s.MaxOpenConns))
+		v.notNegative("sqlConfig.maxIdleConns", int64(s.MaxIdleConns))
+		if s.MaxOpenConns > 0 && s.MaxIdleConns > s.MaxOpenConns {
+			v.fail("sqlConfig.maxIdleConns", "must not exceed maxOpenConns")
+		}
+		v.notNegative("sqlConfig.connMaxLifetime", int64(s.ConnMaxLifetime))
+	case "memory":
+		m := c.Storage.Memory
+		v.notNegative("storage.memory.snapshotInterval", int64(m.SnapshotInterval))
+		if m.SnapshotInterval > 0 {
+			v.file("storage.memory.snapshotPath", m.SnapshotPath)
+		}
+	case "journal":
+		j := c.Storage.Journal
+		v.dir("storage.journal.dir", j.Dir)
+		v.oneOf("storage.journal.fsync", j.Fsync, "", "always", "interval", "never")
+		if j.Fsync == "interval" {
+			v.positive("storage.journal.fsyncInterval", j.FsyncInterval)
+		}
+		v.notNegative("storage.journal.compactInterval", int64(j.CompactInterval))
+	default:
+		v.fail("storage.driver", "must be one of sqlite, memory, journal, got %q", c.Storage.Driver)
+	}
+}
+
+func (c Config) validateServer(v *validator) {
+	s := c.Server
+	v.positive("serverConfig.readTimeout", s.ReadTimeout)
+	v.positive("serverConfig.writeTimeout", s.WriteTimeout)
+	v.positive("serverConfig.idleTimeout", s.IdleTimeout)
+	v.positive("serverConfig.readHeaderTimeout", s.ReadHeaderTimeout)
+	v.positive("serverConfig.idempotencyTTL", s.IdempotencyTTL)
+
+	needsTLS := len(s.Listeners) == 0 && s.TLS.CertFile != ""
+	if len(s.Listeners) == 0 && s.Port == "" {
+		v.fail("serverConfig.port", "must be set when there are no listeners")
+	}
+	for i, l := range s.Listeners {
+		path := fmt.Sprintf("serverConfig.listeners[%d]", i)
+		if l.Address == "" {
+			v.fail(path+".address", "must be set")
+		}
+		switch l.Network {
+		case "tcp", "":
+			if l.Mode != "" {
+				v.fail(path+".mode", "only applies to unix sockets")
+			}
+		case "unix":
+			if mode, err := strconv.ParseUint(l.Mode, 8, 32); l.Mode != "" && (err != nil || mode > 0777) {
+				v.fail(path+".mode", "must be an octal permission such as \"0660\", got %q", l.Mode)
+			}
+			if dir := filepath.Dir(l.Address); l.Address != "" {
+				if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+					v.fail(path+".address", "directory %s does not exist", dir)
+				}
+			}
+		default:
+			v.fail(path+".network", "must be tcp or unix, got %q", l.Network)
+		}
+		needsTLS = needsTLS || l.TLS
+	}
+
+	t := s.TLS
+	switch {
+	case (t.CertFile == "") != (t.KeyFile == ""):
+		v.fail("serverConfig.tls", "certFile and keyFile must be set together")
+	case needsTLS && t.CertFile == "":
+		v.fail("serverConfig.tls", "certFile and keyFile are required by listeners with tls")
+	case t.CertFile != "":
+		v.existing("serverConfig.tls.certFile", t.CertFile)
+		v.existing("serverConfig.tls.keyFile", t.KeyFile)
+	}
+	v.oneOf("serverConfig.tls.minVersion", t.MinVersion, "", "1.2", "1.3")
+	if t.MinVersion == "1.3" && len(t.CipherSuites) > 0 {
+		v.fail("serverConfig.tls.cipherSuites", "cannot be set with minVersion 1.3, whose suites are not configurable")
+	}
+	for i, name := range t.CipherSuites {
+		if !secureCipherSuite(name) {
+			v.fail(fmt.Sprintf("serverConfig.tls.cipherSuites[%d]", i), "unknown or insecure cipher suite %q", name)
+		}
+	}
+	v.notNegative("serverConfig.tls.reloadInterval", int64(t.ReloadInterval))
+}
+
+func secureCipherSuite(name string) bool {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Config) validateNotifier(v *validator) {
+	n := c.Notifier
+	switch n.Kind {
+	case "log", "":
+	case "webhook":
+		if u, err := url.Parse(n.Webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.fail("notifierConfig.webhook.url", "must be an http or https URL, got %q", n.Webhook.URL)
+		}
+		v.positive("notifierConfig.webhook.timeout", n.Webhook.Timeout)
+	case "smtp":
+		if n.SMTP.Addr == "" {
+			v.fail("notifierConfig.smtp.addr", "must be set")
+		}
+		if n.SMTP.From == "" {
+			v.fail("notifierConfig.smtp.from", "must be set")
+		}
+		if len(n.SMTP.To) == 0 {
+			v.fail("notifierConfig.smtp.to", "must list at least one recipient")
+		}
+		if n.SMTP.Password != "" && n.SMTP.Username == "" {
+			v.fail("notifierConfig.smtp.username", "must be set with a password")
+		}
+		v.positive("notifierConfig.smtp.timeout", n.SMTP.Timeout)
+	default:
+		v.fail("notifierConfig.kind", "must be one of log, webhook, smtp, got %q", n.Kind)
+	}
+}
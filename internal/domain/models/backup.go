@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type Backup struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,15 @@
+package models
+
+type BatchOp string
+
+const (
+	BatchCreate BatchOp = "create"
+	BatchUpdate BatchOp = "update"
+	BatchDelete BatchOp = "delete"
+)
+
+// BatchOperation carries the target id in Task.ID for update and delete operations.
+type BatchOperation struct {
+	Op   BatchOp
+	Task Task
+}
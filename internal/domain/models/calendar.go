@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+const (
+	CalendarTodo  = "VTODO"
+	CalendarEvent = "VEVENT"
+)
+
+// CalendarFeed is an actor's subscription feed; only the hash of its secret token is stored.
+type CalendarFeed struct {
+	Owner          string    `json:"owner"`
+	TokenHash      string    `json:"-"`
+	Components     []string  `json:"components"`
+	IncludeOverdue bool      `json:"includeOverdue"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ConfigStatus describes the configuration the server runs with. Version starts at 1 and goes up
+// with every reload that changed a setting; RestartRequired lists the changed settings that only
+// take effect after a restart.
+type ConfigStatus struct {
+	Version         int       `json:"version"`
+	Checksum        string    `json:"checksum"`
+	LoadedAt        time.Time `json:"loadedAt"`
+	RestartRequired []string  `json:"restartRequired"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	// EventOverdueCleared is an update that took the task out of the overdue state.
+	EventOverdueCleared EventType = "overdue_cleared"
+	EventDeleted        EventType = "deleted"
+	EventRestored       EventType = "restored"
+	EventPurged         EventType = "purged"
+	EventUndone         EventType = "undone"
+)
+
+// TaskEvent records a single change of a task. Before is nil for creations and After is nil
+// for purges; both are kept to rebuild the task at any point of its history.
+type TaskEvent struct {
+	ID            int64         `json:"id"`
+	TaskID        int64         `json:"taskId"`
+	Type          EventType     `json:"type"`
+	Actor         string        `json:"actor"`
+	At            time.Time     `json:"at"`
+	Changes       []FieldChange `json:"changes"`
+	UndoneEventID int64         `json:"undoneEventId,omitempty"`
+	Before        *Task         `json:"-"`
+	After         *Task         `json:"-"`
+}
+
+type FieldChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// JobStatus describes a background job as of its last run. Interval is in seconds and zero for
+// one-shot jobs; Skipped counts the runs a follower left to the leader.
+type JobStatus struct {
+	Name           string     `json:"name"`
+	Interval       int64      `json:"interval"`
+	LeaderOnly     bool       `json:"leaderOnly"`
+	Running        bool       `json:"running"`
+	Runs           int64      `json:"runs"`
+	Failures       int64      `json:"failures"`
+	Skipped        int64      `json:"skipped"`
+	LastStarted    *time.Time `json:"lastStarted,omitempty"`
+	LastFinished   *time.Time `json:"lastFinished,omitempty"`
+	LastDurationMs int64      `json:"lastDurationMs"`
+	LastError      string     `json:"lastError,omitempty"`
+	NextRun        *time.Time `json:"nextRun,omitempty"`
+}
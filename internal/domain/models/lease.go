@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Lease gives one holder exclusive use of a named role until ExpiresAt.
+type Lease struct {
+	Name       string    `json:"name"`
+	Holder     string    `json:"holder"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// Leadership is how this instance sees CheckerLease. Holder is empty while nobody holds it.
+type Leadership struct {
+	ID        string     `json:"id"`
+	Leader    bool       `json:"leader"`
+	Holder    string     `json:"holder,omitempty"`
+	Since     *time.Time `json:"since,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
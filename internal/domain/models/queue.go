@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type QueuedJobState string
+
+const (
+	JobPending   QueuedJobState = "pending"
+	JobRunning   QueuedJobState = "running"
+	JobDone      QueuedJobState = "done"
+	JobDead      QueuedJobState = "dead"
+	JobCancelled QueuedJobState = "cancelled"
+)
+
+type QueuedJob struct {
+	ID        int64           `json:"id"`
+	Kind      string          `json:"kind"`
+	Payload   json.RawMessage `json:"payload"`
+	DedupeKey string          `json:"dedupeKey,omitempty"`
+	State     QueuedJobState  `json:"state"`
+	RunAt     time.Time       `json:"runAt"`
+	Attempts  int             `json:"attempts"`
+	// MaxAttempts of zero uses the limit the queue is configured with.
+	MaxAttempts    int        `json:"maxAttempts"`
+	LeaseHolder    string     `json:"leaseHolder,omitempty"`
+	LeaseExpiresAt *time.Time `json:"leaseExpiresAt,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	FinishedAt     *time.Time `json:"finishedAt,omitempty"`
+}
+
+// QueuedJobFilter: empty fields match every job.
+type QueuedJobFilter struct {
+	State   QueuedJobState
+	Kind    string
+	AfterID int64
+	Limit   int
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Reminder fires LeadSeconds before the task's due date; FireAt is nil while the task has no due date.
+// SentAt is set once the reminder was delivered, or queued for delivery when a job queue runs.
+type Reminder struct {
+	ID          int64      `json:"id"`
+	TaskID      int64      `json:"taskId"`
+	LeadSeconds int64      `json:"leadSeconds"`
+	FireAt      *time.Time `json:"fireAt"`
+	SentAt      *time.Time `json:"sentAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
@@ -2,10 +2,24 @@ package models
 
 import "time"
 
+type DueKind string
+
+const (
+	// DueInstant deadlines pass at DueDate itself.
+	DueInstant DueKind = "instant"
+	// DueAllDay deadlines are a calendar date stored as midnight UTC; they pass at the end of that
+	// date in the task's TimeZone.
+	DueAllDay DueKind = "date"
+)
+
 type Task struct {
 	ID          int64      `json:"id"`
+	ExternalID  string     `json:"externalId,omitempty"`
 	Title       string     `json:"title"`
 	Description string     `json:"description"`
 	DueDate     *time.Time `json:"dueDate"`
+	DueKind     DueKind    `json:"dueKind,omitempty"`
+	TimeZone    string     `json:"timeZone,omitempty"`
 	OverDue     bool       `json:"overDue"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
 }
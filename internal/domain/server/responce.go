@@ -1,7 +1,71 @@
 package server
 
+import (
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/validator"
+)
 
 type ResponceWithError struct {
 	Msg, Err string
 }
 
+type ResponceWithViolations struct {
+	Msg, Err   string
+	Violations []validator.Violation
+}
+
+type BatchResponce struct {
+	Atomic    bool              `json:"atomic"`
+	Committed bool              `json:"committed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+type BatchItemResult struct {
+	Index      int                   `json:"index"`
+	Op         string                `json:"op"`
+	Status     int                   `json:"status"`
+	ID         int64                 `json:"id,omitempty"`
+	Task       *models.Task          `json:"task,omitempty"`
+	Err        string                `json:"error,omitempty"`
+	Violations []validator.Violation `json:"violations,omitempty"`
+}
+
+type ResponceWithConflict struct {
+	Msg, Err string
+	Conflict models.TaskEvent
+}
+
+type UndoResponce struct {
+	Task  models.Task      `json:"task"`
+	Event models.TaskEvent `json:"event"`
+}
+
+type ImportResponce struct {
+	Format    string            `json:"format"`
+	DryRun    bool              `json:"dryRun"`
+	Committed bool              `json:"committed"`
+	Created   int               `json:"created"`
+	Updated   int               `json:"updated"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+type ImportRowResult struct {
+	Row        int                   `json:"row"`
+	Action     string                `json:"action,omitempty"`
+	ID         int64                 `json:"id,omitempty"`
+	ExternalID string                `json:"externalId,omitempty"`
+	Err        string                `json:"error,omitempty"`
+	Violations []validator.Violation `json:"violations,omitempty"`
+}
+
+type CalendarFeedResponce struct {
+	Feed  models.CalendarFeed `json:"feed"`
+	Token string              `json:"token,omitempty"`
+	URL   string              `json:"url,omitempty"`
+}
+
+// ReadyResponce is served by /readyz. Followers are ready too; Leader only says which instance runs the checker.
+type ReadyResponce struct {
+	Status string            `json:"status"`
+	Leader models.Leadership `json:"leader"`
+}
@@ -0,0 +1,281 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/clock"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+)
+
+var (
+	ErrDuplicateJob = errors.New("job with this name is already registered")
+	ErrStarted      = errors.New("scheduler is already started")
+	ErrUnknownJob   = errors.New("no job with this name")
+	ErrOneShotJob   = errors.New("one-shot jobs cannot be rescheduled")
+)
+
+type Job struct {
+	Name string
+	// Interval is measured from the end of a run; zero runs the job once.
+	Interval   time.Duration
+	Jitter     time.Duration
+	LeaderOnly bool
+	Run        func(ctx context.Context) error
+}
+
+type scheduleKey struct{}
+
+// Schedule returns the interval and jitter the running job was scheduled with, including any
+// reschedule; ok is false when the job is run outside a Scheduler.
+func Schedule(ctx context.Context) (interval, jitter time.Duration, ok bool) {
+	job, ok := ctx.Value(scheduleKey{}).(Job)
+	return job.Interval, job.Jitter, ok
+}
+
+type Leadership interface {
+	IsLeader() bool
+}
+
+func Configure(job Job, cfg config.JobsConfig) (Job, bool) {
+	c, ok := cfg[job.Name]
+	if !ok {
+		return job, true
+	}
+	if c.Interval > 0 {
+		job.Interval = c.Interval.Duration()
+	}
+	if c.Jitter > 0 {
+		job.Jitter = c.Jitter.Duration()
+	}
+	return job, !c.Disabled
+}
+
+// Scheduler records a panicking job as a failed run.
+type Scheduler struct {
+	clock      clock.Clock
+	leadership Leadership
+	logger     *slog.Logger
+
+	mu      sync.Mutex
+	entries []*entry
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+type entry struct {
+	job    Job
+	status models.JobStatus
+	reset  chan struct{}
+}
+
+func NewScheduler(clk clock.Clock, leadership Leadership, logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		clock:      clk,
+		leadership: leadership,
+		logger:     logger,
+	}
+}
+
+func (s *Scheduler) Add(job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return ErrStarted
+	}
+	for _, e := range s.entries {
+		if e.job.Name == job.Name {
+			return fmt.Errorf("%w: %s", ErrDuplicateJob, job.Name)
+		}
+	}
+
+	s.entries = append(s.entries, &entry{
+		job: job,
+		status: models.JobStatus{
+			Name:       job.Name,
+			Interval:   int64(job.Interval / time.Second),
+			LeaderOnly: job.LeaderOnly,
+		},
+		reset: make(chan struct{}, 1),
+	})
+	return nil
+}
+
+// Reschedule restarts the wait for the job's next run.
+func (s *Scheduler) Reschedule(name string, interval, jitter time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.entries {
+		if e.job.Name != name {
+			continue
+		}
+		if e.job.Interval <= 0 || interval <= 0 {
+			return fmt.Errorf("%w: %s", ErrOneShotJob, name)
+		}
+		if e.job.Interval == interval && e.job.Jitter == jitter {
+			return nil
+		}
+		e.job.Interval = interval
+		e.job.Jitter = jitter
+		e.status.Interval = int64(interval / time.Second)
+		select {
+		case e.reset <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownJob, name)
+}
+
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for _, e := range s.entries {
+		s.wg.Add(1)
+		go s.loop(ctx, e)
+	}
+}
+
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for jobs to stop: %w", ctx.Err())
+	}
+}
+
+func (s *Scheduler) Statuses() []models.JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]models.JobStatus, 0, len(s.entries))
+	for _, e := range s.entries {
+		statuses = append(statuses, e.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	defer s.wg.Done()
+
+	// ran stays false across resets, so a rescheduled job still waits only the jitter before its first run.
+	for ran := false; ; {
+		s.mu.Lock()
+		interval, delay := e.job.Interval, e.job.Jitter
+		s.mu.Unlock()
+
+		if delay > 0 {
+			delay = rand.N(delay)
+		}
+		if ran {
+			delay += interval
+		}
+
+		next := s.clock.Now().Add(delay)
+		s.mu.Lock()
+		e.status.NextRun = &next
+		s.mu.Unlock()
+
+		timer := s.clock.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-e.reset:
+			timer.Stop()
+			continue
+		case <-timer.C():
+		}
+
+		s.run(ctx, e)
+		ran = true
+		if interval <= 0 {
+			s.mu.Lock()
+			e.status.NextRun = nil
+			s.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	s.mu.Lock()
+	job := e.job
+	s.mu.Unlock()
+
+	if job.LeaderOnly && s.leadership != nil && !s.leadership.IsLeader() {
+		s.mu.Lock()
+		e.status.Skipped++
+		s.mu.Unlock()
+		return
+	}
+
+	started := s.clock.Now()
+	s.mu.Lock()
+	e.status.Running = true
+	e.status.LastStarted = &started
+	s.mu.Unlock()
+
+	err := s.safeRun(ctx, job)
+
+	finished := s.clock.Now()
+	s.mu.Lock()
+	e.status.Running = false
+	e.status.Runs++
+	e.status.LastFinished = &finished
+	e.status.LastDurationMs = finished.Sub(started).Milliseconds()
+	e.status.LastError = ""
+	if err != nil {
+		e.status.Failures++
+		e.status.LastError = err.Error()
+	}
+	s.mu.Unlock()
+
+	if err != nil && ctx.Err() == nil {
+		s.logger.Error("job failed", slog.String("job", job.Name), sl.Err(err))
+	}
+}
+
+func (s *Scheduler) safeRun(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("job panicked", slog.String("job", job.Name), slog.Any("panic", r), slog.String("stack", string(debug.Stack())))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(context.WithValue(ctx, scheduleKey{}, job))
+}
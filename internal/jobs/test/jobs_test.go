@@ -0,0 +1,282 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/jobs"
+	"github.com/gintokos/tasksrestapi/internal/lib/clock/fakeclock"
+)
+
+var (
+	logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	start  = time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC)
+)
+
+type leadership struct {
+	leader atomic.Bool
+}
+
+func (l *leadership) IsLeader() bool {
+	return l.leader.Load()
+}
+
+func newScheduler(t *testing.T, fc *fakeclock.Clock, l jobs.Leadership, list ...jobs.Job) *jobs.Scheduler {
+	t.Helper()
+
+	s := jobs.NewScheduler(fc, l, logger)
+	for _, job := range list {
+		if err := s.Add(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Start()
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+	return s
+}
+
+func status(t *testing.T, s *jobs.Scheduler, name string) models.JobStatus {
+	t.Helper()
+
+	for _, st := range s.Statuses() {
+		if st.Name == name {
+			return st
+		}
+	}
+	t.Fatalf("no status for job %s", name)
+	return models.JobStatus{}
+}
+
+func TestScheduler_PeriodicAndOneShot(t *testing.T) {
+	fc := fakeclock.New(start)
+	var periodic, once atomic.Int64
+	s := newScheduler(t, fc, nil,
+		jobs.Job{Name: "periodic", Interval: time.Minute, Run: func(ctx context.Context) error {
+			periodic.Add(1)
+			return nil
+		}},
+		jobs.Job{Name: "once", Run: func(ctx context.Context) error {
+			once.Add(1)
+			return errors.New("boom")
+		}},
+	)
+
+	fc.WaitForTickers(1)
+	for i := 0; i < 10; i++ {
+		fc.Advance(time.Minute)
+		fc.WaitForTickers(1)
+	}
+
+	if got := periodic.Load(); got != 11 {
+		t.Fatalf("expected a run at start and one per minute, got %d", got)
+	}
+	if got := once.Load(); got != 1 {
+		t.Fatalf("expected the one-shot job to run once, got %d", got)
+	}
+
+	st := status(t, s, "periodic")
+	if st.Runs != 11 || st.Failures != 0 || st.Interval != 60 || st.NextRun == nil || !st.NextRun.Equal(fc.Now().Add(time.Minute)) {
+		t.Fatalf("unexpected periodic status %+v", st)
+	}
+	st = status(t, s, "once")
+	if st.Runs != 1 || st.Failures != 1 || st.LastError != "boom" || st.NextRun != nil {
+		t.Fatalf("unexpected one-shot status %+v", st)
+	}
+}
+
+func TestScheduler_IsolatesPanics(t *testing.T) {
+	fc := fakeclock.New(start)
+	var healthy atomic.Int64
+	s := newScheduler(t, fc, nil,
+		jobs.Job{Name: "panics", Interval: time.Minute, Run: func(ctx context.Context) error {
+			panic("nil map")
+		}},
+		jobs.Job{Name: "healthy", Interval: time.Minute, Run: func(ctx context.Context) error {
+			healthy.Add(1)
+			return nil
+		}},
+	)
+
+	fc.WaitForTickers(2)
+	fc.Advance(time.Minute)
+	fc.WaitForTickers(2)
+
+	st := status(t, s, "panics")
+	if st.Runs != 2 || st.Failures != 2 || !strings.Contains(st.LastError, "nil map") {
+		t.Fatalf("expected both panicking runs to be recorded as failures, got %+v", st)
+	}
+	if healthy.Load() != 2 {
+		t.Fatalf("expected the other job to keep running, got %d runs", healthy.Load())
+	}
+}
+
+func TestScheduler_SkipsLeaderOnlyJobsOnFollowers(t *testing.T) {
+	fc := fakeclock.New(start)
+	l := &leadership{}
+	var runs atomic.Int64
+	s := newScheduler(t, fc, l, jobs.Job{Name: "leader", Interval: time.Minute, LeaderOnly: true, Run: func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+
+	fc.WaitForTickers(1)
+	fc.Advance(time.Minute)
+	fc.WaitForTickers(1)
+	l.leader.Store(true)
+	fc.Advance(time.Minute)
+	fc.WaitForTickers(1)
+
+	st := status(t, s, "leader")
+	if runs.Load() != 1 || st.Runs != 1 || st.Skipped != 2 {
+		t.Fatalf("expected 2 skipped runs and 1 after becoming leader, got %d runs, status %+v", runs.Load(), st)
+	}
+}
+
+func TestScheduler_ShutdownCancelsRunningJobs(t *testing.T) {
+	fc := fakeclock.New(start)
+	started := make(chan struct{})
+	s := jobs.NewScheduler(fc, nil, logger)
+	s.Add(jobs.Job{Name: "slow", Interval: time.Minute, Run: func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	s.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("expected shutdown to stop the running job, got %v", err)
+	}
+	if fc.Tickers() != 0 {
+		t.Fatalf("expected no timers left after shutdown, got %d", fc.Tickers())
+	}
+
+	stuckStarted := make(chan struct{})
+	stuck := jobs.NewScheduler(fc, nil, logger)
+	stuck.Add(jobs.Job{Name: "stuck", Run: func(ctx context.Context) error {
+		close(stuckStarted)
+		select {}
+	}})
+	stuck.Start()
+	<-stuckStarted
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := stuck.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected shutdown to give up on a job ignoring cancellation, got %v", err)
+	}
+}
+
+func TestScheduler_JitterAndConfig(t *testing.T) {
+	job, enabled := jobs.Configure(jobs.Job{Name: "overdue", Interval: time.Minute}, config.JobsConfig{
+		"overdue":   {Interval: 300, Jitter: 30},
+		"reminders": {Disabled: true},
+	})
+	if !enabled || job.Interval != 5*time.Minute || job.Jitter != 30*time.Second {
+		t.Fatalf("expected config to override interval and jitter, got %+v", job)
+	}
+	if _, enabled := jobs.Configure(jobs.Job{Name: "reminders"}, config.JobsConfig{"reminders": {Disabled: true}}); enabled {
+		t.Fatalf("expected a disabled job to be reported")
+	}
+
+	fc := fakeclock.New(start)
+	job.Run = func(ctx context.Context) error { return nil }
+	s := newScheduler(t, fc, nil, job)
+	for i := 0; i < 20; i++ {
+		fc.WaitForTickers(1)
+		next := status(t, s, "overdue").NextRun
+		if next == nil {
+			t.Fatal("expected a scheduled next run")
+		}
+		min := fc.Now()
+		if i > 0 {
+			min = min.Add(job.Interval)
+		}
+		if next.Before(min) || !next.Before(min.Add(job.Jitter)) {
+			t.Fatalf("expected next run in [%v, %v), got %v", min, min.Add(job.Jitter), next)
+		}
+		fc.Set(*next)
+	}
+
+	if err := s.Add(jobs.Job{Name: "late"}); !errors.Is(err, jobs.ErrStarted) {
+		t.Fatalf("expected adding to a started scheduler to fail, got %v", err)
+	}
+}
+
+func TestScheduler_RescheduleBeforeFirstRun(t *testing.T) {
+	fc := fakeclock.New(start)
+	s := newScheduler(t, fc, nil, jobs.Job{Name: "overdue", Interval: time.Hour, Jitter: time.Hour, Run: func(ctx context.Context) error { return nil }})
+	fc.WaitForTickers(1)
+
+	if err := s.Reschedule("overdue", time.Minute, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	// Until the job first runs, only the new jitter is waited for, never the interval.
+	first := status(t, s, "overdue").NextRun
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		next := status(t, s, "overdue").NextRun
+		if next != first {
+			if next.Before(fc.Now()) || !next.Before(fc.Now().Add(10*time.Second)) {
+				t.Fatalf("expected the first run within the new jitter, got %v", next.Sub(fc.Now()))
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the job to pick up the new schedule")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestScheduler_Reschedule(t *testing.T) {
+	fc := fakeclock.New(start)
+	var runs atomic.Int64
+	s := newScheduler(t, fc, nil,
+		jobs.Job{Name: "overdue", Interval: time.Hour, Run: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		}},
+		jobs.Job{Name: "once", Run: func(ctx context.Context) error { return nil }},
+	)
+	fc.WaitForTickers(1)
+
+	if err := s.Reschedule("overdue", time.Minute, 0); err != nil {
+		t.Fatal(err)
+	}
+	// The loop picks the change up on its own goroutine, so wait for it in real time.
+	want := fc.Now().Add(time.Minute)
+	deadline := time.Now().Add(5 * time.Second)
+	for next := status(t, s, "overdue").NextRun; next == nil || !next.Equal(want); next = status(t, s, "overdue").NextRun {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the next run to move to %v, got %v", want, next)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	fc.WaitForTickers(1)
+
+	fc.Advance(time.Minute)
+	fc.WaitForTickers(1)
+	if got := runs.Load(); got != 2 {
+		t.Fatalf("expected a run a minute after rescheduling, got %d runs", got)
+	}
+	if st := status(t, s, "overdue"); st.Interval != 60 {
+		t.Fatalf("expected the status to show the new interval, got %d", st.Interval)
+	}
+
+	if err := s.Reschedule("missing", time.Minute, 0); !errors.Is(err, jobs.ErrUnknownJob) {
+		t.Fatalf("expected an unknown job error, got %v", err)
+	}
+	if err := s.Reschedule("once", time.Minute, 0); !errors.Is(err, jobs.ErrOneShotJob) {
+		t.Fatalf("expected a one-shot job error, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package certreload
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/lib/clock"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+)
+
+// Reloader keeps the previous pair in use when a new one fails to load.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	logger   *slog.Logger
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	version string
+}
+
+func New(certFile, keyFile string, logger *slog.Logger) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, logger: logger}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+func (r *Reloader) Reload() error {
+	version, err := r.fileVersion()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert = &cert
+	r.version = version
+	return nil
+}
+
+func (r *Reloader) Watch(clk clock.Clock, interval time.Duration, stop <-chan struct{}) {
+	ticker := clk.NewTicker(interval)
+	defer ticker.Stop()
+
+	// failed keeps a broken pair from being logged on every tick.
+	failed := ""
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C():
+		}
+
+		version, err := r.fileVersion()
+		if err != nil {
+			r.logger.Error("error on checking certificate files", sl.Err(err))
+			continue
+		}
+		r.mu.RLock()
+		changed := version != r.version
+		r.mu.RUnlock()
+		if !changed || version == failed {
+			continue
+		}
+
+		if err := r.Reload(); err != nil {
+			failed = version
+			r.logger.Error("error on reloading certificate, keeping the previous one", sl.Err(err))
+			continue
+		}
+		r.logger.Info("reloaded certificate", slog.String("certFile", r.certFile))
+	}
+}
+
+func (r *Reloader) fileVersion() (string, error) {
+	version := ""
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		version += fmt.Sprintf("%d:%d;", info.ModTime().UnixNano(), info.Size())
+	}
+	return version, nil
+}
@@ -0,0 +1,141 @@
+package certreload_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/lib/certreload"
+	"github.com/gintokos/tasksrestapi/internal/lib/clock/fakeclock"
+)
+
+var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// writePair writes a self-signed certificate for name and stamps both files with modTime, so a
+// rewrite is noticed even on file systems with coarse timestamps.
+func writePair(t *testing.T, certFile, keyFile, name string, modTime time.Time) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{certFile, keyFile} {
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func commonName(t *testing.T, r *certreload.Reloader) string {
+	t.Helper()
+
+	cert, err := r.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestReloader_KeepsCertificateWhenNewFilesAreBroken(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	if _, err := certreload.New(certFile, keyFile, logger); err == nil {
+		t.Fatal("expected an error without certificate files")
+	}
+
+	writePair(t, certFile, keyFile, "first.example", time.Now())
+	r, err := certreload.New(certFile, keyFile, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := commonName(t, r); got != "first.example" {
+		t.Fatalf("expected first.example, got %s", got)
+	}
+
+	// A renewal caught halfway: the certificate is written but the key is not.
+	if err := os.WriteFile(keyFile, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err == nil {
+		t.Fatal("expected reloading a broken pair to fail")
+	}
+	if got := commonName(t, r); got != "first.example" {
+		t.Fatalf("expected the previous certificate to stay in use, got %s", got)
+	}
+}
+
+func TestReloader_WatchPicksUpChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	modTime := time.Now().Add(-time.Hour)
+	writePair(t, certFile, keyFile, "first.example", modTime)
+
+	r, err := certreload.New(certFile, keyFile, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fc := fakeclock.New(time.Now())
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		r.Watch(fc, time.Minute, stop)
+		close(done)
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+	fc.WaitForTickers(1)
+
+	writePair(t, certFile, keyFile, "second.example", modTime.Add(time.Second))
+	fc.Advance(time.Minute)
+
+	// The reload happens on the watcher's goroutine, so wait for it in real time.
+	deadline := time.Now().Add(5 * time.Second)
+	for commonName(t, r) != "second.example" {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the changed certificate to be picked up")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
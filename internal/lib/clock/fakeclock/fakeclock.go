@@ -0,0 +1,154 @@
+package fakeclock
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/lib/clock"
+)
+
+// Clock only moves when told to; like time.Ticker, a tick is dropped while the previous one is unread.
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*ticker
+	// changed is closed and replaced whenever a ticker is created or stopped.
+	changed chan struct{}
+}
+
+func New(now time.Time) *Clock {
+	return &Clock{now: now, changed: make(chan struct{})}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *Clock) NewTicker(d time.Duration) clock.Ticker {
+	if d <= 0 {
+		panic("fakeclock: non-positive interval for NewTicker")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &ticker{clock: c, period: d, next: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.tickers = append(c.tickers, t)
+	c.notify()
+	return t
+}
+
+// NewTimer fires right away for a non-positive d, like time.NewTimer.
+func (c *Clock) NewTimer(d time.Duration) clock.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &ticker{clock: c, once: true, next: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.tickers = append(c.tickers, t)
+	c.notify()
+	return t
+}
+
+func (c *Clock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the clock to now. Moving it backwards fires nothing.
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for {
+		t := c.nextTicker(now)
+		if t == nil {
+			break
+		}
+		c.now = t.next
+		t.next = t.next.Add(t.period)
+		select {
+		case t.c <- c.now:
+		default:
+		}
+		if t.once {
+			c.remove(t)
+		}
+	}
+	c.now = now
+}
+
+func (c *Clock) nextTicker(now time.Time) *ticker {
+	due := make([]*ticker, 0, len(c.tickers))
+	for _, t := range c.tickers {
+		if !t.next.After(now) {
+			due = append(due, t)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].next.Before(due[j].next) })
+	return due[0]
+}
+
+func (c *Clock) Tickers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.tickers)
+}
+
+// WaitForTickers blocks until n tickers or timers are running.
+func (c *Clock) WaitForTickers(n int) {
+	for {
+		c.mu.Lock()
+		count, changed := len(c.tickers), c.changed
+		c.mu.Unlock()
+
+		if count >= n {
+			return
+		}
+		<-changed
+	}
+}
+
+func (c *Clock) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *Clock) remove(t *ticker) {
+	for i, other := range c.tickers {
+		if other == t {
+			c.tickers = append(c.tickers[:i], c.tickers[i+1:]...)
+			c.notify()
+			return
+		}
+	}
+}
+
+type ticker struct {
+	clock  *Clock
+	once   bool
+	period time.Duration
+	next   time.Time
+	c      chan time.Time
+}
+
+func (t *ticker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *ticker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.clock.remove(t)
+}
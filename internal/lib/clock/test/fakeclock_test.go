@@ -0,0 +1,102 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/lib/clock/fakeclock"
+)
+
+var start = time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeClock_AdvanceFiresTicks(t *testing.T) {
+	fc := fakeclock.New(start)
+	ticker := fc.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	fc.Advance(59 * time.Minute)
+	select {
+	case at := <-ticker.C():
+		t.Fatalf("expected no tick before the period, got one at %v", at)
+	default:
+	}
+
+	fc.Advance(time.Minute)
+	if at := <-ticker.C(); !at.Equal(start.Add(time.Hour)) {
+		t.Fatalf("expected tick at %v, got %v", start.Add(time.Hour), at)
+	}
+	if !fc.Now().Equal(start.Add(time.Hour)) {
+		t.Fatalf("expected clock at %v, got %v", start.Add(time.Hour), fc.Now())
+	}
+}
+
+func TestFakeClock_DropsUnreadTicks(t *testing.T) {
+	fc := fakeclock.New(start)
+	ticker := fc.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	fc.Advance(72 * time.Hour)
+	if at := <-ticker.C(); !at.Equal(start.Add(time.Hour)) {
+		t.Fatalf("expected the first tick to be kept, got %v", at)
+	}
+	select {
+	case at := <-ticker.C():
+		t.Fatalf("expected later ticks to be dropped while unread, got %v", at)
+	default:
+	}
+
+	fc.Advance(time.Hour)
+	if at := <-ticker.C(); !at.Equal(start.Add(73 * time.Hour)) {
+		t.Fatalf("expected ticks to keep their schedule, got %v", at)
+	}
+}
+
+func TestFakeClock_StopAndWait(t *testing.T) {
+	fc := fakeclock.New(start)
+
+	started := make(chan struct{})
+	go func() {
+		fc.NewTicker(time.Minute)
+		close(started)
+	}()
+	fc.WaitForTickers(1)
+	<-started
+
+	if fc.Tickers() != 1 {
+		t.Fatalf("expected 1 running ticker, got %d", fc.Tickers())
+	}
+
+	ticker := fc.NewTicker(time.Second)
+	ticker.Stop()
+	fc.Advance(time.Hour)
+	select {
+	case <-ticker.C():
+		t.Fatalf("expected a stopped ticker not to fire")
+	default:
+	}
+	if fc.Tickers() != 1 {
+		t.Fatalf("expected stopped ticker to be removed, got %d running", fc.Tickers())
+	}
+}
+
+func TestFakeClock_TimerFiresOnce(t *testing.T) {
+	fc := fakeclock.New(start)
+	timer := fc.NewTimer(10 * time.Minute)
+
+	fc.Advance(time.Hour)
+	if at := <-timer.C(); !at.Equal(start.Add(10 * time.Minute)) {
+		t.Fatalf("expected timer to fire at %v, got %v", start.Add(10*time.Minute), at)
+	}
+	if fc.Tickers() != 0 {
+		t.Fatalf("expected a fired timer to be forgotten, got %d running", fc.Tickers())
+	}
+
+	stopped := fc.NewTimer(time.Minute)
+	stopped.Stop()
+	fc.Advance(time.Hour)
+	select {
+	case <-stopped.C():
+		t.Fatalf("expected a stopped timer not to fire")
+	default:
+	}
+}
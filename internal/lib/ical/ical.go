@@ -0,0 +1,253 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	DateTimeLayout = "20060102T150405Z"
+	dateLayout     = "20060102"
+	localLayout    = "20060102T150405"
+	maxLineOctets  = 75
+)
+
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []Component
+}
+
+func (c Component) Get(name string) (Property, bool) {
+	for _, p := range c.Properties {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Property{}, false
+}
+
+// Writer emits content lines folded at 75 octets with CRLF endings, as RFC 5545 requires.
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+func (w *Writer) Begin(name string) {
+	w.line("BEGIN:" + name)
+}
+
+func (w *Writer) End(name string) {
+	w.line("END:" + name)
+}
+
+func (w *Writer) Text(name, value string) {
+	w.line(name + ":" + EscapeText(value))
+}
+
+// Raw writes a property whose value is already in iCalendar form, e.g. a date-time.
+func (w *Writer) Raw(name, value string) {
+	w.line(name + ":" + value)
+}
+
+func (w *Writer) Err() error {
+	return w.err
+}
+
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+func (w *Writer) line(s string) {
+	if w.err != nil {
+		return
+	}
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		// Never split a multi-byte UTF-8 sequence.
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		if _, w.err = w.w.WriteString(s[:cut] + "\r\n "); w.err != nil {
+			return
+		}
+		s = s[cut:]
+		// Continuation lines start with the folding space.
+		limit = maxLineOctets - 1
+	}
+	_, w.err = w.w.WriteString(s + "\r\n")
+}
+
+func EscapeText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+func UnescapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func Parse(r io.Reader) ([]Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		roots []Component
+		stack []Component
+	)
+	for n, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			stack = append(stack, Component{Name: strings.ToUpper(prop.Value)})
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", n+1, prop.Value)
+			}
+			done := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				roots = append(roots, done)
+			} else {
+				parent := &stack[len(stack)-1]
+				parent.Components = append(parent.Components, done)
+			}
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property %s outside of a component", n+1, prop.Name)
+			}
+			cur := &stack[len(stack)-1]
+			cur.Properties = append(cur.Properties, prop)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("component %s is not closed", stack[len(stack)-1].Name)
+	}
+	return roots, nil
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseLine(line string) (Property, error) {
+	// The value starts at the first colon that is not inside a quoted parameter value.
+	quoted := false
+	colon := -1
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return Property{}, errors.New("missing ':'")
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	prop := Property{Name: strings.ToUpper(parts[0]), Value: line[colon+1:]}
+	if prop.Name == "" {
+		return Property{}, errors.New("missing property name")
+	}
+	for _, param := range parts[1:] {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return Property{}, fmt.Errorf("malformed parameter %q", param)
+		}
+		if prop.Params == nil {
+			prop.Params = map[string]string{}
+		}
+		prop.Params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+	return prop, nil
+}
+
+// ParseTime reads a DATE or DATE-TIME value; floating times and dates are taken as UTC.
+func ParseTime(p Property) (time.Time, error) {
+	if IsDate(p) {
+		return time.Parse(dateLayout, p.Value)
+	}
+	if strings.HasSuffix(p.Value, "Z") {
+		return time.Parse(DateTimeLayout, p.Value)
+	}
+
+	loc := time.UTC
+	if tzid := p.Params["TZID"]; tzid != "" {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown TZID %q", tzid)
+		}
+		loc = l
+	}
+	return time.ParseInLocation(localLayout, p.Value, loc)
+}
+
+func IsDate(p Property) bool {
+	return p.Params["VALUE"] == "DATE" || len(p.Value) == len(dateLayout)
+}
+
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(DateTimeLayout)
+}
+
+// FormatDate writes the calendar date of t as it reads in t's own location.
+func FormatDate(t time.Time) string {
+	return t.Format(dateLayout)
+}
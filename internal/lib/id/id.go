@@ -1,11 +1,20 @@
 package id
 
 import (
+	"errors"
+	"fmt"
 	"math/rand"
 	"strconv"
+	"sync"
 	"time"
 )
 
+var ErrClockSkew = errors.New("clock moved backwards beyond tolerated skew")
+
+type Generator interface {
+	NextID() (int64, error)
+}
+
 func ValidateID(id string) (int64, bool) {
 	idint64, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
@@ -15,7 +24,107 @@ func ValidateID(id string) (int64, bool) {
 	return idint64, true
 }
 
+var defaultRandom = NewRandomGenerator()
+
 func GenerateRandomID() int64 {
-	rand.New(rand.NewSource(time.Now().UnixNano()))
-	return rand.Int63()
+	id, _ := defaultRandom.NextID()
+	return id
+}
+
+type RandomGenerator struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func NewRandomGenerator() *RandomGenerator {
+	return &RandomGenerator{
+		rnd: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (g *RandomGenerator) NextID() (int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for {
+		if id := g.rnd.Int63(); id != 0 {
+			return id, nil
+		}
+	}
+}
+
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+	MaxNodeID    = 1<<nodeBits - 1
+	maxSequence  = 1<<sequenceBits - 1
+	// maxSkew is how far ahead of the wall clock ids may run after the clock moves backwards.
+	maxSkew = time.Second
+)
+
+var snowflakeEpoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+type SnowflakeGenerator struct {
+	mu       sync.Mutex
+	nodeID   int64
+	now      func() time.Time
+	lastMs   int64
+	sequence int64
+}
+
+// NewSnowflakeGenerator uses time.Now when now is nil.
+func NewSnowflakeGenerator(nodeID int64, now func() time.Time) (*SnowflakeGenerator, error) {
+	if nodeID < 0 || nodeID > MaxNodeID {
+		return nil, fmt.Errorf("node id must be between 0 and %d, got %d", MaxNodeID, nodeID)
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &SnowflakeGenerator{
+		nodeID: nodeID,
+		now:    now,
+	}, nil
+}
+
+func (g *SnowflakeGenerator) NextID() (int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := g.now().Sub(snowflakeEpoch).Milliseconds()
+	if ms < g.lastMs {
+		if time.Duration(g.lastMs-ms)*time.Millisecond > maxSkew {
+			return 0, fmt.Errorf("%w: %dms", ErrClockSkew, g.lastMs-ms)
+		}
+		ms = g.lastMs
+	}
+
+	if ms == g.lastMs {
+		g.sequence++
+		if g.sequence > maxSequence {
+			ms++
+			g.sequence = 0
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastMs = ms
+
+	return ms<<(nodeBits+sequenceBits) | g.nodeID<<sequenceBits | g.sequence, nil
+}
+
+const (
+	GeneratorSnowflake = "snowflake"
+	GeneratorRandom    = "random"
+)
+
+// NewGenerator picks a generator by name; an empty name means snowflake.
+func NewGenerator(name string, nodeID int64) (Generator, error) {
+	switch name {
+	case GeneratorSnowflake, "":
+		return NewSnowflakeGenerator(nodeID, nil)
+	case GeneratorRandom:
+		return NewRandomGenerator(), nil
+	default:
+		return nil, fmt.Errorf("unknown id generator %q", name)
+	}
 }
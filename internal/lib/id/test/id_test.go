@@ -0,0 +1,96 @@
+package id_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+)
+
+func TestSnowflakeGenerator_Ordered(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	gen, err := id.NewSnowflakeGenerator(7, func() time.Time { return now })
+	if err != nil {
+		t.Fatalf("error on creating generator: %v", err)
+	}
+
+	var last int64
+	for i := 0; i < 10000; i++ {
+		if i%1000 == 0 {
+			now = now.Add(time.Millisecond)
+		}
+		next, err := gen.NextID()
+		if err != nil {
+			t.Fatalf("error on generating id: %v", err)
+		}
+		if next <= last {
+			t.Fatalf("expected increasing ids, got %d after %d", next, last)
+		}
+		last = next
+	}
+}
+
+func TestSnowflakeGenerator_ClockSkew(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	gen, err := id.NewSnowflakeGenerator(1, func() time.Time { return now })
+	if err != nil {
+		t.Fatalf("error on creating generator: %v", err)
+	}
+
+	first, _ := gen.NextID()
+
+	now = now.Add(-100 * time.Millisecond)
+	second, err := gen.NextID()
+	if err != nil {
+		t.Fatalf("expected small skew to be tolerated, got %v", err)
+	}
+	if second <= first {
+		t.Fatalf("expected increasing ids across small skew, got %d after %d", second, first)
+	}
+
+	now = now.Add(-time.Hour)
+	if _, err := gen.NextID(); !errors.Is(err, id.ErrClockSkew) {
+		t.Fatalf("expected clock skew error, got %v", err)
+	}
+}
+
+func TestSnowflakeGenerator_Concurrent(t *testing.T) {
+	gen, err := id.NewSnowflakeGenerator(id.MaxNodeID, nil)
+	if err != nil {
+		t.Fatalf("error on creating generator: %v", err)
+	}
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[int64]bool)
+		wg   sync.WaitGroup
+	)
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				next, err := gen.NextID()
+				if err != nil {
+					t.Errorf("error on generating id: %v", err)
+					return
+				}
+				mu.Lock()
+				if seen[next] {
+					t.Errorf("duplicate id %d", next)
+				}
+				seen[next] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestNewSnowflakeGenerator_InvalidNode(t *testing.T) {
+	if _, err := id.NewSnowflakeGenerator(id.MaxNodeID+1, nil); err == nil {
+		t.Fatalf("expected error for node id above %d", id.MaxNodeID)
+	}
+}
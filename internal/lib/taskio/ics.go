@@ -0,0 +1,129 @@
+package taskio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/ical"
+)
+
+const ProdID = "-//gintokos//tasksrestapi//EN"
+
+// UID prefers the external id so round trips through other tools stay stable.
+func UID(task models.Task) string {
+	if task.ExternalID != "" {
+		return task.ExternalID
+	}
+	return strconv.FormatInt(task.ID, 10) + "@tasksrestapi"
+}
+
+func readICS(r io.Reader) ([]Record, error) {
+	roots, err := ical.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	row := 0
+	for _, cal := range roots {
+		if cal.Name != "VCALENDAR" {
+			return nil, fmt.Errorf("unexpected top-level component %s", cal.Name)
+		}
+		for _, c := range cal.Components {
+			if c.Name != "VTODO" {
+				continue
+			}
+			row++
+			records = append(records, todoRecord(row, c))
+		}
+	}
+	return records, nil
+}
+
+func todoRecord(row int, c ical.Component) Record {
+	rec := Record{Row: row}
+	if p, ok := c.Get("UID"); ok {
+		rec.Task.ExternalID = ical.UnescapeText(p.Value)
+	}
+	if p, ok := c.Get("SUMMARY"); ok {
+		rec.Task.Title = ical.UnescapeText(p.Value)
+	}
+	if p, ok := c.Get("DESCRIPTION"); ok {
+		rec.Task.Description = ical.UnescapeText(p.Value)
+	}
+	if p, ok := c.Get("DUE"); ok {
+		due, err := ical.ParseTime(p)
+		if err != nil {
+			rec.Err = fmt.Errorf("DUE: %w", err)
+			return rec
+		}
+		rec.Task.DueDate = &due
+		if ical.IsDate(p) {
+			rec.Task.DueKind = models.DueAllDay
+		}
+		rec.Task.TimeZone = p.Params["TZID"]
+	}
+	if rec.Task.ExternalID == "" {
+		rec.Err = errors.New("UID is required")
+	}
+	return rec
+}
+
+type icsWriter struct {
+	w     *ical.Writer
+	stamp string
+}
+
+func (w *icsWriter) Write(task models.Task) error {
+	WriteTodo(w.w, task, w.stamp)
+	return w.w.Err()
+}
+
+func (w *icsWriter) Close() error {
+	w.w.End("VCALENDAR")
+	return w.w.Flush()
+}
+
+func WriteTodo(w *ical.Writer, task models.Task, stamp string) {
+	w.Begin("VTODO")
+	w.Text("UID", UID(task))
+	w.Raw("DTSTAMP", stamp)
+	w.Text("SUMMARY", task.Title)
+	if task.Description != "" {
+		w.Text("DESCRIPTION", task.Description)
+	}
+	if task.DueDate != nil {
+		writeDue(w, "DUE", task)
+	}
+	w.Raw("STATUS", "NEEDS-ACTION")
+	w.End("VTODO")
+}
+
+// WriteDueEvent writes task as a VEVENT starting at its due date, for clients that do not show VTODOs.
+func WriteDueEvent(w *ical.Writer, task models.Task, stamp string) {
+	if task.DueDate == nil {
+		return
+	}
+	w.Begin("VEVENT")
+	w.Text("UID", "due-"+UID(task))
+	w.Raw("DTSTAMP", stamp)
+	w.Text("SUMMARY", task.Title)
+	if task.Description != "" {
+		w.Text("DESCRIPTION", task.Description)
+	}
+	writeDue(w, "DTSTART", task)
+	w.Raw("TRANSP", "TRANSPARENT")
+	w.End("VEVENT")
+}
+
+// writeDue keeps all-day deadlines as a DATE so clients show them on the right day in every zone.
+func writeDue(w *ical.Writer, name string, task models.Task) {
+	if task.DueKind == models.DueAllDay {
+		w.Raw(name+";VALUE=DATE", ical.FormatDate(task.DueDate.UTC()))
+		return
+	}
+	w.Raw(name, ical.FormatTime(*task.DueDate))
+}
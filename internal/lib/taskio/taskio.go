@@ -0,0 +1,265 @@
+package taskio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/ical"
+)
+
+type Format string
+
+const (
+	FormatJSONL Format = "jsonl"
+	FormatCSV   Format = "csv"
+	FormatICS   Format = "ics"
+)
+
+var ErrUnknownFormat = errors.New("format must be one of jsonl, csv, ics")
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatJSONL, FormatCSV, FormatICS:
+		return f, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatICS:
+		return "text/calendar; charset=utf-8"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// Record is one task read from an import; Row is the 1-based line, data row or VTODO it came from.
+type Record struct {
+	Row  int
+	Task models.Task
+	Err  error
+}
+
+// Read reports malformed rows in Record.Err.
+func Read(f Format, r io.Reader) ([]Record, error) {
+	switch f {
+	case FormatJSONL:
+		return readJSONL(r)
+	case FormatCSV:
+		return readCSV(r)
+	case FormatICS:
+		return readICS(r)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+type Writer interface {
+	Write(task models.Task) error
+	// Close flushes buffered output; it does not close the underlying writer.
+	Close() error
+}
+
+func NewWriter(f Format, w io.Writer) (Writer, error) {
+	switch f {
+	case FormatJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatICS:
+		iw := ical.NewWriter(w)
+		iw.Begin("VCALENDAR")
+		iw.Raw("VERSION", "2.0")
+		iw.Raw("PRODID", ProdID)
+		return &icsWriter{w: iw, stamp: ical.FormatTime(time.Now())}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// jsonlRecord accepts exported tasks back, ignoring the server-owned fields other than the id.
+type jsonlRecord struct {
+	ID          int64      `json:"id"`
+	ExternalID  string     `json:"externalId"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueDate     *time.Time `json:"dueDate"`
+	DueKind     string     `json:"dueKind"`
+	TimeZone    string     `json:"timeZone"`
+}
+
+func readJSONL(r io.Reader) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	var records []Record
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		var rec jsonlRecord
+		err := json.Unmarshal(raw, &rec)
+		records = append(records, Record{
+			Row: line,
+			Task: models.Task{
+				ExternalID:  exportedID(rec.ExternalID, rec.ID),
+				Title:       rec.Title,
+				Description: rec.Description,
+				DueDate:     rec.DueDate,
+				DueKind:     models.DueKind(rec.DueKind),
+				TimeZone:    rec.TimeZone,
+			},
+			Err: err,
+		})
+	}
+	return records, scanner.Err()
+}
+
+// exportedID gives a task exported without an external id the UID it has in iCalendar, so
+// importing an export updates the tasks it came from.
+func exportedID(externalID string, id int64) string {
+	if externalID == "" && id > 0 {
+		return UID(models.Task{ID: id})
+	}
+	return externalID
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (w *jsonlWriter) Write(task models.Task) error {
+	return w.enc.Encode(task)
+}
+
+func (w *jsonlWriter) Close() error {
+	return nil
+}
+
+var csvHeader = []string{"id", "externalId", "title", "description", "dueDate", "dueKind", "timeZone", "overDue"}
+
+func readCSV(r io.Reader) ([]Record, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New("header: title column is required")
+	}
+	field := func(row []string, name string) string {
+		i, ok := columns[strings.ToLower(name)]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return row[i]
+	}
+
+	var records []Record
+	for n := 1; ; n++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		rec := Record{Row: n}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			rec.Err = err
+			records = append(records, rec)
+			continue
+		}
+
+		id, _ := strconv.ParseInt(strings.TrimSpace(field(row, "id")), 10, 64)
+		rec.Task = models.Task{
+			ExternalID:  exportedID(field(row, "externalId"), id),
+			Title:       field(row, "title"),
+			Description: field(row, "description"),
+			DueKind:     models.DueKind(strings.TrimSpace(field(row, "dueKind"))),
+			TimeZone:    strings.TrimSpace(field(row, "timeZone")),
+		}
+		if raw := strings.TrimSpace(field(row, "dueDate")); raw != "" {
+			due, err := time.Parse(time.RFC3339, raw)
+			if err != nil && len(raw) == len(time.DateOnly) {
+				due, err = time.Parse(time.DateOnly, raw)
+				if err == nil && rec.Task.DueKind == "" {
+					rec.Task.DueKind = models.DueAllDay
+				}
+			}
+			if err != nil {
+				rec.Err = fmt.Errorf("dueDate: %w", err)
+			} else {
+				rec.Task.DueDate = &due
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (w *csvWriter) Write(task models.Task) error {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if err := w.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+
+	due := ""
+	if task.DueDate != nil {
+		due = task.DueDate.UTC().Format(time.RFC3339)
+		if task.DueKind == models.DueAllDay {
+			due = task.DueDate.UTC().Format(time.DateOnly)
+		}
+	}
+	return w.w.Write([]string{
+		strconv.FormatInt(task.ID, 10),
+		task.ExternalID,
+		task.Title,
+		task.Description,
+		due,
+		string(task.DueKind),
+		task.TimeZone,
+		strconv.FormatBool(task.OverDue),
+	})
+}
+
+func (w *csvWriter) Close() error {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.w.Write(csvHeader)
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
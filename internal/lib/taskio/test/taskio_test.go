@@ -0,0 +1,93 @@
+package taskio_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/taskio"
+)
+
+func TestRoundTrip(t *testing.T) {
+	due := time.Date(2025, 3, 4, 15, 30, 0, 0, time.UTC)
+	tasks := []models.Task{
+		{ID: 1, ExternalID: "ext-1", Title: "Plain", Description: "line one\nline two, with; punctuation", DueDate: &due},
+		{ID: 2, ExternalID: "ext-2", Title: strings.Repeat("Долгий заголовок ", 10)},
+	}
+
+	for _, format := range []taskio.Format{taskio.FormatJSONL, taskio.FormatCSV, taskio.FormatICS} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := taskio.NewWriter(format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, task := range tasks {
+				if err := w.Write(task); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if format == taskio.FormatICS {
+				for _, line := range strings.Split(buf.String(), "\r\n") {
+					if len(line) > 75 {
+						t.Fatalf("line longer than 75 octets: %q", line)
+					}
+				}
+			}
+
+			records, err := taskio.Read(format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != len(tasks) {
+				t.Fatalf("expected %d records, got %d", len(tasks), len(records))
+			}
+			for i, rec := range records {
+				want := tasks[i]
+				if rec.Err != nil {
+					t.Fatalf("record %d: %v", i, rec.Err)
+				}
+				sameDue := (want.DueDate == nil && rec.Task.DueDate == nil) ||
+					(want.DueDate != nil && rec.Task.DueDate != nil && want.DueDate.Equal(*rec.Task.DueDate))
+				if rec.Task.ExternalID != want.ExternalID || rec.Task.Title != want.Title ||
+					rec.Task.Description != want.Description || !sameDue {
+					t.Fatalf("record %d: expected %+v, got %+v", i, want, rec.Task)
+				}
+			}
+		})
+	}
+}
+
+func TestRead_RowErrors(t *testing.T) {
+	csv := "title,dueDate\nGood,2025-01-01T00:00:00Z\nBad,tomorrow\n"
+	records, err := taskio.Read(taskio.FormatCSV, strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Err != nil || records[1].Err == nil || records[1].Row != 2 {
+		t.Fatalf("expected an error on row 2 only, got %+v", records)
+	}
+
+	ics := "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:a\r\nSUMMARY:Dated\r\nDUE;TZID=Europe/Berlin:20250601T090000\r\nEND:VTODO\r\n" +
+		"BEGIN:VTODO\r\nSUMMARY:No uid\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+	records, err = taskio.Read(taskio.FormatICS, strings.NewReader(ics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Err != nil || records[1].Err == nil {
+		t.Fatalf("expected an error on the second VTODO only, got %+v", records)
+	}
+	if want := time.Date(2025, 6, 1, 7, 0, 0, 0, time.UTC); !records[0].Task.DueDate.Equal(want) {
+		t.Fatalf("expected DUE %s, got %s", want, records[0].Task.DueDate)
+	}
+
+	if _, err := taskio.Read(taskio.FormatCSV, strings.NewReader("name\nx\n")); err == nil {
+		t.Fatalf("expected missing title column to fail the whole import")
+	}
+}
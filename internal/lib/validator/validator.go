@@ -0,0 +1,74 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+type Violation struct {
+	Field string `json:"field"`
+	Msg   string `json:"msg"`
+}
+
+type Violations []Violation
+
+func (v Violations) Error() string {
+	parts := make([]string, 0, len(v))
+	for _, violation := range v {
+		parts = append(parts, fmt.Sprintf("%s: %s", violation.Field, violation.Msg))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Rule checks a single field of T and returns a non-empty message when the value is invalid.
+type Rule[T any] struct {
+	Field string
+	Check func(v T) string
+}
+
+func Validate[T any](v T, rules []Rule[T]) Violations {
+	var violations Violations
+	for _, rule := range rules {
+		if msg := rule.Check(v); msg != "" {
+			violations = append(violations, Violation{Field: rule.Field, Msg: msg})
+		}
+	}
+	return violations
+}
+
+func Required[T any](field string, get func(T) string) Rule[T] {
+	return Rule[T]{
+		Field: field,
+		Check: func(v T) string {
+			if strings.TrimSpace(get(v)) == "" {
+				return "is required"
+			}
+			return ""
+		},
+	}
+}
+
+func MaxLen[T any](field string, max int, get func(T) string) Rule[T] {
+	return Rule[T]{
+		Field: field,
+		Check: func(v T) string {
+			if utf8.RuneCountInString(get(v)) > max {
+				return fmt.Sprintf("must be at most %d characters", max)
+			}
+			return ""
+		},
+	}
+}
+
+func ReadOnly[T any](field string, isSet func(T) bool) Rule[T] {
+	return Rule[T]{
+		Field: field,
+		Check: func(v T) string {
+			if isSet(v) {
+				return "is server-owned and cannot be set"
+			}
+			return ""
+		},
+	}
+}
@@ -0,0 +1,174 @@
+package notify_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/notify"
+)
+
+func notification() notify.Notification {
+	due := time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)
+	return notify.Notification{
+		Reminder: models.Reminder{ID: 7, TaskID: 1, LeadSeconds: 3600},
+		Task:     models.Task{ID: 1, Title: "Pay rent", DueDate: &due},
+	}
+}
+
+// fakeSMTP speaks just enough SMTP for net/smtp and hands every received message to the returned channel.
+func fakeSMTP(t *testing.T) (string, <-chan string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, messages)
+		}
+	}()
+	return ln.Addr().String(), messages
+}
+
+func serveSMTP(conn net.Conn, messages chan<- string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+
+	reply("220 fake ESMTP")
+	var data strings.Builder
+	inData := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		if inData {
+			if line == ".\r\n" {
+				inData = false
+				messages <- data.String()
+				reply("250 OK")
+				continue
+			}
+			data.WriteString(line)
+			continue
+		}
+
+		switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 fake")
+		case strings.HasPrefix(cmd, "DATA"):
+			inData = true
+			reply("354 go ahead")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	addr, messages := fakeSMTP(t)
+
+	n, err := notify.New(config.NotifierConfig{
+		Kind: "smtp",
+		SMTP: config.SMTPConfig{Addr: addr, From: "tasks@example.com", To: []string{"alice@example.com"}},
+	}, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := n.Notify(context.Background(), notification()); err != nil {
+		t.Fatalf("error on sending mail: %v", err)
+	}
+
+	select {
+	case msg := <-messages:
+		if !strings.Contains(msg, "Subject: Reminder: Pay rent") || !strings.Contains(msg, "To: alice@example.com") {
+			t.Fatalf("unexpected message:\n%s", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("fake SMTP server received no message")
+	}
+}
+
+func TestSMTPNotifier_StopsWithContext(t *testing.T) {
+	// The server accepts connections but never greets, like a stuck relay.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	n := notify.NewSMTPNotifier(config.SMTPConfig{Addr: ln.Addr().String(), From: "tasks@example.com", To: []string{"alice@example.com"}, Timeout: 60})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	if err := n.Notify(ctx, notification()); err == nil {
+		t.Fatal("expected an error from a server that never answers")
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Fatalf("expected Notify to give up with the context, took %v", elapsed)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	received := make(chan notify.Notification, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notify.Notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- n
+	}))
+	defer srv.Close()
+
+	n := notify.NewWebhookNotifier(srv.URL, time.Second)
+	if err := n.Notify(context.Background(), notification()); err != nil {
+		t.Fatalf("error on calling webhook: %v", err)
+	}
+	if got := <-received; got.Reminder.ID != 7 || got.Task.Title != "Pay rent" {
+		t.Fatalf("unexpected payload %+v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	if err := notify.NewWebhookNotifier(failing.URL, time.Second).Notify(context.Background(), notification()); err == nil {
+		t.Fatalf("expected an error for a 502 response")
+	}
+}
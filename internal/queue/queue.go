@@ -0,0 +1,307 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/jobs"
+	"github.com/gintokos/tasksrestapi/internal/lib/clock"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+const JobPurge = "queue-purge"
+
+const (
+	defaultWorkers      = 4
+	defaultPollInterval = time.Second
+	defaultLeaseTTL     = 5 * time.Minute
+	defaultMaxAttempts  = 5
+	defaultBackoffBase  = 10 * time.Second
+	defaultBackoffMax   = time.Hour
+)
+
+var ErrNoHandler = errors.New("no handler for this kind of job")
+
+// Handler must return once ctx is done.
+type Handler func(ctx context.Context, job models.QueuedJob) error
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent sends the job straight to the dead letters.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Pool stays idle for storages without a QueueStore.
+type Pool struct {
+	store  storage.QueueStore
+	clock  clock.Clock
+	logger *slog.Logger
+	worker string
+
+	workers     int
+	poll        time.Duration
+	leaseTTL    time.Duration
+	maxAttempts int
+	backoffBase time.Duration
+	backoffMax  time.Duration
+	retention   time.Duration
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	busy     int
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewPool(logger *slog.Logger, st storage.Storage, worker string, cfg config.QueueConfig) *Pool {
+	p := &Pool{
+		clock:       services.ClockOf(st),
+		logger:      logger,
+		worker:      worker,
+		workers:     cfg.Workers,
+		poll:        cfg.PollInterval.Duration(),
+		leaseTTL:    cfg.LeaseTTL.Duration(),
+		maxAttempts: cfg.MaxAttempts,
+		backoffBase: cfg.BackoffBase.Duration(),
+		backoffMax:  cfg.BackoffMax.Duration(),
+		retention:   cfg.Retention.Duration(),
+		handlers:    make(map[string]Handler),
+	}
+	if p.workers <= 0 {
+		p.workers = defaultWorkers
+	}
+	if p.poll <= 0 {
+		p.poll = defaultPollInterval
+	}
+	if p.leaseTTL <= 0 {
+		p.leaseTTL = defaultLeaseTTL
+	}
+	if p.maxAttempts <= 0 {
+		p.maxAttempts = defaultMaxAttempts
+	}
+	if p.backoffBase <= 0 {
+		p.backoffBase = defaultBackoffBase
+	}
+	if p.backoffMax < p.backoffBase {
+		p.backoffMax = max(defaultBackoffMax, p.backoffBase)
+	}
+
+	if store, ok := st.(storage.QueueStore); ok {
+		p.store = store
+	}
+	return p
+}
+
+func (p *Pool) Enabled() bool {
+	return p.store != nil
+}
+
+// Handle must be called before Start.
+func (p *Pool) Handle(kind string, h Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.handlers[kind] = h
+}
+
+func (p *Pool) Jobs() []jobs.Job {
+	if p.store == nil || p.retention <= 0 {
+		return nil
+	}
+	return []jobs.Job{{Name: JobPurge, Interval: time.Hour, LeaderOnly: true, Run: p.purge}}
+}
+
+func (p *Pool) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.store == nil || p.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	p.wg.Add(1)
+	go p.loop(ctx)
+}
+
+// Shutdown returns interrupted jobs to the queue without using up an attempt.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	cancel := p.cancel
+	p.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for queued jobs to stop: %w", ctx.Err())
+	}
+}
+
+func (p *Pool) loop(ctx context.Context) {
+	defer p.wg.Done()
+
+	ticker := p.clock.NewTicker(p.poll)
+	defer ticker.Stop()
+
+	for {
+		p.claim(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+func (p *Pool) claim(ctx context.Context) {
+	p.mu.Lock()
+	free := p.workers - p.busy
+	p.mu.Unlock()
+	if free <= 0 {
+		return
+	}
+
+	now := p.clock.Now()
+	claimed, err := p.store.ClaimJobs(p.worker, now, now.Add(p.leaseTTL), free, p.logger)
+	if err != nil {
+		p.logger.Error("error on claiming queued jobs", sl.Err(err))
+		return
+	}
+
+	for _, job := range claimed {
+		p.mu.Lock()
+		p.busy++
+		p.mu.Unlock()
+
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.process(ctx, job)
+
+			p.mu.Lock()
+			p.busy--
+			p.mu.Unlock()
+		}()
+	}
+}
+
+func (p *Pool) process(ctx context.Context, job models.QueuedJob) {
+	maxAttempts := job.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = p.maxAttempts
+	}
+
+	p.mu.Lock()
+	handler := p.handlers[job.Kind]
+	p.mu.Unlock()
+
+	var err error
+	switch {
+	case handler == nil:
+		err = Permanent(fmt.Errorf("%w: %s", ErrNoHandler, job.Kind))
+	case job.Attempts > maxAttempts:
+		// The last attempt never reported back before its lease expired.
+		err = Permanent(fmt.Errorf("gave up after %d attempts", maxAttempts))
+	default:
+		runCtx, cancel := context.WithTimeout(ctx, p.leaseTTL)
+		err = p.safeRun(runCtx, handler, job)
+		cancel()
+	}
+
+	now := p.clock.Now().UTC()
+	job.UpdatedAt = now
+	var permanent permanentError
+	switch {
+	case err == nil:
+		job.State = models.JobDone
+		job.LastError = ""
+		job.FinishedAt = &now
+	case ctx.Err() != nil:
+		job.State = models.JobPending
+		job.RunAt = now
+		job.Attempts--
+		job.LastError = "interrupted by shutdown"
+	case errors.As(err, &permanent) || job.Attempts >= maxAttempts:
+		job.State = models.JobDead
+		job.LastError = err.Error()
+		job.FinishedAt = &now
+		p.logger.Error("queued job moved to dead letters", slog.Int64("id", job.ID), slog.String("kind", job.Kind), sl.Err(err))
+	default:
+		job.State = models.JobPending
+		job.RunAt = now.Add(p.backoff(job.Attempts))
+		job.LastError = err.Error()
+		p.logger.Info("queued job failed, will retry", slog.Int64("id", job.ID), slog.String("kind", job.Kind),
+			slog.Int("attempt", job.Attempts), slog.Time("runAt", job.RunAt), sl.Err(err))
+	}
+
+	if err := p.store.FinishJob(job, p.worker, p.logger); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			p.logger.Info("queued job was cancelled or lost its lease while running", slog.Int64("id", job.ID))
+			return
+		}
+		p.logger.Error("error on finishing queued job", slog.Int64("id", job.ID), sl.Err(err))
+	}
+}
+
+func (p *Pool) backoff(attempts int) time.Duration {
+	d := p.backoffBase
+	for i := 1; i < attempts && d < p.backoffMax; i++ {
+		d *= 2
+	}
+	return min(d, p.backoffMax)
+}
+
+func (p *Pool) safeRun(ctx context.Context, handler Handler, job models.QueuedJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.logger.Error("queued job panicked", slog.Int64("id", job.ID), slog.String("kind", job.Kind),
+				slog.Any("panic", r), slog.String("stack", string(debug.Stack())))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+func (p *Pool) purge(ctx context.Context) error {
+	purged, err := p.store.PurgeJobs(p.clock.Now().Add(-p.retention), p.logger)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		p.logger.Info("purged finished queued jobs", slog.Int64("count", purged))
+	}
+	return nil
+}
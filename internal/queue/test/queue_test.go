@@ -0,0 +1,232 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/clock/fakeclock"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/queue"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage/memory"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+)
+
+var (
+	logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	start  = time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC)
+)
+
+var testConfig = config.QueueConfig{Workers: 2, PollInterval: 1, LeaseTTL: 60, MaxAttempts: 3, BackoffBase: 10, BackoffMax: 15}
+
+func newStorage(t *testing.T, fc *fakeclock.Clock) *sqllite.Storage {
+	t.Helper()
+
+	st, err := sqllite.NewStorage(filepath.Join(t.TempDir(), "storage.db"), id.NewRandomGenerator(), sqllite.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+	st.SetClock(fc)
+	return st
+}
+
+func startPool(t *testing.T, fc *fakeclock.Clock, pool *queue.Pool) {
+	t.Helper()
+
+	pool.Start()
+	t.Cleanup(func() { pool.Shutdown(context.Background()) })
+	fc.WaitForTickers(1)
+}
+
+func enqueue(t *testing.T, st *sqllite.Storage, kind string) int64 {
+	t.Helper()
+
+	job, _, err := services.EnqueueJob(kind, map[string]string{"kind": kind}, start, "", st, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job.ID
+}
+
+// waitForJob waits in real time, since the workers finish jobs in their own goroutines.
+func waitForJob(t *testing.T, st *sqllite.Storage, id int64, want string, cond func(job models.QueuedJob) bool) models.QueuedJob {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := st.GetJob(id, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.LeaseHolder == "" && cond(job) {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected job %d to be %s, got %+v", id, want, job)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func inState(state models.QueuedJobState) func(job models.QueuedJob) bool {
+	return func(job models.QueuedJob) bool { return job.State == state }
+}
+
+func retrying(attempts int) func(job models.QueuedJob) bool {
+	return func(job models.QueuedJob) bool { return job.State == models.JobPending && job.Attempts == attempts }
+}
+
+func TestPool_RunsJobsAndRetriesWithBackoff(t *testing.T) {
+	fc := fakeclock.New(start)
+	st := newStorage(t, fc)
+
+	var okRuns, flakyRuns atomic.Int32
+	pool := queue.NewPool(logger, st, "worker-1", testConfig)
+	pool.Handle("ok", func(ctx context.Context, job models.QueuedJob) error {
+		if string(job.Payload) != `{"kind":"ok"}` {
+			t.Errorf("unexpected payload %s", job.Payload)
+		}
+		okRuns.Add(1)
+		return nil
+	})
+	pool.Handle("flaky", func(ctx context.Context, job models.QueuedJob) error {
+		flakyRuns.Add(1)
+		return errors.New("upstream unavailable")
+	})
+
+	okID := enqueue(t, st, "ok")
+	flakyID := enqueue(t, st, "flaky")
+	startPool(t, fc, pool)
+
+	if job := waitForJob(t, st, okID, "done", inState(models.JobDone)); job.Attempts != 1 || job.FinishedAt == nil || okRuns.Load() != 1 {
+		t.Fatalf("expected the job to be done after one run, got %+v runs=%d", job, okRuns.Load())
+	}
+
+	job := waitForJob(t, st, flakyID, "retrying", retrying(1))
+	if !job.RunAt.Equal(start.Add(10*time.Second)) || job.LastError != "upstream unavailable" {
+		t.Fatalf("expected the first retry after the base backoff, got %+v", job)
+	}
+
+	// The backoff doubles but is capped at 15s.
+	fc.Advance(10 * time.Second)
+	job = waitForJob(t, st, flakyID, "retrying", retrying(2))
+	if want := start.Add(25 * time.Second); !job.RunAt.Equal(want) {
+		t.Fatalf("expected the second retry at %v, got %+v", want, job)
+	}
+
+	fc.Advance(15 * time.Second)
+	job = waitForJob(t, st, flakyID, "dead", inState(models.JobDead))
+	if job.Attempts != 3 || flakyRuns.Load() != 3 || job.LastError != "upstream unavailable" {
+		t.Fatalf("expected the job dead-lettered after 3 attempts, got %+v runs=%d", job, flakyRuns.Load())
+	}
+
+	if _, err := services.RetryQueuedJob(flakyID, st, logger); err != nil {
+		t.Fatal(err)
+	}
+	fc.Advance(time.Second)
+	waitForJob(t, st, flakyID, "retrying", retrying(1))
+	if flakyRuns.Load() != 4 {
+		t.Fatalf("expected the retried job to run once more, got %d runs", flakyRuns.Load())
+	}
+}
+
+func TestPool_DeadLettersPermanentFailures(t *testing.T) {
+	fc := fakeclock.New(start)
+	st := newStorage(t, fc)
+
+	// A worker that died holding a job with no attempts left leaves it for the pool to give up on.
+	crashed, _, err := st.EnqueueJob(models.QueuedJob{Kind: "fatal", MaxAttempts: 1, RunAt: start, CreatedAt: start}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.ClaimJobs("crashed", start, start.Add(time.Second), 1, logger); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testConfig
+	cfg.Workers = 3
+	pool := queue.NewPool(logger, st, "worker-1", cfg)
+	pool.Handle("fatal", func(ctx context.Context, job models.QueuedJob) error {
+		return queue.Permanent(errors.New("payload is not valid"))
+	})
+	pool.Handle("panics", func(ctx context.Context, job models.QueuedJob) error {
+		panic("boom")
+	})
+
+	fatalID := enqueue(t, st, "fatal")
+	unknownID := enqueue(t, st, "unknown")
+	panicID := enqueue(t, st, "panics")
+	startPool(t, fc, pool)
+
+	if job := waitForJob(t, st, fatalID, "dead", inState(models.JobDead)); job.Attempts != 1 || job.LastError != "payload is not valid" {
+		t.Fatalf("expected a permanent failure to be dead after one attempt, got %+v", job)
+	}
+	if job := waitForJob(t, st, unknownID, "dead", inState(models.JobDead)); !strings.Contains(job.LastError, queue.ErrNoHandler.Error()) {
+		t.Fatalf("expected a job without handler to be dead, got %+v", job)
+	}
+	if job := waitForJob(t, st, panicID, "retrying", retrying(1)); job.LastError != "panic: boom" {
+		t.Fatalf("expected a panic to be recorded as a failed attempt, got %+v", job)
+	}
+
+	fc.Advance(time.Second)
+	if job := waitForJob(t, st, crashed.ID, "dead", inState(models.JobDead)); job.Attempts != 2 || !strings.Contains(job.LastError, "gave up") {
+		t.Fatalf("expected the job to be given up once its lease expired, got %+v", job)
+	}
+}
+
+func TestPool_ShutdownReturnsRunningJobs(t *testing.T) {
+	fc := fakeclock.New(start)
+	st := newStorage(t, fc)
+
+	started := make(chan struct{})
+	pool := queue.NewPool(logger, st, "worker-1", testConfig)
+	pool.Handle("slow", func(ctx context.Context, job models.QueuedJob) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	slowID := enqueue(t, st, "slow")
+	startPool(t, fc, pool)
+	<-started
+
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	job, err := st.GetJob(slowID, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.State != models.JobPending || job.Attempts != 0 || job.LeaseHolder != "" || job.LastError != "interrupted by shutdown" {
+		t.Fatalf("expected the interrupted job back in the queue without using an attempt, got %+v", job)
+	}
+}
+
+func TestPool_IdleWithoutQueueStore(t *testing.T) {
+	st, err := memory.NewStorage(id.NewRandomGenerator(), "", 0, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := queue.NewPool(logger, st, "worker-1", testConfig)
+	if pool.Enabled() || pool.Jobs() != nil {
+		t.Fatal("expected the pool to be disabled without a queue store")
+	}
+	pool.Start()
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := services.EnqueueJob("ok", nil, start, "", st, logger); !errors.Is(err, services.ErrQueueUnsupported) {
+		t.Fatalf("expected ErrQueueUnsupported, got %v", err)
+	}
+}
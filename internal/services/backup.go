@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+var ErrBackupUnsupported = errors.New("storage does not support online backups")
+
+const (
+	backupPrefix = "storage-"
+	backupSuffix = ".db"
+	backupLayout = "20060102T150405.000Z"
+)
+
+// CreateBackup keeps every backup when keep <= 0.
+func CreateBackup(dir string, keep int, now time.Time, st storage.Storage, logger *slog.Logger) (models.Backup, error) {
+	backuper, ok := st.(storage.Backuper)
+	if !ok {
+		return models.Backup{}, ErrBackupUnsupported
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return models.Backup{}, err
+	}
+
+	createdAt := now.UTC()
+	name := backupPrefix + createdAt.Format(backupLayout) + backupSuffix
+	path := filepath.Join(dir, name)
+
+	// Rotation and restore never see a partial file.
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	if err := backuper.Backup(tmp, logger); err != nil {
+		os.Remove(tmp)
+		return models.Backup{}, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return models.Backup{}, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return models.Backup{}, err
+	}
+
+	if keep > 0 {
+		if err := rotateBackups(dir, keep, logger); err != nil {
+			logger.Error("error on rotating backups", sl.Err(err))
+		}
+	}
+
+	return models.Backup{Name: name, Path: path, Size: info.Size(), CreatedAt: createdAt}, nil
+}
+
+func ListBackups(dir string) ([]models.Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var backups []models.Backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+			continue
+		}
+		createdAt, err := time.Parse(backupLayout, strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix))
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, models.Backup{
+			Name:      name,
+			Path:      filepath.Join(dir, name),
+			Size:      info.Size(),
+			CreatedAt: createdAt,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+func rotateBackups(dir string, keep int, logger *slog.Logger) error {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return err
+	}
+	if len(backups) <= keep {
+		return nil
+	}
+
+	var errs []error
+	for _, b := range backups[keep:] {
+		if err := os.Remove(b.Path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		logger.Info("removed old backup", slog.String("name", b.Name))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+var (
+	ErrCalendarUnsupported = errors.New("storage does not support calendar feeds")
+	ErrFeedUnauthorized    = errors.New("a valid feed token is required to manage an existing feed")
+)
+
+func GetCalendarFeed(token string, st storage.Storage, logger *slog.Logger) (models.CalendarFeed, error) {
+	return feedForManagement(token, st, logger)
+}
+
+// SaveCalendarFeed only creates a feed for an owner without one; otherwise the token is required.
+func SaveCalendarFeed(settings models.CalendarFeed, token string, now time.Time, st storage.Storage, logger *slog.Logger) (models.CalendarFeed, string, error) {
+	fs, ok := st.(storage.FeedStore)
+	if !ok {
+		return models.CalendarFeed{}, "", ErrCalendarUnsupported
+	}
+
+	var (
+		feed     models.CalendarFeed
+		newToken string
+		err      error
+	)
+	if token != "" {
+		if feed, err = feedForManagement(token, st, logger); err != nil {
+			return models.CalendarFeed{}, "", err
+		}
+	} else {
+		_, err = fs.GetFeedByOwner(settings.Owner, logger)
+		switch {
+		case err == nil:
+			return models.CalendarFeed{}, "", ErrFeedUnauthorized
+		case !errors.Is(err, storage.ErrNotFound):
+			return models.CalendarFeed{}, "", err
+		}
+		if newToken, err = newFeedToken(); err != nil {
+			return models.CalendarFeed{}, "", err
+		}
+		feed = models.CalendarFeed{Owner: settings.Owner, TokenHash: hashFeedToken(newToken), CreatedAt: now}
+	}
+
+	feed.Components = settings.Components
+	feed.IncludeOverdue = settings.IncludeOverdue
+	feed.UpdatedAt = now
+	if err := fs.SaveFeed(feed, logger); err != nil {
+		return models.CalendarFeed{}, "", err
+	}
+	return feed, newToken, nil
+}
+
+func RegenerateFeedToken(token string, now time.Time, st storage.Storage, logger *slog.Logger) (models.CalendarFeed, string, error) {
+	feed, err := feedForManagement(token, st, logger)
+	if err != nil {
+		return models.CalendarFeed{}, "", err
+	}
+
+	newToken, err := newFeedToken()
+	if err != nil {
+		return models.CalendarFeed{}, "", err
+	}
+	feed.TokenHash = hashFeedToken(newToken)
+	feed.UpdatedAt = now
+	if err := st.(storage.FeedStore).SaveFeed(feed, logger); err != nil {
+		return models.CalendarFeed{}, "", err
+	}
+	return feed, newToken, nil
+}
+
+// RevokeCalendarFeed needs no token, so an owner who lost it can drop the feed and create a new
+// one; revoking never hands out access to the old feed.
+func RevokeCalendarFeed(owner string, st storage.Storage, logger *slog.Logger) error {
+	fs, ok := st.(storage.FeedStore)
+	if !ok {
+		return ErrCalendarUnsupported
+	}
+	return fs.DeleteFeed(owner, logger)
+}
+
+func feedForManagement(token string, st storage.Storage, logger *slog.Logger) (models.CalendarFeed, error) {
+	if _, ok := st.(storage.FeedStore); !ok {
+		return models.CalendarFeed{}, ErrCalendarUnsupported
+	}
+	if token == "" {
+		return models.CalendarFeed{}, ErrFeedUnauthorized
+	}
+	feed, err := GetCalendarFeedByToken(token, st, logger)
+	if errors.Is(err, storage.ErrNotFound) {
+		return models.CalendarFeed{}, ErrFeedUnauthorized
+	}
+	return feed, err
+}
+
+func GetCalendarFeedByToken(token string, st storage.Storage, logger *slog.Logger) (models.CalendarFeed, error) {
+	fs, ok := st.(storage.FeedStore)
+	if !ok {
+		return models.CalendarFeed{}, ErrCalendarUnsupported
+	}
+	return fs.GetFeedByToken(hashFeedToken(token), logger)
+}
+
+func FeedTasks(feed models.CalendarFeed, st storage.Storage, logger *slog.Logger) ([]models.Task, time.Time, error) {
+	all, err := st.GetAllTasks(logger)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var tasks []models.Task
+	for _, task := range all {
+		if task.DueDate == nil || (task.OverDue && !feed.IncludeOverdue) {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+
+	modified := feed.UpdatedAt
+	if es, ok := st.(storage.EventStore); ok {
+		last, err := es.LastEventAt(logger)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		if last.After(modified) {
+			modified = last
+		}
+	}
+	return tasks, modified.UTC(), nil
+}
+
+func newFeedToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
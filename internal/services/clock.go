@@ -0,0 +1,14 @@
+package services
+
+import (
+	"github.com/gintokos/tasksrestapi/internal/lib/clock"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+// ClockOf returns the clock st stamps times with, so callers judge deadlines by the same time.
+func ClockOf(st storage.Storage) clock.Clock {
+	if c, ok := st.(storage.Clocked); ok {
+		return c.Clock()
+	}
+	return clock.Real
+}
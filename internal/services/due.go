@@ -0,0 +1,57 @@
+package services
+
+import (
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+)
+
+func Deadline(task models.Task) *time.Time {
+	if task.DueDate == nil {
+		return nil
+	}
+	if task.DueKind != models.DueAllDay {
+		due := task.DueDate.UTC()
+		return &due
+	}
+
+	y, m, d := task.DueDate.Date()
+	end := time.Date(y, m, d+1, 0, 0, 0, 0, Location(task.TimeZone)).UTC()
+	return &end
+}
+
+// IsOverdue is the only source of Task.OverDue: a task is overdue once its deadline has passed.
+func IsOverdue(task models.Task, now time.Time) bool {
+	deadline := Deadline(task)
+	return deadline != nil && !now.Before(*deadline)
+}
+
+// Location resolves an IANA zone name, treating empty or unknown names as UTC.
+func Location(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// normalizeDue stores all-day dates as midnight UTC of the date the client wrote.
+func normalizeDue(task models.Task) models.Task {
+	if task.DueDate == nil {
+		task.DueKind = ""
+		return task
+	}
+
+	due := task.DueDate.UTC()
+	if task.DueKind == models.DueAllDay {
+		y, m, d := task.DueDate.Date()
+		due = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	} else {
+		task.DueKind = models.DueInstant
+	}
+	task.DueDate = &due
+	return task
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+const (
+	ActorAnonymous = "anonymous"
+	ActorChecker   = "checker"
+)
+
+var ErrHistoryUnsupported = errors.New("storage does not keep task history")
+
+func GetTaskHistory(id int64, st storage.Storage, logger *slog.Logger) ([]models.TaskEvent, error) {
+	es, ok := st.(storage.EventStore)
+	if !ok {
+		return nil, ErrHistoryUnsupported
+	}
+
+	events, err := es.GetTaskEvents(id, logger)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, storage.ErrNotFound
+	}
+	return events, nil
+}
+
+// GetTaskAsOf rebuilds a task from the last event recorded at or before asOf.
+func GetTaskAsOf(id int64, asOf time.Time, st storage.Storage, logger *slog.Logger) (models.Task, error) {
+	events, err := GetTaskHistory(id, st, logger)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	var state *models.Task
+	for _, event := range events {
+		if event.At.After(asOf) {
+			break
+		}
+		state = event.After
+	}
+	if state == nil {
+		return models.Task{}, storage.ErrNotFound
+	}
+	return *state, nil
+}
+
+// withinTx runs fn directly for backends without an EventStore.
+func withinTx(st storage.Storage, logger *slog.Logger, fn func(st storage.Storage) error) error {
+	if es, ok := st.(storage.EventStore); ok {
+		return es.WithinTx(fn, logger)
+	}
+	return fn(st)
+}
+
+func recordEvent(eventType models.EventType, actor string, before, after *models.Task, st storage.Storage, logger *slog.Logger) error {
+	_, err := appendEvent(models.TaskEvent{Type: eventType, Actor: actor}, before, after, st, logger)
+	return err
+}
+
+func appendEvent(event models.TaskEvent, before, after *models.Task, st storage.Storage, logger *slog.Logger) (models.TaskEvent, error) {
+	es, ok := st.(storage.EventStore)
+	if !ok {
+		return event, nil
+	}
+
+	changes, err := diffTasks(before, after)
+	if err != nil {
+		return models.TaskEvent{}, err
+	}
+	if (event.Type == models.EventUpdated || event.Type == models.EventOverdueCleared) && len(changes) == 0 {
+		return event, nil
+	}
+
+	event.At = ClockOf(st).Now().UTC()
+	event.Changes = changes
+	event.Before = before
+	event.After = after
+	if after != nil {
+		event.TaskID = after.ID
+	} else if before != nil {
+		event.TaskID = before.ID
+	}
+
+	return es.AppendEvent(event, logger)
+}
+
+func diffTasks(before, after *models.Task) ([]models.FieldChange, error) {
+	beforeFields, err := taskFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := taskFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(beforeFields)+len(afterFields))
+	for name := range beforeFields {
+		names[name] = true
+	}
+	for name := range afterFields {
+		names[name] = true
+	}
+	delete(names, "id")
+
+	changes := []models.FieldChange{}
+	for name := range names {
+		b, a := beforeFields[name], afterFields[name]
+		if bytes.Equal(b, a) {
+			continue
+		}
+		changes = append(changes, models.FieldChange{Field: name, Before: nullIfEmpty(b), After: nullIfEmpty(a)})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	return changes, nil
+}
+
+func taskFields(task *models.Task) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if task == nil {
+		return fields, nil
+	}
+
+	b, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range fields {
+		if bytes.Equal(value, []byte("null")) {
+			delete(fields, name)
+		}
+	}
+	return fields, nil
+}
+
+func nullIfEmpty(value json.RawMessage) json.RawMessage {
+	if value == nil {
+		return json.RawMessage("null")
+	}
+	return value
+}
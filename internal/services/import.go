@@ -0,0 +1,63 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/taskio"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+var ErrDuplicateExternalID = errors.New("external id appears more than once in the import")
+
+// PlanImport updates the live task with the same external id and creates everything else. Tasks
+// without an external id are matched by the UID they are exported with.
+func PlanImport(tasks []models.Task, storage storage.Storage, logger *slog.Logger) ([]models.BatchOperation, error) {
+	existing, err := storage.GetAllTasks(logger)
+	if err != nil {
+		return nil, err
+	}
+	byUID := make(map[string]models.Task, len(existing))
+	for _, task := range existing {
+		byUID[taskio.UID(task)] = task
+	}
+
+	ops := make([]models.BatchOperation, len(tasks))
+	seen := make(map[string]bool, len(tasks))
+	for i, task := range tasks {
+		if task.ExternalID != "" {
+			if seen[task.ExternalID] {
+				return nil, fmt.Errorf("%w: %s", ErrDuplicateExternalID, task.ExternalID)
+			}
+			seen[task.ExternalID] = true
+		}
+
+		task.ID = 0
+		ops[i] = models.BatchOperation{Op: models.BatchCreate, Task: task}
+		if match, ok := byUID[task.ExternalID]; ok && task.ExternalID != "" {
+			task.ID = match.ID
+			task.ExternalID = match.ExternalID
+			ops[i] = models.BatchOperation{Op: models.BatchUpdate, Task: task}
+		}
+	}
+	return ops, nil
+}
+
+// ImportTasks applies the whole import in one atomic batch; with dryRun it only returns the plan.
+func ImportTasks(tasks []models.Task, dryRun bool, actor string, storage storage.Storage, logger *slog.Logger) ([]models.BatchOperation, []storage.BatchResult, error) {
+	ops, err := PlanImport(tasks, storage, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dryRun {
+		return ops, nil, nil
+	}
+
+	results, err := ApplyBatch(ops, true, actor, storage, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ops, results, nil
+}
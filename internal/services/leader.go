@@ -0,0 +1,36 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+const CheckerLease = "checker"
+
+// GetLeadership reads the lease so it also shows when another instance leads.
+func GetLeadership(id string, now time.Time, st storage.Storage, logger *slog.Logger) (models.Leadership, error) {
+	ls, ok := st.(storage.LeaseStore)
+	if !ok {
+		return models.Leadership{ID: id, Leader: true, Holder: id}, nil
+	}
+
+	lease, err := ls.GetLease(CheckerLease, logger)
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && !now.Before(lease.ExpiresAt)) {
+		return models.Leadership{ID: id}, nil
+	}
+	if err != nil {
+		return models.Leadership{}, err
+	}
+
+	return models.Leadership{
+		ID:        id,
+		Leader:    lease.Holder == id,
+		Holder:    lease.Holder,
+		Since:     &lease.AcquiredAt,
+		ExpiresAt: &lease.ExpiresAt,
+	}, nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+var ErrQueueUnsupported = errors.New("storage does not support a job queue")
+
+func EnqueueJob(kind string, payload interface{}, runAt time.Time, dedupeKey string, st storage.Storage, logger *slog.Logger) (models.QueuedJob, bool, error) {
+	qs, ok := st.(storage.QueueStore)
+	if !ok {
+		return models.QueuedJob{}, false, ErrQueueUnsupported
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return models.QueuedJob{}, false, err
+	}
+	return qs.EnqueueJob(models.QueuedJob{
+		Kind:      kind,
+		Payload:   raw,
+		DedupeKey: dedupeKey,
+		RunAt:     runAt.UTC(),
+		CreatedAt: ClockOf(st).Now().UTC(),
+	}, logger)
+}
+
+func GetQueuedJobs(filter models.QueuedJobFilter, st storage.Storage, logger *slog.Logger) ([]models.QueuedJob, error) {
+	qs, ok := st.(storage.QueueStore)
+	if !ok {
+		return nil, ErrQueueUnsupported
+	}
+	return qs.ListJobs(filter, logger)
+}
+
+func GetQueuedJob(id int64, st storage.Storage, logger *slog.Logger) (models.QueuedJob, error) {
+	qs, ok := st.(storage.QueueStore)
+	if !ok {
+		return models.QueuedJob{}, ErrQueueUnsupported
+	}
+	return qs.GetJob(id, logger)
+}
+
+func RetryQueuedJob(id int64, st storage.Storage, logger *slog.Logger) (models.QueuedJob, error) {
+	qs, ok := st.(storage.QueueStore)
+	if !ok {
+		return models.QueuedJob{}, ErrQueueUnsupported
+	}
+	return qs.RetryJob(id, ClockOf(st).Now(), logger)
+}
+
+func CancelQueuedJob(id int64, st storage.Storage, logger *slog.Logger) (models.QueuedJob, error) {
+	qs, ok := st.(storage.QueueStore)
+	if !ok {
+		return models.QueuedJob{}, ErrQueueUnsupported
+	}
+	return qs.CancelJob(id, ClockOf(st).Now(), logger)
+}
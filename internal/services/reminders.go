@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/notify"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+var ErrRemindersUnsupported = errors.New("storage does not support reminders")
+
+func CreateReminder(taskID, leadSeconds int64, now time.Time, st storage.Storage, logger *slog.Logger) (models.Reminder, error) {
+	rs, ok := st.(storage.ReminderStore)
+	if !ok {
+		return models.Reminder{}, ErrRemindersUnsupported
+	}
+
+	task, err := st.GetTask(taskID, logger)
+	if err != nil {
+		return models.Reminder{}, err
+	}
+	if task.DeletedAt != nil {
+		return models.Reminder{}, storage.ErrNotFound
+	}
+
+	return rs.CreateReminder(models.Reminder{
+		TaskID:      taskID,
+		LeadSeconds: leadSeconds,
+		FireAt:      fireAt(Deadline(task), leadSeconds),
+		CreatedAt:   now.UTC(),
+	}, logger)
+}
+
+func GetTaskReminders(taskID int64, st storage.Storage, logger *slog.Logger) ([]models.Reminder, error) {
+	rs, ok := st.(storage.ReminderStore)
+	if !ok {
+		return nil, ErrRemindersUnsupported
+	}
+
+	if _, err := st.GetTask(taskID, logger); err != nil {
+		return nil, err
+	}
+	return rs.GetTaskReminders(taskID, logger)
+}
+
+func DeleteReminder(taskID, reminderID int64, st storage.Storage, logger *slog.Logger) error {
+	rs, ok := st.(storage.ReminderStore)
+	if !ok {
+		return ErrRemindersUnsupported
+	}
+
+	reminders, err := rs.GetTaskReminders(taskID, logger)
+	if err != nil {
+		return err
+	}
+	for _, r := range reminders {
+		if r.ID == reminderID {
+			return rs.DeleteReminder(reminderID, logger)
+		}
+	}
+	return storage.ErrNotFound
+}
+
+func FireDueReminders(ctx context.Context, now time.Time, notifier notify.Notifier, st storage.Storage, logger *slog.Logger) (int, error) {
+	return handleDueReminders(now, st, logger, func(reminder models.Reminder, task models.Task) error {
+		return notifier.Notify(ctx, notify.Notification{Reminder: reminder, Task: task})
+	})
+}
+
+const JobSendReminder = "send-reminder"
+
+// ReminderJob: FireAt tells a reminder that moved after it was queued from the one that was queued.
+type ReminderJob struct {
+	ReminderID int64     `json:"reminderId"`
+	TaskID     int64     `json:"taskId"`
+	FireAt     time.Time `json:"fireAt"`
+}
+
+const JobDeliverWebhook = "deliver-webhook"
+
+// WebhookQueue queues every notification as a deliver-webhook job.
+type WebhookQueue struct {
+	st     storage.Storage
+	logger *slog.Logger
+}
+
+func NewWebhookQueue(st storage.Storage, logger *slog.Logger) *WebhookQueue {
+	return &WebhookQueue{st: st, logger: logger}
+}
+
+func (q *WebhookQueue) Notify(ctx context.Context, n notify.Notification) error {
+	key := fmt.Sprintf("webhook:%d:%d", n.Reminder.ID, n.Reminder.FireAt.Unix())
+	_, _, err := EnqueueJob(JobDeliverWebhook, n, ClockOf(q.st).Now(), key, q.st, q.logger)
+	return err
+}
+
+func QueueDueReminders(now time.Time, st storage.Storage, logger *slog.Logger) (int, error) {
+	return handleDueReminders(now, st, logger, func(reminder models.Reminder, task models.Task) error {
+		job := ReminderJob{ReminderID: reminder.ID, TaskID: task.ID, FireAt: reminder.FireAt.UTC()}
+		// The key keeps a reminder from being queued twice when giving its claim back failed.
+		key := fmt.Sprintf("reminder:%d:%d", reminder.ID, job.FireAt.Unix())
+		_, _, err := EnqueueJob(JobSendReminder, job, now, key, st, logger)
+		return err
+	})
+}
+
+// SendReminder drops reminders that were deleted, moved or missed their deadline since being queued.
+func SendReminder(ctx context.Context, job ReminderJob, now time.Time, notifier notify.Notifier, st storage.Storage, logger *slog.Logger) error {
+	rs, ok := st.(storage.ReminderStore)
+	if !ok {
+		return ErrRemindersUnsupported
+	}
+
+	reminders, err := rs.GetTaskReminders(job.TaskID, logger)
+	if err != nil {
+		return err
+	}
+	var reminder *models.Reminder
+	for i := range reminders {
+		if reminders[i].ID == job.ReminderID {
+			reminder = &reminders[i]
+		}
+	}
+	if reminder == nil || !sameTime(reminder.FireAt, &job.FireAt) {
+		return nil
+	}
+
+	task, err := st.GetTask(job.TaskID, logger)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if deadline := Deadline(task); task.DeletedAt != nil || deadline == nil || !deadline.After(now) {
+		return nil
+	}
+	return notifier.Notify(ctx, notify.Notification{Reminder: *reminder, Task: task})
+}
+
+// handleDueReminders claims each reminder before delivering it and releases it if delivery fails.
+func handleDueReminders(now time.Time, st storage.Storage, logger *slog.Logger, deliver func(models.Reminder, models.Task) error) (int, error) {
+	rs, ok := st.(storage.ReminderStore)
+	if !ok {
+		return 0, nil
+	}
+
+	due, err := rs.GetDueReminders(now, logger)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, reminder := range due {
+		task, err := st.GetTask(reminder.TaskID, logger)
+		if errors.Is(err, storage.ErrNotFound) {
+			if err := rs.DeleteReminder(reminder.ID, logger); err != nil {
+				logger.Error("error on deleting orphaned reminder", sl.Err(err))
+			}
+			continue
+		}
+		if err != nil {
+			return sent, err
+		}
+		// Trashed tasks keep their reminders in case they are restored.
+		if task.DeletedAt != nil {
+			continue
+		}
+
+		sentAt := now.UTC()
+		claimed, err := rs.ClaimReminder(reminder.ID, sentAt, logger)
+		if err != nil {
+			return sent, err
+		}
+		if !claimed {
+			continue
+		}
+		reminder.SentAt = &sentAt
+
+		// Reminders that could not fire before the deadline passed are dropped rather than sent late.
+		if deadline := Deadline(task); deadline == nil || !deadline.After(now) {
+			continue
+		}
+		if err := deliver(reminder, task); err != nil {
+			logger.Error("error on sending reminder", slog.Int64("reminderId", reminder.ID), sl.Err(err))
+			if err := rs.ReleaseReminder(reminder.ID, sentAt, logger); err != nil {
+				return sent, err
+			}
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func rescheduleReminders(before, after *models.Task, st storage.Storage, logger *slog.Logger) error {
+	rs, ok := st.(storage.ReminderStore)
+	if !ok || after == nil || sameTime(deadlineOf(before), Deadline(*after)) {
+		return nil
+	}
+
+	reminders, err := rs.GetTaskReminders(after.ID, logger)
+	if err != nil {
+		return err
+	}
+
+	now := ClockOf(st).Now()
+	for _, r := range reminders {
+		r.FireAt = fireAt(Deadline(*after), r.LeadSeconds)
+		if r.FireAt != nil && r.FireAt.After(now) {
+			r.SentAt = nil
+		}
+		if err := rs.UpdateReminder(r, logger); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fireAt(due *time.Time, leadSeconds int64) *time.Time {
+	if due == nil {
+		return nil
+	}
+	at := due.Add(-time.Duration(leadSeconds) * time.Second).UTC()
+	return &at
+}
+
+func deadlineOf(task *models.Task) *time.Time {
+	if task == nil {
+		return nil
+	}
+	return Deadline(*task)
+}
+
+func sameTime(a, b *time.Time) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && a.Equal(*b))
+}
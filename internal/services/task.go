@@ -2,6 +2,7 @@ package services
 
 import (
 	"log/slog"
+	"time"
 
 	"github.com/gintokos/tasksrestapi/internal/domain/models"
 	"github.com/gintokos/tasksrestapi/internal/storage"
@@ -11,14 +12,241 @@ func GetallTasks(storage storage.Storage, logger *slog.Logger) ([]models.Task, e
 	return storage.GetAllTasks(logger)
 }
 
-func CreateNewTask(task models.Task, storage storage.Storage, logger *slog.Logger) (models.Task, error) {
-	return storage.CreateTask(task, logger)
+func GetTask(id int64, storage storage.Storage, logger *slog.Logger) (models.Task, error) {
+	return storage.GetTask(id, logger)
 }
 
-func UpdateTask(task models.Task, storage storage.Storage, logger *slog.Logger) (models.Task, error) {
-	return storage.UpdateTask(task, logger)
+func CreateNewTask(task models.Task, actor string, st storage.Storage, logger *slog.Logger) (models.Task, error) {
+	task = normalizeDue(task)
+	task.OverDue = IsOverdue(task, ClockOf(st).Now())
+
+	var created models.Task
+	err := withinTx(st, logger, func(tx storage.Storage) error {
+		var err error
+		if created, err = tx.CreateTask(task, logger); err != nil {
+			return err
+		}
+		return recordEvent(models.EventCreated, actor, nil, &created, tx, logger)
+	})
+	if err != nil {
+		return models.Task{}, err
+	}
+	return created, nil
+}
+
+func UpdateTask(task models.Task, actor string, st storage.Storage, logger *slog.Logger) (models.Task, error) {
+	task = normalizeDue(task)
+	task.OverDue = IsOverdue(task, ClockOf(st).Now())
+
+	var updated models.Task
+	err := withinTx(st, logger, func(tx storage.Storage) error {
+		before, err := tx.GetTask(task.ID, logger)
+		if err != nil {
+			return err
+		}
+		if updated, err = tx.UpdateTask(task, logger); err != nil {
+			return err
+		}
+		if err := recordEvent(updateEventType(before, updated), actor, &before, &updated, tx, logger); err != nil {
+			return err
+		}
+		return rescheduleReminders(&before, &updated, tx, logger)
+	})
+	if err != nil {
+		return models.Task{}, err
+	}
+	return updated, nil
+}
+
+// RefreshOverdue re-reads the task in the transaction and only changes its overdue flag, so an
+// update that landed since the caller read the task is kept.
+func RefreshOverdue(id int64, now time.Time, actor string, st storage.Storage, logger *slog.Logger) (bool, error) {
+	changed := false
+	err := withinTx(st, logger, func(tx storage.Storage) error {
+		before, err := tx.GetTask(id, logger)
+		if err != nil {
+			return err
+		}
+		overdue := IsOverdue(before, now)
+		if before.DeletedAt != nil || before.OverDue == overdue {
+			return nil
+		}
+		task := before
+		task.OverDue = overdue
+		updated, err := tx.UpdateTask(task, logger)
+		if err != nil {
+			return err
+		}
+		changed = true
+		return recordEvent(updateEventType(before, updated), actor, &before, &updated, tx, logger)
+	})
+	return changed, err
+}
+
+func DeleteTask(id int64, actor string, st storage.Storage, logger *slog.Logger) error {
+	return withinTx(st, logger, func(tx storage.Storage) error {
+		before, err := tx.GetTask(id, logger)
+		if err != nil {
+			return err
+		}
+		if err := tx.DeleteTask(id, logger); err != nil {
+			return err
+		}
+		return recordEvent(models.EventDeleted, actor, &before, currentTask(id, tx, logger), tx, logger)
+	})
+}
+
+func ApplyBatch(ops []models.BatchOperation, atomic bool, actor string, st storage.Storage, logger *slog.Logger) ([]storage.BatchResult, error) {
+	now := ClockOf(st).Now()
+	ops = append([]models.BatchOperation(nil), ops...)
+	for i, op := range ops {
+		if op.Op == models.BatchCreate || op.Op == models.BatchUpdate {
+			ops[i].Task = normalizeDue(op.Task)
+			ops[i].Task.OverDue = IsOverdue(ops[i].Task, now)
+		}
+	}
+
+	var results []storage.BatchResult
+	err := withinTx(st, logger, func(tx storage.Storage) error {
+		// state follows each task through the batch, so an id that appears twice gets the first
+		// op's result as the before of the second.
+		state := make(map[int64]*models.Task)
+		for _, op := range ops {
+			if _, seen := state[op.Task.ID]; op.Op == models.BatchCreate || seen {
+				continue
+			}
+			state[op.Task.ID] = currentTask(op.Task.ID, tx, logger)
+		}
+
+		var err error
+		if results, err = tx.ApplyBatch(ops, atomic, logger); err != nil {
+			return err
+		}
+
+		for i, res := range results {
+			if res.Err != nil {
+				continue
+			}
+			before := state[ops[i].Task.ID]
+			switch ops[i].Op {
+			case models.BatchCreate:
+				err = recordEvent(models.EventCreated, actor, nil, &res.Task, tx, logger)
+			case models.BatchUpdate:
+				after := res.Task
+				eventType := models.EventUpdated
+				if before != nil {
+					eventType = updateEventType(*before, after)
+				}
+				err = recordEvent(eventType, actor, before, &after, tx, logger)
+				if err == nil {
+					err = rescheduleReminders(before, &after, tx, logger)
+				}
+				state[after.ID] = &after
+			case models.BatchDelete:
+				after := currentTask(ops[i].Task.ID, tx, logger)
+				err = recordEvent(models.EventDeleted, actor, before, after, tx, logger)
+				state[ops[i].Task.ID] = after
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func GetTrash(storage storage.Storage, logger *slog.Logger) ([]models.Task, error) {
+	return storage.GetTrash(logger)
+}
+
+func RestoreTask(id int64, actor string, st storage.Storage, logger *slog.Logger) (models.Task, error) {
+	var restored models.Task
+	err := withinTx(st, logger, func(tx storage.Storage) error {
+		before, err := tx.GetTask(id, logger)
+		if err != nil {
+			return err
+		}
+		if restored, err = tx.RestoreTask(id, logger); err != nil {
+			return err
+		}
+		return recordEvent(models.EventRestored, actor, &before, &restored, tx, logger)
+	})
+	if err != nil {
+		return models.Task{}, err
+	}
+	return restored, nil
+}
+
+func PurgeTask(id int64, actor string, st storage.Storage, logger *slog.Logger) error {
+	return withinTx(st, logger, func(tx storage.Storage) error {
+		before, err := tx.GetTask(id, logger)
+		if err != nil {
+			return err
+		}
+		if err := tx.PurgeTask(id, logger); err != nil {
+			return err
+		}
+		return recordEvent(models.EventPurged, actor, &before, nil, tx, logger)
+	})
+}
+
+func PurgeTrash(before time.Time, actor string, st storage.Storage, logger *slog.Logger) (int, error) {
+	var ids []int64
+	err := withinTx(st, logger, func(tx storage.Storage) error {
+		trash, err := tx.GetTrash(logger)
+		if err != nil {
+			return err
+		}
+		if ids, err = tx.PurgeTrash(before, logger); err != nil {
+			return err
+		}
+
+		purged := make(map[int64]bool, len(ids))
+		for _, id := range ids {
+			purged[id] = true
+		}
+		for _, task := range trash {
+			if !purged[task.ID] {
+				continue
+			}
+			if err := recordEvent(models.EventPurged, actor, &task, nil, tx, logger); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+const JobPurgeTrash = "purge-trash"
+
+type PurgeTrashJob struct {
+	Before time.Time `json:"before"`
+}
+
+// QueuePurgeTrash returns the purge that is still pending or running instead of queuing another.
+func QueuePurgeTrash(before time.Time, st storage.Storage, logger *slog.Logger) (models.QueuedJob, bool, error) {
+	return EnqueueJob(JobPurgeTrash, PurgeTrashJob{Before: before.UTC()}, ClockOf(st).Now(), JobPurgeTrash, st, logger)
+}
+
+func currentTask(id int64, storage storage.Storage, logger *slog.Logger) *models.Task {
+	task, err := storage.GetTask(id, logger)
+	if err != nil {
+		return nil
+	}
+	return &task
 }
 
-func DeleteTask(id int64, storage storage.Storage, logger *slog.Logger) error {
-	return storage.DeleteTask(id, logger)
+func updateEventType(before, after models.Task) models.EventType {
+	if before.OverDue && !after.OverDue {
+		return models.EventOverdueCleared
+	}
+	return models.EventUpdated
 }
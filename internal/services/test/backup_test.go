@@ -0,0 +1,43 @@
+package services_test
+
+import (
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/services"
+	mocks "github.com/gintokos/tasksrestapi/internal/storage/mock"
+)
+
+func TestCreateBackup_Rotates(t *testing.T) {
+	logger := slog.Default()
+	st := newStorage(t)
+	dir := filepath.Join(t.TempDir(), "backups")
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		if _, err := services.CreateBackup(dir, 2, start.Add(time.Duration(i)*time.Hour), st, logger); err != nil {
+			t.Fatalf("error on backup %d: %v", i, err)
+		}
+	}
+
+	backups, err := services.ListBackups(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups after rotation, got %d", len(backups))
+	}
+	if !backups[0].CreatedAt.Equal(start.Add(3*time.Hour)) || !backups[1].CreatedAt.Equal(start.Add(2*time.Hour)) {
+		t.Fatalf("expected the two newest backups, got %+v", backups)
+	}
+}
+
+func TestCreateBackup_Unsupported(t *testing.T) {
+	_, err := services.CreateBackup(t.TempDir(), 0, time.Now(), mocks.NewMockStorage(nil), slog.Default())
+	if !errors.Is(err, services.ErrBackupUnsupported) {
+		t.Fatalf("expected ErrBackupUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package services_test
+
+import (
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/clock/fakeclock"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+)
+
+func newStorage(t *testing.T) *sqllite.Storage {
+	t.Helper()
+
+	st, err := sqllite.NewStorage(filepath.Join(t.TempDir(), "storage.db"), id.NewRandomGenerator(), sqllite.Options{})
+	if err != nil {
+		t.Fatalf("error on creating storage: %v", err)
+	}
+	return st
+}
+
+// withFakeClock makes st and the services reading its clock run on a fake one.
+func withFakeClock(st *sqllite.Storage) *fakeclock.Clock {
+	fc := fakeclock.New(time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC))
+	st.SetClock(fc)
+	return fc
+}
+
+func TestTaskHistory(t *testing.T) {
+	logger := slog.Default()
+	st := newStorage(t)
+	fc := withFakeClock(st)
+
+	task, err := services.CreateNewTask(models.Task{Title: "Draft"}, "alice", st, logger)
+	if err != nil {
+		t.Fatalf("error on creating task: %v", err)
+	}
+	afterCreate := fc.Now()
+	fc.Advance(time.Minute)
+
+	task.Title = "Final"
+	if _, err := services.UpdateTask(task, "bob", st, logger); err != nil {
+		t.Fatalf("error on updating task: %v", err)
+	}
+	afterUpdate := fc.Now()
+	fc.Advance(time.Minute)
+
+	if err := services.DeleteTask(task.ID, "carol", st, logger); err != nil {
+		t.Fatalf("error on deleting task: %v", err)
+	}
+
+	events, err := services.GetTaskHistory(task.ID, st, logger)
+	if err != nil {
+		t.Fatalf("error on getting history: %v", err)
+	}
+
+	wantTypes := []models.EventType{models.EventCreated, models.EventUpdated, models.EventDeleted}
+	wantActors := []string{"alice", "bob", "carol"}
+	if len(events) != len(wantTypes) {
+		t.Fatalf("expected %d events, got %d", len(wantTypes), len(events))
+	}
+	for i := range events {
+		if events[i].Type != wantTypes[i] || events[i].Actor != wantActors[i] {
+			t.Errorf("event %d: expected %s by %s, got %s by %s", i, wantTypes[i], wantActors[i], events[i].Type, events[i].Actor)
+		}
+	}
+
+	update := events[1]
+	if len(update.Changes) != 1 || update.Changes[0].Field != "title" ||
+		string(update.Changes[0].Before) != `"Draft"` || string(update.Changes[0].After) != `"Final"` {
+		t.Errorf("expected title diff Draft -> Final, got %+v", update.Changes)
+	}
+
+	asOf, err := services.GetTaskAsOf(task.ID, afterCreate, st, logger)
+	if err != nil {
+		t.Fatalf("error on getting task as of creation: %v", err)
+	}
+	if asOf.Title != "Draft" {
+		t.Errorf("expected title Draft as of creation, got %s", asOf.Title)
+	}
+
+	asOf, err = services.GetTaskAsOf(task.ID, afterUpdate, st, logger)
+	if err != nil {
+		t.Fatalf("error on getting task as of update: %v", err)
+	}
+	if asOf.Title != "Final" || asOf.DeletedAt != nil {
+		t.Errorf("expected live task titled Final as of update, got %+v", asOf)
+	}
+
+	_, err = services.GetTaskAsOf(task.ID, afterCreate.Add(-time.Hour), st, logger)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found before creation, got %v", err)
+	}
+}
+
+func TestApplyBatch_SameTaskTwice(t *testing.T) {
+	logger := slog.Default()
+	st := newStorage(t)
+
+	task, err := services.CreateNewTask(models.Task{Title: "Draft"}, "alice", st, logger)
+	if err != nil {
+		t.Fatalf("error on creating task: %v", err)
+	}
+	first, second := task, task
+	first.Title = "Second draft"
+	second.Title = "Second draft"
+	second.Description = "Reviewed"
+	_, err = services.ApplyBatch([]models.BatchOperation{
+		{Op: models.BatchUpdate, Task: first},
+		{Op: models.BatchUpdate, Task: second},
+	}, true, "bob", st, logger)
+	if err != nil {
+		t.Fatalf("error on applying batch: %v", err)
+	}
+
+	events, err := services.GetTaskHistory(task.ID, st, logger)
+	if err != nil {
+		t.Fatalf("error on getting history: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected an event per op, got %d", len(events))
+	}
+	if changes := events[2].Changes; len(changes) != 1 || changes[0].Field != "description" {
+		t.Fatalf("expected the second op to only change the description, got %+v", changes)
+	}
+
+	reverted, _, err := services.UndoLast(task.ID, "bob", st, logger)
+	if err != nil {
+		t.Fatalf("error on undoing: %v", err)
+	}
+	if reverted.Title != "Second draft" || reverted.Description != "" {
+		t.Errorf("expected undo to keep the first op's title, got %+v", reverted)
+	}
+}
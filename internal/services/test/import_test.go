@@ -0,0 +1,94 @@
+package services_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/taskio"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+func TestImportTasks_ReimportsExport(t *testing.T) {
+	logger := slog.Default()
+
+	for _, format := range []taskio.Format{taskio.FormatJSONL, taskio.FormatCSV, taskio.FormatICS} {
+		t.Run(string(format), func(t *testing.T) {
+			st := newStorage(t)
+			if _, err := services.CreateNewTask(models.Task{Title: "No external id"}, "alice", st, logger); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := services.CreateNewTask(models.Task{ExternalID: "ext-1", Title: "External"}, "alice", st, logger); err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			w, err := taskio.NewWriter(format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			exported, _ := st.GetAllTasks(logger)
+			for _, task := range exported {
+				if err := w.Write(task); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			records, err := taskio.Read(format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tasks := make([]models.Task, len(records))
+			for i, rec := range records {
+				tasks[i] = rec.Task
+			}
+			ops, _, err := services.ImportTasks(tasks, false, "alice", st, logger)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, op := range ops {
+				if op.Op != models.BatchUpdate {
+					t.Fatalf("expected every exported task to be updated, got %+v", ops)
+				}
+			}
+			after, _ := st.GetAllTasks(logger)
+			if len(after) != len(exported) {
+				t.Fatalf("expected %d tasks after re-import, got %d", len(exported), len(after))
+			}
+			for i := range after {
+				if after[i].ExternalID != exported[i].ExternalID {
+					t.Errorf("expected external id %q to be kept, got %q", exported[i].ExternalID, after[i].ExternalID)
+				}
+			}
+		})
+	}
+}
+
+func TestImportTasks_RejectsDuplicateExternalIDs(t *testing.T) {
+	logger := slog.Default()
+	st := newStorage(t)
+
+	_, _, err := services.ImportTasks([]models.Task{
+		{ExternalID: "ext-1", Title: "First"},
+		{ExternalID: "ext-1", Title: "Second"},
+	}, false, "alice", st, logger)
+	if !errors.Is(err, services.ErrDuplicateExternalID) {
+		t.Fatalf("expected a duplicate external id error, got %v", err)
+	}
+	if tasks, _ := st.GetAllTasks(logger); len(tasks) != 0 {
+		t.Fatalf("expected nothing to be imported, got %+v", tasks)
+	}
+
+	if _, err := services.CreateNewTask(models.Task{ExternalID: "ext-1", Title: "First"}, "alice", st, logger); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := services.CreateNewTask(models.Task{ExternalID: "ext-1", Title: "Second"}, "alice", st, logger); !errors.Is(err, storage.ErrExternalIDTaken) {
+		t.Fatalf("expected the storage to refuse a second live task with ext-1, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package services_test
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/services"
+)
+
+func TestOverdue_DerivedInBothDirections(t *testing.T) {
+	logger := slog.Default()
+	st := newStorage(t)
+	fc := withFakeClock(st)
+
+	past := fc.Now().Add(-time.Hour)
+	task, err := services.CreateNewTask(models.Task{Title: "Late", DueDate: &past}, "alice", st, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !task.OverDue {
+		t.Fatalf("expected task created with a past due date to be overdue")
+	}
+
+	future := fc.Now().Add(24 * time.Hour)
+	task.DueDate = &future
+	task.OverDue = true
+	updated, err := services.UpdateTask(task, "bob", st, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.OverDue {
+		t.Fatalf("expected overdue to be cleared when the due date moved forward")
+	}
+
+	history, err := services.GetTaskHistory(task.ID, st, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := history[len(history)-1]; last.Type != models.EventOverdueCleared || last.Actor != "bob" {
+		t.Fatalf("expected an overdue_cleared event by bob, got %+v", last)
+	}
+
+	reverted, _, err := services.UndoLast(task.ID, "bob", st, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reverted.OverDue {
+		t.Fatalf("expected undo to bring back the past due date and the overdue state")
+	}
+
+	results, err := services.ApplyBatch([]models.BatchOperation{
+		{Op: models.BatchUpdate, Task: models.Task{ID: task.ID, Title: "Late", DueDate: &future}},
+	}, true, "carol", st, logger)
+	if err != nil || results[0].Err != nil || results[0].Task.OverDue {
+		t.Fatalf("expected batch update to clear overdue, got %+v (err %v)", results, err)
+	}
+}
+
+func TestOverdue_AllDayEndsInTaskTimeZone(t *testing.T) {
+	due := time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC)
+	auckland := models.Task{DueDate: &due, DueKind: models.DueAllDay, TimeZone: "Pacific/Auckland"}
+	losAngeles := models.Task{DueDate: &due, DueKind: models.DueAllDay, TimeZone: "America/Los_Angeles"}
+
+	// Midnight after March 10 is 11:00Z in Auckland (+13) and 07:00Z on March 11 in Los Angeles (-7).
+	tests := []struct {
+		name string
+		task models.Task
+		now  time.Time
+		want bool
+	}{
+		{"auckland before end of day", auckland, time.Date(2026, time.March, 10, 10, 59, 0, 0, time.UTC), false},
+		{"auckland at end of day", auckland, time.Date(2026, time.March, 10, 11, 0, 0, 0, time.UTC), true},
+		{"los angeles same instant", losAngeles, time.Date(2026, time.March, 10, 11, 0, 0, 0, time.UTC), false},
+		{"los angeles at end of day", losAngeles, time.Date(2026, time.March, 11, 7, 0, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := services.IsOverdue(tt.task, tt.now); got != tt.want {
+				t.Fatalf("expected overdue %v at %v, got %v (deadline %v)", tt.want, tt.now, got, services.Deadline(tt.task))
+			}
+		})
+	}
+}
+
+func TestOverdue_AllDayKeepsCalendarDate(t *testing.T) {
+	logger := slog.Default()
+	st := newStorage(t)
+
+	// A date written in a zone ahead of UTC must not slide to the previous day.
+	due := time.Date(2030, time.June, 1, 0, 0, 0, 0, services.Location("Asia/Tokyo"))
+	task, err := services.CreateNewTask(models.Task{Title: "Ship", DueDate: &due, DueKind: models.DueAllDay, TimeZone: "Asia/Tokyo"}, "alice", st, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := task.DueDate.UTC().Format(time.DateOnly); got != "2030-06-01" {
+		t.Fatalf("expected the all-day date to stay 2030-06-01, got %s", got)
+	}
+	if task.OverDue {
+		t.Fatalf("expected a future all-day task not to be overdue")
+	}
+}
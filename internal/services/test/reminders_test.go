@@ -0,0 +1,153 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/clock/fakeclock"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/notify"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+)
+
+type recordingNotifier struct {
+	sent []notify.Notification
+	err  error
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification notify.Notification) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+func TestReminders_FireOnceAcrossRestarts(t *testing.T) {
+	logger := slog.Default()
+	path := filepath.Join(t.TempDir(), "storage.db")
+	st, err := sqllite.NewStorage(path, id.NewRandomGenerator(), sqllite.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fc := fakeclock.New(time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC))
+	st.SetClock(fc)
+	now := fc.Now()
+	due := now.Add(2 * time.Hour)
+	task, err := services.CreateNewTask(models.Task{Title: "Ship it", DueDate: &due}, "alice", st, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, lead := range []int64{24 * 3600, 3600} {
+		if _, err := services.CreateReminder(task.ID, lead, now, st, logger); err != nil {
+			t.Fatalf("error on creating reminder: %v", err)
+		}
+	}
+
+	failing := &recordingNotifier{err: errors.New("smtp down")}
+	if sent, _ := services.FireDueReminders(context.Background(), now, failing, st, logger); sent != 0 {
+		t.Fatalf("expected failed delivery not to count, got %d", sent)
+	}
+
+	notifier := &recordingNotifier{}
+	if sent, err := services.FireDueReminders(context.Background(), now, notifier, st, logger); err != nil || sent != 1 {
+		t.Fatalf("expected the 1 day reminder to fire after the failed attempt, got %d (err %v)", sent, err)
+	}
+	if sent, _ := services.FireDueReminders(context.Background(), now, notifier, st, logger); sent != 0 {
+		t.Fatalf("expected reminder to fire only once, got %d more", sent)
+	}
+
+	st.Close()
+	st, err = sqllite.NewStorage(path, id.NewRandomGenerator(), sqllite.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	st.SetClock(fc)
+
+	if sent, _ := services.FireDueReminders(context.Background(), now, notifier, st, logger); sent != 0 {
+		t.Fatalf("expected no repeat after restart, got %d", sent)
+	}
+	fc.Advance(time.Hour + time.Second)
+	if sent, _ := services.FireDueReminders(context.Background(), fc.Now(), notifier, st, logger); sent != 1 {
+		t.Fatalf("expected the 1 hour reminder to fire, got %d", sent)
+	}
+	if len(notifier.sent) != 2 || notifier.sent[0].Task.ID != task.ID {
+		t.Fatalf("expected 2 notifications for the task, got %+v", notifier.sent)
+	}
+
+	newDue := due.Add(48 * time.Hour)
+	task.DueDate = &newDue
+	if _, err := services.UpdateTask(task, "alice", st, logger); err != nil {
+		t.Fatal(err)
+	}
+	reminders, err := services.GetTaskReminders(task.ID, st, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range reminders {
+		if r.SentAt != nil || !r.FireAt.Equal(newDue.Add(-time.Duration(r.LeadSeconds)*time.Second)) {
+			t.Fatalf("expected reminder rescheduled for the new due date, got %+v", r)
+		}
+	}
+}
+
+type countingNotifier struct {
+	sent atomic.Int32
+}
+
+func (n *countingNotifier) Notify(ctx context.Context, notification notify.Notification) error {
+	n.sent.Add(1)
+	return nil
+}
+
+func TestReminders_FireOnceAcrossInstances(t *testing.T) {
+	logger := slog.Default()
+	path := filepath.Join(t.TempDir(), "storage.db")
+
+	instances := make([]*sqllite.Storage, 4)
+	for i := range instances {
+		st, err := sqllite.NewStorage(path, id.NewRandomGenerator(), sqllite.Options{BusyTimeout: 5 * time.Second})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer st.Close()
+		instances[i] = st
+	}
+
+	now := time.Now()
+	due := now.Add(2 * time.Hour)
+	task, err := services.CreateNewTask(models.Task{Title: "Ship it", DueDate: &due}, "alice", instances[0], logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := services.CreateReminder(task.ID, 3*3600, now, instances[0], logger); err != nil {
+		t.Fatalf("error on creating reminder: %v", err)
+	}
+
+	notifier := &countingNotifier{}
+	var wg sync.WaitGroup
+	for _, st := range instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := services.FireDueReminders(context.Background(), now, notifier, st, logger); err != nil {
+				t.Errorf("error on firing reminders: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if sent := notifier.sent.Load(); sent != 1 {
+		t.Fatalf("expected the reminder to be delivered once, got %d", sent)
+	}
+}
@@ -0,0 +1,147 @@
+package services_test
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/services"
+)
+
+func TestUndo(t *testing.T) {
+	logger := slog.Default()
+	st := newStorage(t)
+
+	task, err := services.CreateNewTask(models.Task{Title: "Draft"}, "alice", st, logger)
+	if err != nil {
+		t.Fatalf("error on creating task: %v", err)
+	}
+	task.Title = "Final"
+	if _, err := services.UpdateTask(task, "alice", st, logger); err != nil {
+		t.Fatalf("error on updating task: %v", err)
+	}
+
+	events, _ := services.GetTaskHistory(task.ID, st, logger)
+	created := events[0]
+
+	_, _, err = services.UndoEvent(created.ID, "bob", st, logger)
+	var conflictErr *services.UndoConflictError
+	if !errors.As(err, &conflictErr) || !errors.Is(err, services.ErrUndoConflict) {
+		t.Fatalf("expected undo conflict, got %v", err)
+	}
+	if conflictErr.Conflicting.ID != events[1].ID {
+		t.Errorf("expected conflicting event %d, got %d", events[1].ID, conflictErr.Conflicting.ID)
+	}
+
+	reverted, undoEvent, err := services.UndoLast(task.ID, "bob", st, logger)
+	if err != nil {
+		t.Fatalf("error on undoing update: %v", err)
+	}
+	if reverted.Title != "Draft" {
+		t.Errorf("expected title Draft after undo, got %s", reverted.Title)
+	}
+	if undoEvent.Type != models.EventUndone || undoEvent.UndoneEventID != events[1].ID || undoEvent.Actor != "bob" {
+		t.Errorf("expected undo event for %d by bob, got %+v", events[1].ID, undoEvent)
+	}
+
+	if err := services.DeleteTask(task.ID, "alice", st, logger); err != nil {
+		t.Fatalf("error on deleting task: %v", err)
+	}
+	restored, _, err := services.UndoLast(task.ID, "bob", st, logger)
+	if err != nil {
+		t.Fatalf("error on undoing delete: %v", err)
+	}
+	if restored.DeletedAt != nil || restored.Title != "Draft" {
+		t.Errorf("expected live task titled Draft after undoing delete, got %+v", restored)
+	}
+
+	if err := services.DeleteTask(task.ID, "alice", st, logger); err != nil {
+		t.Fatalf("error on deleting task: %v", err)
+	}
+	if err := services.PurgeTask(task.ID, "alice", st, logger); err != nil {
+		t.Fatalf("error on purging task: %v", err)
+	}
+	if _, _, err := services.UndoLast(task.ID, "bob", st, logger); !errors.Is(err, services.ErrNotUndoable) {
+		t.Errorf("expected purge to be not undoable, got %v", err)
+	}
+}
+
+func TestUndoEvent_ConcurrentUndosConflict(t *testing.T) {
+	logger := slog.Default()
+	st := newStorage(t)
+
+	task, err := services.CreateNewTask(models.Task{Title: "Draft"}, "alice", st, logger)
+	if err != nil {
+		t.Fatalf("error on creating task: %v", err)
+	}
+	task.Title = "Final"
+	if _, err := services.UpdateTask(task, "alice", st, logger); err != nil {
+		t.Fatalf("error on updating task: %v", err)
+	}
+	events, _ := services.GetTaskHistory(task.ID, st, logger)
+	updated := events[len(events)-1]
+
+	const undos = 4
+	errs := make(chan error, undos)
+	for i := 0; i < undos; i++ {
+		go func() {
+			_, _, err := services.UndoEvent(updated.ID, "bob", st, logger)
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < undos; i++ {
+		err := <-errs
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, services.ErrUndoConflict):
+			t.Errorf("expected an undo conflict, got %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("expected exactly one undo to succeed, got %d", succeeded)
+	}
+
+	events, _ = services.GetTaskHistory(task.ID, st, logger)
+	if len(events) != 3 || events[2].Type != models.EventUndone {
+		t.Errorf("expected one undo event after the update, got %+v", events)
+	}
+}
+
+func TestUndoLast_SkipsOverdueRefresh(t *testing.T) {
+	logger := slog.Default()
+	st := newStorage(t)
+	fc := withFakeClock(st)
+
+	due := fc.Now().Add(time.Hour)
+	task, err := services.CreateNewTask(models.Task{Title: "Draft", DueDate: &due}, "alice", st, logger)
+	if err != nil {
+		t.Fatalf("error on creating task: %v", err)
+	}
+	task.Title = "Final"
+	if _, err := services.UpdateTask(task, "alice", st, logger); err != nil {
+		t.Fatalf("error on updating task: %v", err)
+	}
+	events, _ := services.GetTaskHistory(task.ID, st, logger)
+	updated := events[len(events)-1]
+
+	fc.Advance(2 * time.Hour)
+	if changed, err := services.RefreshOverdue(task.ID, fc.Now(), services.ActorChecker, st, logger); err != nil || !changed {
+		t.Fatalf("expected the checker to mark the task overdue, got %v, %v", changed, err)
+	}
+
+	reverted, undoEvent, err := services.UndoLast(task.ID, "bob", st, logger)
+	if err != nil {
+		t.Fatalf("error on undoing update: %v", err)
+	}
+	if undoEvent.UndoneEventID != updated.ID {
+		t.Errorf("expected the update %d undone instead of the overdue refresh, got %d", updated.ID, undoEvent.UndoneEventID)
+	}
+	if reverted.Title != "Draft" || !reverted.OverDue {
+		t.Errorf("expected title Draft and the task still overdue, got %+v", reverted)
+	}
+}
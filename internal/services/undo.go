@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+var (
+	ErrUndoConflict = errors.New("task was modified after this event")
+	ErrNotUndoable  = errors.New("event cannot be undone")
+)
+
+type UndoConflictError struct {
+	Conflicting models.TaskEvent
+}
+
+func (e *UndoConflictError) Error() string {
+	return fmt.Sprintf("%s: event %d", ErrUndoConflict, e.Conflicting.ID)
+}
+
+func (e *UndoConflictError) Unwrap() error {
+	return ErrUndoConflict
+}
+
+func UndoLast(taskID int64, actor string, st storage.Storage, logger *slog.Logger) (models.Task, models.TaskEvent, error) {
+	return undo(0, taskID, actor, st, logger)
+}
+
+// UndoEvent reverts the given event when it is still the latest change of its task.
+func UndoEvent(eventID int64, actor string, st storage.Storage, logger *slog.Logger) (models.Task, models.TaskEvent, error) {
+	es, ok := st.(storage.EventStore)
+	if !ok {
+		return models.Task{}, models.TaskEvent{}, ErrHistoryUnsupported
+	}
+
+	event, err := es.GetEvent(eventID, logger)
+	if err != nil {
+		return models.Task{}, models.TaskEvent{}, err
+	}
+	return undo(event.ID, event.TaskID, actor, st, logger)
+}
+
+// undo reverts the latest event of the task when eventID is 0. Overdue refreshes are skipped:
+// the checker keeps that flag up to date on its own and they are not a user's change to revert.
+func undo(eventID, taskID int64, actor string, st storage.Storage, logger *slog.Logger) (models.Task, models.TaskEvent, error) {
+	es, ok := st.(storage.EventStore)
+	if !ok {
+		return models.Task{}, models.TaskEvent{}, ErrHistoryUnsupported
+	}
+
+	var (
+		reverted  models.Task
+		undoEvent models.TaskEvent
+	)
+	err := es.WithinTx(func(tx storage.Storage) error {
+		events, err := GetTaskHistory(taskID, tx, logger)
+		if err != nil {
+			return err
+		}
+		event := lastChange(events)
+		if eventID != 0 && event.ID != eventID {
+			return &UndoConflictError{Conflicting: event}
+		}
+		if event.After == nil {
+			return fmt.Errorf("%w: task was purged", ErrNotUndoable)
+		}
+
+		current, err := tx.GetTask(taskID, logger)
+		if err != nil {
+			return err
+		}
+		if reverted, err = applyState(current, event.Before, tx, logger); err != nil {
+			return err
+		}
+
+		undoEvent, err = appendEvent(models.TaskEvent{
+			Type:          models.EventUndone,
+			Actor:         actor,
+			UndoneEventID: event.ID,
+		}, &current, &reverted, tx, logger)
+		if err != nil {
+			return err
+		}
+		return rescheduleReminders(&current, &reverted, tx, logger)
+	}, logger)
+	if err != nil {
+		return models.Task{}, models.TaskEvent{}, err
+	}
+	return reverted, undoEvent, nil
+}
+
+func lastChange(events []models.TaskEvent) models.TaskEvent {
+	for i := len(events) - 1; i > 0; i-- {
+		if !overdueOnly(events[i]) {
+			return events[i]
+		}
+	}
+	return events[0]
+}
+
+func overdueOnly(event models.TaskEvent) bool {
+	if event.Before == nil || event.After == nil || len(event.Changes) == 0 {
+		return false
+	}
+	for _, change := range event.Changes {
+		if change.Field != "overDue" {
+			return false
+		}
+	}
+	return true
+}
+
+// applyState trashes the task for a nil target, i.e. before it existed.
+func applyState(current models.Task, target *models.Task, st storage.Storage, logger *slog.Logger) (models.Task, error) {
+	if target == nil {
+		if current.DeletedAt == nil {
+			if err := st.DeleteTask(current.ID, logger); err != nil {
+				return models.Task{}, err
+			}
+		}
+		return st.GetTask(current.ID, logger)
+	}
+
+	if current.DeletedAt != nil {
+		if _, err := st.RestoreTask(current.ID, logger); err != nil {
+			return models.Task{}, err
+		}
+	}
+
+	content := *target
+	content.DeletedAt = nil
+	content.OverDue = IsOverdue(content, ClockOf(st).Now())
+	if _, err := st.UpdateTask(content, logger); err != nil {
+		return models.Task{}, err
+	}
+
+	if target.DeletedAt != nil {
+		if err := st.DeleteTask(current.ID, logger); err != nil {
+			return models.Task{}, err
+		}
+	}
+	return st.GetTask(current.ID, logger)
+}
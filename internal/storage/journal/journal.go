@@ -0,0 +1,350 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/atomicfile"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/storage/memory"
+)
+
+const (
+	FsyncAlways   = "always"
+	FsyncInterval = "interval"
+	FsyncNever    = "never"
+
+	logFile      = "journal.log"
+	snapshotFile = "snapshot.json"
+
+	headerSize     = 8
+	maxRecordBytes = 64 << 20
+)
+
+var (
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+	errChecksum = errors.New("record checksum mismatch")
+	errTooLarge = errors.New("record length exceeds limit")
+)
+
+type Options struct {
+	// Fsync defaults to FsyncAlways.
+	Fsync           string
+	FsyncInterval   time.Duration
+	CompactInterval time.Duration
+}
+
+// A record is a big-endian uint32 payload length, a uint32 CRC-32C and the JSON payload.
+type Storage struct {
+	*memory.Storage
+
+	mu       sync.Mutex
+	dir      string
+	file     *os.File
+	opts     Options
+	logger   *slog.Logger
+	unsynced bool
+	// broken is set when a failed append could not be undone.
+	broken error
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+type record struct {
+	Put    []models.Task `json:"put,omitempty"`
+	Remove []int64       `json:"remove,omitempty"`
+}
+
+func NewStorage(dir string, idgen id.Generator, opts Options, logger *slog.Logger) (*Storage, error) {
+	switch opts.Fsync {
+	case "":
+		opts.Fsync = FsyncAlways
+	case FsyncAlways, FsyncNever:
+	case FsyncInterval:
+		if opts.FsyncInterval <= 0 {
+			return nil, errors.New("fsync interval must be positive")
+		}
+	default:
+		return nil, fmt.Errorf("unknown fsync policy %q", opts.Fsync)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	tasks, err := loadSnapshot(filepath.Join(dir, snapshotFile))
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, logFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err = replay(file, tasks, logger)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	st := &Storage{
+		dir:    dir,
+		file:   file,
+		opts:   opts,
+		logger: logger,
+		stop:   make(chan struct{}),
+	}
+	st.Storage = memory.NewJournaled(idgen, tasks, st)
+
+	if opts.Fsync == FsyncInterval {
+		st.every(opts.FsyncInterval, st.syncIfNeeded)
+	}
+	if opts.CompactInterval > 0 {
+		st.every(opts.CompactInterval, st.compactLogged)
+	}
+	return st, nil
+}
+
+func (st *Storage) Append(put []models.Task, removed []int64) error {
+	payload, err := json.Marshal(record{Put: put, Remove: removed})
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, headerSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.Checksum(payload, crcTable))
+	copy(buf[headerSize:], payload)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.broken != nil {
+		return fmt.Errorf("journal is unusable: %w", st.broken)
+	}
+
+	offset, err := st.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	_, err = st.file.Write(buf)
+	if err == nil && st.opts.Fsync == FsyncAlways {
+		err = st.file.Sync()
+	}
+	if err != nil {
+		// Drop the record so a change the caller sees fail is not replayed, and the next append
+		// does not land behind garbage.
+		truncErr := st.file.Truncate(offset)
+		_, seekErr := st.file.Seek(offset, io.SeekStart)
+		if truncErr != nil || seekErr != nil {
+			st.broken = errors.Join(truncErr, seekErr)
+		}
+		return errors.Join(err, truncErr, seekErr)
+	}
+
+	if st.opts.Fsync != FsyncAlways {
+		st.unsynced = true
+	}
+	return nil
+}
+
+func (st *Storage) Compact() error {
+	return st.Checkpoint(func(tasks []models.Task) error {
+		b, err := json.Marshal(tasks)
+		if err != nil {
+			return err
+		}
+		if err := atomicfile.Write(filepath.Join(st.dir, snapshotFile), b); err != nil {
+			return err
+		}
+
+		st.mu.Lock()
+		defer st.mu.Unlock()
+
+		if err := st.file.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := st.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		st.unsynced = false
+		st.broken = nil
+		return st.file.Sync()
+	})
+}
+
+func (st *Storage) Close() error {
+	close(st.stop)
+	st.done.Wait()
+
+	compactErr := st.Compact()
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	return errors.Join(compactErr, st.file.Close())
+}
+
+func (st *Storage) every(interval time.Duration, fn func()) {
+	st.done.Add(1)
+	go func() {
+		defer st.done.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-st.stop:
+				return
+			case <-ticker.C:
+				fn()
+			}
+		}
+	}()
+}
+
+func (st *Storage) syncIfNeeded() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if !st.unsynced {
+		return
+	}
+	if err := st.file.Sync(); err != nil {
+		st.logger.Error("error on syncing journal", sl.Err(err))
+		return
+	}
+	st.unsynced = false
+}
+
+func (st *Storage) compactLogged() {
+	if err := st.Compact(); err != nil {
+		st.logger.Error("error on compacting journal", sl.Err(err))
+	}
+}
+
+func loadSnapshot(path string) ([]models.Task, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []models.Task
+	if err := json.Unmarshal(b, &tasks); err != nil {
+		return nil, fmt.Errorf("decoding snapshot %s: %w", path, err)
+	}
+	return tasks, nil
+}
+
+// replay cuts the log at a torn record or checksum mismatch; other read errors are returned.
+func replay(file *os.File, tasks []models.Task, logger *slog.Logger) ([]models.Task, error) {
+	state := make(map[int64]models.Task, len(tasks))
+	order := make([]int64, 0, len(tasks))
+	for _, task := range tasks {
+		state[task.ID] = task
+		order = append(order, task.ID)
+	}
+
+	reader := bufio.NewReader(file)
+	var (
+		offset  int64
+		records int
+		header  [headerSize]byte
+	)
+	for {
+		rec, size, err := readRecord(reader, header[:])
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, errChecksum) && !errors.Is(err, errTooLarge) {
+			return nil, fmt.Errorf("reading journal at offset %d: %w", offset, err)
+		}
+		if err != nil {
+			logger.Warn("truncating journal after damaged record", slog.Int64("offset", offset), sl.Err(err))
+			if err := file.Truncate(offset); err != nil {
+				return nil, err
+			}
+			if err := file.Sync(); err != nil {
+				return nil, err
+			}
+			break
+		}
+
+		for _, task := range rec.Put {
+			if _, ok := state[task.ID]; !ok {
+				order = append(order, task.ID)
+			}
+			state[task.ID] = task
+		}
+		for _, id := range rec.Remove {
+			delete(state, id)
+		}
+		offset += size
+		records++
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	logger.Info("journal replayed", slog.Int("records", records), slog.Int("tasks", len(state)))
+
+	result := make([]models.Task, 0, len(state))
+	for _, id := range order {
+		if task, ok := state[id]; ok {
+			result = append(result, task)
+			delete(state, id)
+		}
+	}
+	return result, nil
+}
+
+func readRecord(reader io.Reader, header []byte) (record, int64, error) {
+	if _, err := io.ReadFull(reader, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return record{}, 0, fmt.Errorf("torn record header: %w", err)
+		}
+		return record{}, 0, err
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	checksum := binary.BigEndian.Uint32(header[4:8])
+	if length > maxRecordBytes {
+		return record{}, 0, fmt.Errorf("%w: %d", errTooLarge, length)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return record{}, 0, fmt.Errorf("torn record payload: %w", err)
+	}
+	if crc32.Checksum(payload, crcTable) != checksum {
+		return record{}, 0, errChecksum
+	}
+
+	var rec record
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return record{}, 0, fmt.Errorf("decoding record: %w", err)
+	}
+	return rec, int64(headerSize) + int64(length), nil
+}
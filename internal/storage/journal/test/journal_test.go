@@ -0,0 +1,187 @@
+package journal_test
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+	"github.com/gintokos/tasksrestapi/internal/storage/journal"
+	"github.com/gintokos/tasksrestapi/internal/storage/storagetest"
+)
+
+var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func open(t *testing.T, dir string) *journal.Storage {
+	t.Helper()
+
+	st, err := journal.NewStorage(dir, id.NewRandomGenerator(), journal.Options{Fsync: journal.FsyncAlways}, logger)
+	if err != nil {
+		t.Fatalf("error on opening journal: %v", err)
+	}
+	return st
+}
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		st := open(t, t.TempDir())
+		t.Cleanup(func() { st.Close() })
+		return st
+	})
+}
+
+func TestReplay(t *testing.T) {
+	dir := t.TempDir()
+	st := open(t, dir)
+
+	kept, _ := st.CreateTask(models.Task{Title: "Kept"}, logger)
+	changed, _ := st.CreateTask(models.Task{Title: "Before"}, logger)
+	changed.Title = "After"
+	if _, err := st.UpdateTask(changed, logger); err != nil {
+		t.Fatalf("error on updating task: %v", err)
+	}
+	trashed, _ := st.CreateTask(models.Task{Title: "Trashed"}, logger)
+	if err := st.DeleteTask(trashed.ID, logger); err != nil {
+		t.Fatalf("error on deleting task: %v", err)
+	}
+
+	// Simulate a crash: the log is left as is, without the compaction Close would do.
+	reopened := open(t, dir)
+	defer reopened.Close()
+
+	tasks, _ := reopened.GetAllTasks(logger)
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 live tasks after replay, got %+v", tasks)
+	}
+	got, err := reopened.GetTask(changed.ID, logger)
+	if err != nil || got.Title != "After" {
+		t.Fatalf("expected replayed update, got %+v (err %v)", got, err)
+	}
+	if _, err := reopened.GetTask(kept.ID, logger); err != nil {
+		t.Fatalf("expected task %d after replay: %v", kept.ID, err)
+	}
+	trash, _ := reopened.GetTrash(logger)
+	if len(trash) != 1 || trash[0].ID != trashed.ID {
+		t.Fatalf("expected trashed task after replay, got %+v", trash)
+	}
+}
+
+func TestReplay_TruncatesTornTail(t *testing.T) {
+	dir := t.TempDir()
+	st := open(t, dir)
+
+	first, _ := st.CreateTask(models.Task{Title: "First"}, logger)
+	st.CreateTask(models.Task{Title: "Second"}, logger)
+
+	logPath := filepath.Join(dir, "journal.log")
+	info, err := os.Stat(logPath)
+	if err != nil {
+		t.Fatalf("error on stating journal: %v", err)
+	}
+
+	// Cut the last record in half, as a crash in the middle of a write would.
+	if err := os.Truncate(logPath, info.Size()-5); err != nil {
+		t.Fatalf("error on truncating journal: %v", err)
+	}
+
+	reopened := open(t, dir)
+	tasks, _ := reopened.GetAllTasks(logger)
+	if len(tasks) != 1 || tasks[0].ID != first.ID {
+		t.Fatalf("expected only the first task to survive, got %+v", tasks)
+	}
+
+	third, err := reopened.CreateTask(models.Task{Title: "Third"}, logger)
+	if err != nil {
+		t.Fatalf("error on creating task after recovery: %v", err)
+	}
+
+	again := open(t, dir)
+	defer again.Close()
+	if _, err := again.GetTask(third.ID, logger); err != nil {
+		t.Fatalf("expected task written after recovery to replay: %v", err)
+	}
+}
+
+func TestReplay_TruncatesCorruptedRecord(t *testing.T) {
+	dir := t.TempDir()
+	st := open(t, dir)
+
+	first, _ := st.CreateTask(models.Task{Title: "First"}, logger)
+	st.CreateTask(models.Task{Title: "Second"}, logger)
+
+	logPath := filepath.Join(dir, "journal.log")
+	b, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("error on reading journal: %v", err)
+	}
+	b[len(b)-2] ^= 0xff
+	if err := os.WriteFile(logPath, b, 0o644); err != nil {
+		t.Fatalf("error on writing journal: %v", err)
+	}
+
+	reopened := open(t, dir)
+	defer reopened.Close()
+
+	tasks, _ := reopened.GetAllTasks(logger)
+	if len(tasks) != 1 || tasks[0].ID != first.ID {
+		t.Fatalf("expected corrupted record to be dropped, got %+v", tasks)
+	}
+}
+
+func TestReplay_TruncatesOversizedHeader(t *testing.T) {
+	dir := t.TempDir()
+	st := open(t, dir)
+
+	first, _ := st.CreateTask(models.Task{Title: "First"}, logger)
+
+	logPath := filepath.Join(dir, "journal.log")
+	f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("error on opening journal: %v", err)
+	}
+	f.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 'x'})
+	f.Close()
+
+	reopened := open(t, dir)
+	defer reopened.Close()
+
+	tasks, _ := reopened.GetAllTasks(logger)
+	if len(tasks) != 1 || tasks[0].ID != first.ID {
+		t.Fatalf("expected the damaged tail to be dropped, got %+v", tasks)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	st := open(t, dir)
+
+	task, _ := st.CreateTask(models.Task{Title: "Task"}, logger)
+	if err := st.Compact(); err != nil {
+		t.Fatalf("error on compacting: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "journal.log"))
+	if err != nil {
+		t.Fatalf("error on stating journal: %v", err)
+	}
+	if info.Size() != 0 {
+		t.Fatalf("expected empty log after compaction, got %d bytes", info.Size())
+	}
+
+	task.Title = "Changed after compaction"
+	if _, err := st.UpdateTask(task, logger); err != nil {
+		t.Fatalf("error on updating task: %v", err)
+	}
+
+	reopened := open(t, dir)
+	defer reopened.Close()
+
+	got, err := reopened.GetTask(task.ID, logger)
+	if err != nil || got.Title != "Changed after compaction" {
+		t.Fatalf("expected snapshot plus log to replay, got %+v (err %v)", got, err)
+	}
+}
@@ -0,0 +1,501 @@
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/atomicfile"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+const maxCreateAttempts = 5
+
+type Storage struct {
+	storage.ClockSource
+
+	mu    sync.RWMutex
+	tasks map[int64]models.Task
+	byDue []dueEntry
+	idgen id.Generator
+
+	journal Journal
+	pending []change
+
+	snapshotPath string
+	dirty        bool
+	logger       *slog.Logger
+	stop         chan struct{}
+	done         chan struct{}
+}
+
+type dueEntry struct {
+	due time.Time
+	id  int64
+}
+
+// Journal: a failed Append rolls the mutation back.
+type Journal interface {
+	Append(put []models.Task, removed []int64) error
+}
+
+type change struct {
+	id      int64
+	before  models.Task
+	existed bool
+}
+
+func NewJournaled(idgen id.Generator, tasks []models.Task, journal Journal) *Storage {
+	st := &Storage{
+		tasks:   make(map[int64]models.Task, len(tasks)),
+		idgen:   idgen,
+		journal: journal,
+	}
+	for _, task := range tasks {
+		st.set(task)
+	}
+	return st
+}
+
+func NewStorage(idgen id.Generator, snapshotPath string, snapshotInterval time.Duration, logger *slog.Logger) (*Storage, error) {
+	st := &Storage{
+		tasks:        make(map[int64]models.Task),
+		idgen:        idgen,
+		snapshotPath: snapshotPath,
+		logger:       logger,
+	}
+
+	if snapshotPath == "" {
+		return st, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(snapshotPath), 0o755); err != nil {
+		return nil, err
+	}
+	if err := st.load(); err != nil {
+		return nil, err
+	}
+	if snapshotInterval > 0 {
+		st.stop = make(chan struct{})
+		st.done = make(chan struct{})
+		go st.snapshotLoop(snapshotInterval)
+	}
+	return st, nil
+}
+
+func (st *Storage) GetAllTasks(logger *slog.Logger) ([]models.Task, error) {
+	logger.Info("op: storage.memory.GetAllTasks")
+
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	return st.sorted(func(t models.Task) bool { return t.DeletedAt == nil }), nil
+}
+
+func (st *Storage) GetTask(id int64, logger *slog.Logger) (models.Task, error) {
+	logger.Info("op: storage.memory.GetTask")
+
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	task, ok := st.tasks[id]
+	if !ok {
+		return models.Task{}, storage.ErrNotFound
+	}
+	return task, nil
+}
+
+func (st *Storage) GetTasksDueBefore(before time.Time, logger *slog.Logger) ([]models.Task, error) {
+	logger.Info("op: storage.memory.GetTasksDueBefore")
+
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	end := sort.Search(len(st.byDue), func(i int) bool { return !st.byDue[i].due.Before(before) })
+	tasks := make([]models.Task, 0, end)
+	for _, entry := range st.byDue[:end] {
+		tasks = append(tasks, st.tasks[entry.id])
+	}
+	return tasks, nil
+}
+
+func (st *Storage) CreateTask(task models.Task, logger *slog.Logger) (models.Task, error) {
+	logger.Info("op: storage.memory.CreateTask")
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	task, err := st.create(task)
+	if err != nil {
+		return models.Task{}, err
+	}
+	return task, st.commit()
+}
+
+func (st *Storage) UpdateTask(task models.Task, logger *slog.Logger) (models.Task, error) {
+	logger.Info("op: storage.memory.UpdateTask")
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	task, err := st.update(task)
+	if err != nil {
+		return models.Task{}, err
+	}
+	return task, st.commit()
+}
+
+func (st *Storage) DeleteTask(id int64, logger *slog.Logger) error {
+	logger.Info("op: storage.memory.DeleteTask")
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if err := st.delete(id); err != nil {
+		return err
+	}
+	return st.commit()
+}
+
+func (st *Storage) ApplyBatch(ops []models.BatchOperation, atomic bool, logger *slog.Logger) ([]storage.BatchResult, error) {
+	logger.Info("op: storage.memory.ApplyBatch")
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	results := make([]storage.BatchResult, len(ops))
+	for i, op := range ops {
+		switch op.Op {
+		case models.BatchCreate:
+			results[i].Task, results[i].Err = st.create(op.Task)
+		case models.BatchUpdate:
+			results[i].Task, results[i].Err = st.update(op.Task)
+		case models.BatchDelete:
+			results[i] = storage.BatchResult{Task: models.Task{ID: op.Task.ID}, Err: st.delete(op.Task.ID)}
+		default:
+			results[i].Err = storage.ErrUnknownOperation
+		}
+
+		if results[i].Err != nil && atomic {
+			st.rollback()
+			storage.MarkRolledBack(results)
+			return results, nil
+		}
+	}
+
+	if err := st.commit(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (st *Storage) GetTrash(logger *slog.Logger) ([]models.Task, error) {
+	logger.Info("op: storage.memory.GetTrash")
+
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	tasks := st.sorted(func(t models.Task) bool { return t.DeletedAt != nil })
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].DeletedAt.After(*tasks[j].DeletedAt) })
+	return tasks, nil
+}
+
+func (st *Storage) RestoreTask(id int64, logger *slog.Logger) (models.Task, error) {
+	logger.Info("op: storage.memory.RestoreTask")
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	task, ok := st.tasks[id]
+	if !ok || task.DeletedAt == nil {
+		return models.Task{}, storage.ErrNotFound
+	}
+	task.DeletedAt = nil
+	st.put(task)
+	return task, st.commit()
+}
+
+func (st *Storage) PurgeTask(id int64, logger *slog.Logger) error {
+	logger.Info("op: storage.memory.PurgeTask")
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	task, ok := st.tasks[id]
+	if !ok || task.DeletedAt == nil {
+		return storage.ErrNotFound
+	}
+	st.remove(id)
+	return st.commit()
+}
+
+func (st *Storage) PurgeTrash(before time.Time, logger *slog.Logger) ([]int64, error) {
+	logger.Info("op: storage.memory.PurgeTrash")
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var ids []int64
+	for id, task := range st.tasks {
+		if task.DeletedAt != nil && !task.DeletedAt.After(before) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		st.remove(id)
+	}
+	if err := st.commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (st *Storage) Close() error {
+	if st.stop != nil {
+		close(st.stop)
+		<-st.done
+	}
+	if st.snapshotPath == "" {
+		return nil
+	}
+	return st.Snapshot()
+}
+
+func (st *Storage) create(task models.Task) (models.Task, error) {
+	for attempt := 0; attempt < maxCreateAttempts; attempt++ {
+		next, err := st.idgen.NextID()
+		if err != nil {
+			return models.Task{}, err
+		}
+		if _, taken := st.tasks[next]; taken {
+			continue
+		}
+
+		task.ID = next
+		task.DeletedAt = nil
+		st.put(task)
+		return task, nil
+	}
+	return models.Task{}, fmt.Errorf("%w after %d attempts", storage.ErrIDConflict, maxCreateAttempts)
+}
+
+func (st *Storage) update(task models.Task) (models.Task, error) {
+	current, ok := st.tasks[task.ID]
+	if !ok || current.DeletedAt != nil {
+		return models.Task{}, storage.ErrNotFound
+	}
+	task.DeletedAt = nil
+	st.put(task)
+	return task, nil
+}
+
+func (st *Storage) delete(id int64) error {
+	task, ok := st.tasks[id]
+	if !ok || task.DeletedAt != nil {
+		return storage.ErrNotFound
+	}
+	now := st.Clock().Now().UTC()
+	task.DeletedAt = &now
+	st.put(task)
+	return nil
+}
+
+// put and remove are the only ways mutations change tasks; callers finish with commit.
+func (st *Storage) put(task models.Task) {
+	st.track(task.ID)
+	st.set(task)
+}
+
+func (st *Storage) remove(id int64) {
+	if _, ok := st.tasks[id]; ok {
+		st.track(id)
+		st.unset(id)
+	}
+}
+
+func (st *Storage) track(id int64) {
+	before, existed := st.tasks[id]
+	st.pending = append(st.pending, change{id: id, before: before, existed: existed})
+}
+
+func (st *Storage) commit() error {
+	if len(st.pending) == 0 {
+		return nil
+	}
+
+	if st.journal != nil {
+		var (
+			put     []models.Task
+			removed []int64
+			seen    = make(map[int64]bool, len(st.pending))
+		)
+		for _, c := range st.pending {
+			if seen[c.id] {
+				continue
+			}
+			seen[c.id] = true
+			if task, ok := st.tasks[c.id]; ok {
+				put = append(put, task)
+			} else {
+				removed = append(removed, c.id)
+			}
+		}
+		if err := st.journal.Append(put, removed); err != nil {
+			st.rollback()
+			return err
+		}
+	}
+
+	st.pending = nil
+	st.dirty = true
+	return nil
+}
+
+func (st *Storage) rollback() {
+	for i := len(st.pending) - 1; i >= 0; i-- {
+		c := st.pending[i]
+		if c.existed {
+			st.set(c.before)
+		} else {
+			st.unset(c.id)
+		}
+	}
+	st.pending = nil
+}
+
+func (st *Storage) set(task models.Task) {
+	if previous, ok := st.tasks[task.ID]; ok {
+		st.unindex(previous)
+	}
+	st.tasks[task.ID] = task
+	if task.DueDate != nil && task.DeletedAt == nil {
+		entry := dueEntry{due: *task.DueDate, id: task.ID}
+		i := sort.Search(len(st.byDue), func(i int) bool { return !st.byDue[i].less(entry) })
+		st.byDue = append(st.byDue, dueEntry{})
+		copy(st.byDue[i+1:], st.byDue[i:])
+		st.byDue[i] = entry
+	}
+}
+
+func (st *Storage) unset(id int64) {
+	if task, ok := st.tasks[id]; ok {
+		st.unindex(task)
+		delete(st.tasks, id)
+	}
+}
+
+func (st *Storage) unindex(task models.Task) {
+	if task.DueDate == nil || task.DeletedAt != nil {
+		return
+	}
+	entry := dueEntry{due: *task.DueDate, id: task.ID}
+	i := sort.Search(len(st.byDue), func(i int) bool { return !st.byDue[i].less(entry) })
+	if i < len(st.byDue) && st.byDue[i].id == task.ID {
+		st.byDue = append(st.byDue[:i], st.byDue[i+1:]...)
+	}
+}
+
+func (e dueEntry) less(other dueEntry) bool {
+	if !e.due.Equal(other.due) {
+		return e.due.Before(other.due)
+	}
+	return e.id < other.id
+}
+
+func (st *Storage) sorted(keep func(models.Task) bool) []models.Task {
+	var tasks []models.Task
+	for _, task := range st.tasks {
+		if keep(task) {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks
+}
+
+// Checkpoint holds the write lock while fn runs.
+func (st *Storage) Checkpoint(fn func(tasks []models.Task) error) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	return fn(st.sorted(func(models.Task) bool { return true }))
+}
+
+func (st *Storage) Snapshot() error {
+	if st.snapshotPath == "" {
+		return nil
+	}
+
+	st.mu.Lock()
+	tasks := st.sorted(func(models.Task) bool { return true })
+	st.dirty = false
+	st.mu.Unlock()
+
+	if err := st.writeSnapshot(tasks); err != nil {
+		st.mu.Lock()
+		st.dirty = true
+		st.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (st *Storage) writeSnapshot(tasks []models.Task) error {
+	b, err := json.Marshal(tasks)
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(st.snapshotPath, b)
+}
+
+func (st *Storage) load() error {
+	b, err := os.ReadFile(st.snapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var tasks []models.Task
+	if err := json.Unmarshal(b, &tasks); err != nil {
+		return fmt.Errorf("decoding snapshot %s: %w", st.snapshotPath, err)
+	}
+	for _, task := range tasks {
+		st.set(task)
+	}
+	return nil
+}
+
+func (st *Storage) snapshotLoop(interval time.Duration) {
+	defer close(st.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-st.stop:
+			return
+		case <-ticker.C:
+			st.mu.RLock()
+			dirty := st.dirty
+			st.mu.RUnlock()
+			if !dirty {
+				continue
+			}
+			if err := st.Snapshot(); err != nil {
+				st.logger.Error("error on writing memory snapshot", sl.Err(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package memory_test
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+	"github.com/gintokos/tasksrestapi/internal/storage/memory"
+	"github.com/gintokos/tasksrestapi/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		st, err := memory.NewStorage(id.NewRandomGenerator(), "", 0, slog.Default())
+		if err != nil {
+			t.Fatalf("error on creating storage: %v", err)
+		}
+		return st
+	})
+}
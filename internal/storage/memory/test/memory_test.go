@@ -0,0 +1,79 @@
+package memory_test
+
+import (
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/storage/memory"
+)
+
+func TestStorage_Snapshot(t *testing.T) {
+	logger := slog.Default()
+	path := filepath.Join(t.TempDir(), "snapshot", "tasks.json")
+
+	st, err := memory.NewStorage(id.NewRandomGenerator(), path, time.Hour, logger)
+	if err != nil {
+		t.Fatalf("error on creating storage: %v", err)
+	}
+
+	kept, _ := st.CreateTask(models.Task{Title: "Kept"}, logger)
+	trashed, _ := st.CreateTask(models.Task{Title: "Trashed"}, logger)
+	if err := st.DeleteTask(trashed.ID, logger); err != nil {
+		t.Fatalf("error on deleting task: %v", err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatalf("error on closing storage: %v", err)
+	}
+
+	reopened, err := memory.NewStorage(id.NewRandomGenerator(), path, 0, logger)
+	if err != nil {
+		t.Fatalf("error on reopening storage: %v", err)
+	}
+
+	tasks, _ := reopened.GetAllTasks(logger)
+	if len(tasks) != 1 || tasks[0].ID != kept.ID {
+		t.Fatalf("expected only task %d after reload, got %+v", kept.ID, tasks)
+	}
+	trash, _ := reopened.GetTrash(logger)
+	if len(trash) != 1 || trash[0].ID != trashed.ID {
+		t.Fatalf("expected task %d in trash after reload, got %+v", trashed.ID, trash)
+	}
+}
+
+func TestStorage_GetTasksDueBefore(t *testing.T) {
+	logger := slog.Default()
+
+	st, err := memory.NewStorage(id.NewRandomGenerator(), "", 0, logger)
+	if err != nil {
+		t.Fatalf("error on creating storage: %v", err)
+	}
+
+	now := time.Now()
+	due := func(d time.Duration) *time.Time {
+		at := now.Add(d)
+		return &at
+	}
+
+	late, _ := st.CreateTask(models.Task{Title: "Late", DueDate: due(-time.Hour)}, logger)
+	later, _ := st.CreateTask(models.Task{Title: "Later", DueDate: due(-2 * time.Hour)}, logger)
+	st.CreateTask(models.Task{Title: "Future", DueDate: due(time.Hour)}, logger)
+	st.CreateTask(models.Task{Title: "No due date"}, logger)
+	moved, _ := st.CreateTask(models.Task{Title: "Moved", DueDate: due(-3 * time.Hour)}, logger)
+
+	moved.DueDate = due(2 * time.Hour)
+	if _, err := st.UpdateTask(moved, logger); err != nil {
+		t.Fatalf("error on updating task: %v", err)
+	}
+
+	tasks, err := st.GetTasksDueBefore(now, logger)
+	if err != nil {
+		t.Fatalf("error on getting due tasks: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != later.ID || tasks[1].ID != late.ID {
+		t.Fatalf("expected tasks %d and %d in due order, got %+v", later.ID, late.ID, tasks)
+	}
+}
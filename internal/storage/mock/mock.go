@@ -1,7 +1,9 @@
 package mocks
 
 import (
+	"sort"
 	"sync"
+	"time"
 
 	"log/slog"
 
@@ -11,12 +13,15 @@ import (
 )
 
 type MockStorage struct {
+	storage.ClockSource
+
 	mu         sync.Mutex
 	tasks      []models.Task
 	GetAllFunc func(logger *slog.Logger) ([]models.Task, error)
 	CreateFunc func(task models.Task, logger *slog.Logger) (models.Task, error)
 	UpdateFunc func(task models.Task, logger *slog.Logger) (models.Task, error)
 	DeleteFunc func(id int64, logger *slog.Logger) error
+	BatchFunc  func(ops []models.BatchOperation, atomic bool, logger *slog.Logger) ([]storage.BatchResult, error)
 }
 
 func NewMockStorage(tasks []models.Task) *MockStorage {
@@ -33,7 +38,26 @@ func (m *MockStorage) GetAllTasks(logger *slog.Logger) ([]models.Task, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	return m.tasks, nil
+	var tasks []models.Task
+	for _, t := range m.tasks {
+		if t.DeletedAt == nil {
+			tasks = append(tasks, t)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+func (m *MockStorage) GetTask(id int64, logger *slog.Logger) (models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, t := range m.tasks {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return models.Task{}, storage.ErrNotFound
 }
 
 func (m *MockStorage) CreateTask(task models.Task, logger *slog.Logger) (models.Task, error) {
@@ -57,21 +81,15 @@ func (m *MockStorage) UpdateTask(task models.Task, logger *slog.Logger) (models.
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	exists := false
-	for _, t := range m.tasks {
-		if t.ID == task.ID {
-			exists = true
-			task.Description = t.Description
-			task.Title = t.Title
-			task.DueDate = t.DueDate
-			task.OverDue = t.OverDue
+	for i := range m.tasks {
+		if m.tasks[i].ID == task.ID && m.tasks[i].DeletedAt == nil {
+			task.DeletedAt = nil
+			m.tasks[i] = task
+			return task, nil
 		}
 	}
-	if !exists {
-		return models.Task{}, storage.ErrNotFound
-	}
 
-	return task, nil
+	return models.Task{}, storage.ErrNotFound
 }
 
 func (m *MockStorage) DeleteTask(id int64, logger *slog.Logger) error {
@@ -82,19 +100,119 @@ func (m *MockStorage) DeleteTask(id int64, logger *slog.Logger) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	exists := false
+	for i := range m.tasks {
+		if m.tasks[i].ID == id && m.tasks[i].DeletedAt == nil {
+			now := m.Clock().Now()
+			m.tasks[i].DeletedAt = &now
+			return nil
+		}
+	}
+
+	return storage.ErrNotFound
+}
+
+func (m *MockStorage) ApplyBatch(ops []models.BatchOperation, atomic bool, logger *slog.Logger) ([]storage.BatchResult, error) {
+	if m.BatchFunc != nil {
+		return m.BatchFunc(ops, atomic, logger)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks := append([]models.Task(nil), m.tasks...)
+	results := make([]storage.BatchResult, len(ops))
+	for i, op := range ops {
+		switch op.Op {
+		case models.BatchCreate:
+			task := op.Task
+			task.ID = id.GenerateRandomID()
+			tasks = append(tasks, task)
+			results[i].Task = task
+		case models.BatchUpdate:
+			results[i].Err = storage.ErrNotFound
+			for j := range tasks {
+				if tasks[j].ID == op.Task.ID && tasks[j].DeletedAt == nil {
+					tasks[j] = op.Task
+					results[i] = storage.BatchResult{Task: op.Task}
+				}
+			}
+		case models.BatchDelete:
+			results[i] = storage.BatchResult{Task: models.Task{ID: op.Task.ID}, Err: storage.ErrNotFound}
+			for j := range tasks {
+				if tasks[j].ID == op.Task.ID && tasks[j].DeletedAt == nil {
+					now := m.Clock().Now()
+					tasks[j].DeletedAt = &now
+					results[i].Err = nil
+					break
+				}
+			}
+		default:
+			results[i].Err = storage.ErrUnknownOperation
+		}
+
+		if results[i].Err != nil && atomic {
+			storage.MarkRolledBack(results)
+			return results, nil
+		}
+	}
+	m.tasks = tasks
+
+	return results, nil
+}
+
+func (m *MockStorage) GetTrash(logger *slog.Logger) ([]models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var tasks []models.Task
+	for _, t := range m.tasks {
+		if t.DeletedAt != nil {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+func (m *MockStorage) RestoreTask(id int64, logger *slog.Logger) (models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.tasks {
+		if m.tasks[i].ID == id && m.tasks[i].DeletedAt != nil {
+			m.tasks[i].DeletedAt = nil
+			return m.tasks[i], nil
+		}
+	}
+	return models.Task{}, storage.ErrNotFound
+}
+
+func (m *MockStorage) PurgeTask(id int64, logger *slog.Logger) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.tasks {
+		if m.tasks[i].ID == id && m.tasks[i].DeletedAt != nil {
+			m.tasks = append(m.tasks[:i], m.tasks[i+1:]...)
+			return nil
+		}
+	}
+	return storage.ErrNotFound
+}
+
+func (m *MockStorage) PurgeTrash(before time.Time, logger *slog.Logger) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var purged []int64
 	buff := make([]models.Task, 0, len(m.tasks))
 	for _, t := range m.tasks {
-		if t.ID == id {
-			exists = true
+		if t.DeletedAt != nil && !t.DeletedAt.After(before) {
+			purged = append(purged, t.ID)
 			continue
 		}
 		buff = append(buff, t)
 	}
-	if !exists {
-		return storage.ErrNotFound
-	}
 	m.tasks = buff
 
-	return nil
+	return purged, nil
 }
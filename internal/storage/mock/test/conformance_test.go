@@ -0,0 +1,15 @@
+package mocks_test
+
+import (
+	"testing"
+
+	"github.com/gintokos/tasksrestapi/internal/storage"
+	mocks "github.com/gintokos/tasksrestapi/internal/storage/mock"
+	"github.com/gintokos/tasksrestapi/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return mocks.NewMockStorage(nil)
+	})
+}
@@ -0,0 +1,113 @@
+package sqllite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+var ErrSchemaVersion = errors.New("backup schema version is newer than supported")
+
+func (st *Storage) Backup(path string, logger *slog.Logger) error {
+	logger.Info("op: storage.sqllite.Backup")
+
+	_, err := st.db.Exec("VACUUM INTO ?", path)
+	return err
+}
+
+func ValidateBackup(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	db, err := sql.Open("sqlite3", fileURI(path, url.Values{"mode": {"ro"}}))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var check string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&check); err != nil {
+		return err
+	}
+	if check != "ok" {
+		return fmt.Errorf("integrity check failed: %s", check)
+	}
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("%w: got %d, want at most %d", ErrSchemaVersion, version, len(migrations))
+	}
+	return nil
+}
+
+// Restore must run with the service stopped. A backup from an older schema is migrated before it
+// replaces the storage.
+func Restore(backupPath, storagepath string) error {
+	if err := ValidateBackup(backupPath); err != nil {
+		return err
+	}
+
+	src, err := os.Open(backupPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dir := filepath.Dir(storagepath)
+	tmp, err := os.CreateTemp(dir, filepath.Base(storagepath)+".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := migrateFile(tmp.Name()); err != nil {
+		return err
+	}
+
+	// A stale WAL would be replayed on top of the restored file.
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(storagepath + suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(tmp.Name(), storagepath); err != nil {
+		return err
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func migrateFile(path string) error {
+	db, err := sql.Open("sqlite3", fileURI(path, nil))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return migrate(db)
+}
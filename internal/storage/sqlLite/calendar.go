@@ -0,0 +1,57 @@
+package sqllite
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+func (st *Storage) GetFeedByOwner(owner string, logger *slog.Logger) (models.CalendarFeed, error) {
+	logger.Info("op: storage.sqllite.GetFeedByOwner")
+
+	return scanFeed(stmt(st.tx, st.stmts.getFeedByOwner).QueryRow(owner))
+}
+
+func (st *Storage) GetFeedByToken(tokenHash string, logger *slog.Logger) (models.CalendarFeed, error) {
+	logger.Info("op: storage.sqllite.GetFeedByToken")
+
+	return scanFeed(stmt(st.tx, st.stmts.getFeedByToken).QueryRow(tokenHash))
+}
+
+func (st *Storage) SaveFeed(feed models.CalendarFeed, logger *slog.Logger) error {
+	logger.Info("op: storage.sqllite.SaveFeed")
+
+	_, err := stmt(st.tx, st.stmts.saveFeed).Exec(feed.Owner, feed.TokenHash, strings.Join(feed.Components, ","),
+		feed.IncludeOverdue, feed.CreatedAt.UTC(), feed.UpdatedAt.UTC())
+	return err
+}
+
+func (st *Storage) DeleteFeed(owner string, logger *slog.Logger) error {
+	logger.Info("op: storage.sqllite.DeleteFeed")
+
+	res, err := stmt(st.tx, st.stmts.deleteFeed).Exec(owner)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res)
+}
+
+func scanFeed(row rowScanner) (models.CalendarFeed, error) {
+	var (
+		feed       models.CalendarFeed
+		components string
+	)
+	err := row.Scan(&feed.Owner, &feed.TokenHash, &components, &feed.IncludeOverdue, &feed.CreatedAt, &feed.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.CalendarFeed{}, storage.ErrNotFound
+	}
+	if err != nil {
+		return models.CalendarFeed{}, err
+	}
+	feed.Components = strings.Split(components, ",")
+	return feed, nil
+}
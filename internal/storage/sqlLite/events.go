@@ -0,0 +1,133 @@
+package sqllite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+func (st *Storage) AppendEvent(event models.TaskEvent, logger *slog.Logger) (models.TaskEvent, error) {
+	logger.Info("op: storage.sqllite.AppendEvent")
+
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		return models.TaskEvent{}, err
+	}
+	before, err := marshalSnapshot(event.Before)
+	if err != nil {
+		return models.TaskEvent{}, err
+	}
+	after, err := marshalSnapshot(event.After)
+	if err != nil {
+		return models.TaskEvent{}, err
+	}
+
+	var undoneEventID interface{}
+	if event.UndoneEventID != 0 {
+		undoneEventID = event.UndoneEventID
+	}
+
+	res, err := stmt(st.tx, st.stmts.appendEvent).Exec(event.TaskID, event.Type, event.Actor, event.At.UTC(), changes, before, after, undoneEventID)
+	if err != nil {
+		return models.TaskEvent{}, err
+	}
+
+	event.ID, err = res.LastInsertId()
+	if err != nil {
+		return models.TaskEvent{}, err
+	}
+	return event, nil
+}
+
+func (st *Storage) GetTaskEvents(taskID int64, logger *slog.Logger) ([]models.TaskEvent, error) {
+	logger.Info("op: storage.sqllite.GetTaskEvents")
+
+	rows, err := stmt(st.tx, st.stmts.getTaskEvents).Query(taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.TaskEvent
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (st *Storage) GetEvent(id int64, logger *slog.Logger) (models.TaskEvent, error) {
+	logger.Info("op: storage.sqllite.GetEvent")
+
+	event, err := scanEvent(stmt(st.tx, st.stmts.getEvent).QueryRow(id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.TaskEvent{}, storage.ErrNotFound
+	}
+	return event, err
+}
+
+func (st *Storage) LastEventAt(logger *slog.Logger) (time.Time, error) {
+	logger.Info("op: storage.sqllite.LastEventAt")
+
+	var at time.Time
+	err := stmt(st.tx, st.stmts.lastEventAt).QueryRow().Scan(&at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	return at, err
+}
+
+func scanEvent(row rowScanner) (models.TaskEvent, error) {
+	var (
+		event         models.TaskEvent
+		changes       []byte
+		before, after []byte
+		undoneEventID sql.NullInt64
+	)
+	err := row.Scan(&event.ID, &event.TaskID, &event.Type, &event.Actor, &event.At, &changes, &before, &after, &undoneEventID)
+	if err != nil {
+		return models.TaskEvent{}, err
+	}
+	event.UndoneEventID = undoneEventID.Int64
+
+	if err := json.Unmarshal(changes, &event.Changes); err != nil {
+		return models.TaskEvent{}, err
+	}
+	if event.Before, err = unmarshalSnapshot(before); err != nil {
+		return models.TaskEvent{}, err
+	}
+	if event.After, err = unmarshalSnapshot(after); err != nil {
+		return models.TaskEvent{}, err
+	}
+	return event, nil
+}
+
+func marshalSnapshot(task *models.Task) (interface{}, error) {
+	if task == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func unmarshalSnapshot(b []byte) (*models.Task, error) {
+	if b == nil {
+		return nil, nil
+	}
+	var task models.Task
+	if err := json.Unmarshal(b, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
@@ -0,0 +1,57 @@
+package sqllite
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+func (st *Storage) ReserveIdempotencyKey(rec storage.IdempotencyRecord, logger *slog.Logger) (storage.IdempotencyRecord, bool, error) {
+	logger.Info("op: storage.sqllite.ReserveIdempotencyKey")
+
+	res, err := stmt(st.tx, st.stmts.reserveIdempotencyKey).Exec(rec.Key, rec.Fingerprint, rec.CreatedAt.UTC(), rec.ExpiresAt.UTC())
+	if err != nil {
+		return storage.IdempotencyRecord{}, false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return storage.IdempotencyRecord{}, false, err
+	}
+	if affected == 1 {
+		return storage.IdempotencyRecord{}, true, nil
+	}
+
+	var existing storage.IdempotencyRecord
+	err = stmt(st.tx, st.stmts.getIdempotencyKey).QueryRow(rec.Key).
+		Scan(&existing.Key, &existing.Fingerprint, &existing.Status, &existing.ContentType, &existing.Body, &existing.CreatedAt, &existing.ExpiresAt)
+	if err != nil {
+		return storage.IdempotencyRecord{}, false, err
+	}
+	return existing, false, nil
+}
+
+func (st *Storage) CompleteIdempotencyKey(key string, status int, contentType string, body []byte, logger *slog.Logger) error {
+	logger.Info("op: storage.sqllite.CompleteIdempotencyKey")
+
+	_, err := stmt(st.tx, st.stmts.completeIdempotencyKey).Exec(status, contentType, body, key)
+	return err
+}
+
+func (st *Storage) ReleaseIdempotencyKey(key string, logger *slog.Logger) error {
+	logger.Info("op: storage.sqllite.ReleaseIdempotencyKey")
+
+	_, err := stmt(st.tx, st.stmts.releaseIdempotencyKey).Exec(key)
+	return err
+}
+
+func (st *Storage) PurgeIdempotencyKeys(now time.Time, logger *slog.Logger) (int64, error) {
+	logger.Info("op: storage.sqllite.PurgeIdempotencyKeys")
+
+	res, err := stmt(st.tx, st.stmts.purgeIdempotencyKeys).Exec(now.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,48 @@
+package sqllite
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+func (st *Storage) AcquireLease(name, holder string, now, expiresAt time.Time, logger *slog.Logger) (models.Lease, bool, error) {
+	logger.Info("op: storage.sqllite.AcquireLease")
+
+	res, err := stmt(st.tx, st.stmts.acquireLease).Exec(name, holder, now.UTC(), expiresAt.UTC())
+	if err != nil {
+		return models.Lease{}, false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return models.Lease{}, false, err
+	}
+
+	lease, err := st.GetLease(name, logger)
+	if err != nil {
+		return models.Lease{}, false, err
+	}
+	return lease, n > 0 && lease.Holder == holder, nil
+}
+
+func (st *Storage) ReleaseLease(name, holder string, logger *slog.Logger) error {
+	logger.Info("op: storage.sqllite.ReleaseLease")
+
+	_, err := stmt(st.tx, st.stmts.releaseLease).Exec(name, holder)
+	return err
+}
+
+func (st *Storage) GetLease(name string, logger *slog.Logger) (models.Lease, error) {
+	logger.Info("op: storage.sqllite.GetLease")
+
+	var lease models.Lease
+	err := stmt(st.tx, st.stmts.getLease).QueryRow(name).Scan(&lease.Name, &lease.Holder, &lease.AcquiredAt, &lease.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Lease{}, storage.ErrNotFound
+	}
+	return lease, err
+}
@@ -0,0 +1,150 @@
+package sqllite
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migrations are applied in order; the index of the last applied one is kept in PRAGMA user_version.
+var migrations = []string{
+	`
+	CREATE TABLE IF NOT EXISTS tasks(
+		id INTEGER PRIMARY KEY,
+		title TEXT,
+		description TEXT,
+		due_date DATETIME,
+		overdue BOOLEAN
+	)
+	`,
+	`
+	CREATE TABLE IF NOT EXISTS idempotency_keys(
+		key TEXT PRIMARY KEY,
+		fingerprint TEXT NOT NULL,
+		status INTEGER NOT NULL DEFAULT 0,
+		body BLOB,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at ON idempotency_keys(expires_at);
+	`,
+	`
+	ALTER TABLE tasks ADD COLUMN deleted_at DATETIME;
+	CREATE INDEX IF NOT EXISTS tasks_deleted_at ON tasks(deleted_at);
+	`,
+	`
+	CREATE TABLE IF NOT EXISTS task_events(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		at DATETIME NOT NULL,
+		changes TEXT NOT NULL,
+		before TEXT,
+		after TEXT
+	);
+	CREATE INDEX IF NOT EXISTS task_events_task_id ON task_events(task_id, id);
+	`,
+	`
+	ALTER TABLE task_events ADD COLUMN undone_event_id INTEGER;
+	`,
+	`
+	ALTER TABLE tasks ADD COLUMN external_id TEXT;
+	CREATE INDEX IF NOT EXISTS tasks_external_id ON tasks(external_id);
+	`,
+	`
+	CREATE TABLE IF NOT EXISTS calendar_feeds(
+		owner TEXT PRIMARY KEY,
+		token_hash TEXT NOT NULL UNIQUE,
+		components TEXT NOT NULL,
+		include_overdue BOOLEAN NOT NULL,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+	`,
+	`
+	CREATE TABLE IF NOT EXISTS reminders(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		lead_seconds INTEGER NOT NULL,
+		fire_at DATETIME,
+		sent_at DATETIME,
+		created_at DATETIME NOT NULL,
+		UNIQUE(task_id, lead_seconds)
+	);
+	CREATE INDEX IF NOT EXISTS reminders_pending ON reminders(fire_at) WHERE sent_at IS NULL;
+	`,
+	`
+	ALTER TABLE tasks ADD COLUMN due_kind TEXT;
+	ALTER TABLE tasks ADD COLUMN time_zone TEXT;
+	UPDATE tasks SET due_date = strftime('%Y-%m-%d %H:%M:%f+00:00', due_date)
+		WHERE due_date IS NOT NULL AND due_date NOT LIKE '%+00:00';
+	`,
+	`
+	CREATE TABLE IF NOT EXISTS leases(
+		name TEXT PRIMARY KEY,
+		holder TEXT NOT NULL,
+		acquired_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL
+	);
+	`,
+	`
+	CREATE TABLE IF NOT EXISTS jobs(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		dedupe_key TEXT,
+		state TEXT NOT NULL,
+		run_at DATETIME NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		max_attempts INTEGER NOT NULL,
+		lease_holder TEXT,
+		lease_expires_at DATETIME,
+		last_error TEXT,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		finished_at DATETIME
+	);
+	CREATE INDEX IF NOT EXISTS jobs_claimable ON jobs(state, run_at);
+	CREATE UNIQUE INDEX IF NOT EXISTS jobs_dedupe_key ON jobs(dedupe_key) WHERE state IN ('pending', 'running');
+	`,
+	`
+	ALTER TABLE idempotency_keys ADD COLUMN content_type TEXT NOT NULL DEFAULT '';
+	`,
+	`
+	UPDATE tasks SET external_id = NULL
+	WHERE deleted_at IS NULL AND external_id IS NOT NULL AND id NOT IN (
+		SELECT MIN(id) FROM tasks WHERE deleted_at IS NULL AND external_id IS NOT NULL GROUP BY external_id
+	);
+	DROP INDEX IF EXISTS tasks_external_id;
+	CREATE UNIQUE INDEX tasks_external_id ON tasks(external_id) WHERE deleted_at IS NULL;
+	`,
+}
+
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package sqllite
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+	"github.com/mattn/go-sqlite3"
+)
+
+func (st *Storage) EnqueueJob(job models.QueuedJob, logger *slog.Logger) (models.QueuedJob, bool, error) {
+	logger.Info("op: storage.sqllite.EnqueueJob")
+
+	payload := string(job.Payload)
+	if payload == "" {
+		payload = "null"
+	}
+	queued, err := scanJob(stmt(st.tx, st.stmts.enqueueJob).QueryRow(job.Kind, payload, nullString(job.DedupeKey), job.RunAt.UTC(),
+		job.MaxAttempts, job.CreatedAt.UTC(), job.CreatedAt.UTC()))
+	if errors.Is(err, sql.ErrNoRows) {
+		// The insert was skipped because the dedupe key is taken.
+		existing, err := scanJob(stmt(st.tx, st.stmts.getJobByDedupeKey).QueryRow(job.DedupeKey))
+		return existing, false, err
+	}
+	if err != nil {
+		return models.QueuedJob{}, false, err
+	}
+	return queued, true, nil
+}
+
+func (st *Storage) ClaimJobs(worker string, now, leaseUntil time.Time, limit int, logger *slog.Logger) ([]models.QueuedJob, error) {
+	logger.Info("op: storage.sqllite.ClaimJobs")
+
+	jobs, err := queryJobs(stmt(st.tx, st.stmts.claimJobs).Query(worker, leaseUntil.UTC(), now.UTC(), now.UTC(), now.UTC(), limit))
+	if err != nil {
+		return nil, err
+	}
+	// RETURNING does not keep the order of the subquery.
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].RunAt.Equal(jobs[j].RunAt) {
+			return jobs[i].RunAt.Before(jobs[j].RunAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+	return jobs, nil
+}
+
+func (st *Storage) FinishJob(job models.QueuedJob, worker string, logger *slog.Logger) error {
+	logger.Info("op: storage.sqllite.FinishJob")
+
+	res, err := stmt(st.tx, st.stmts.finishJob).Exec(job.State, job.RunAt.UTC(), job.Attempts, nullString(job.LastError),
+		utcOrNil(job.FinishedAt), job.UpdatedAt.UTC(), job.ID, worker)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res)
+}
+
+func (st *Storage) GetJob(id int64, logger *slog.Logger) (models.QueuedJob, error) {
+	logger.Info("op: storage.sqllite.GetJob")
+
+	job, err := scanJob(stmt(st.tx, st.stmts.getJob).QueryRow(id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.QueuedJob{}, storage.ErrNotFound
+	}
+	return job, err
+}
+
+func (st *Storage) ListJobs(filter models.QueuedJobFilter, logger *slog.Logger) ([]models.QueuedJob, error) {
+	logger.Info("op: storage.sqllite.ListJobs")
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	return queryJobs(stmt(st.tx, st.stmts.listJobs).Query(filter.State, filter.State, filter.Kind, filter.Kind, filter.AfterID, limit))
+}
+
+func (st *Storage) RetryJob(id int64, now time.Time, logger *slog.Logger) (models.QueuedJob, error) {
+	logger.Info("op: storage.sqllite.RetryJob")
+
+	job, err := scanJob(stmt(st.tx, st.stmts.retryJob).QueryRow(now.UTC(), now.UTC(), id))
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		// Another job with the same dedupe key is already queued.
+		return models.QueuedJob{}, storage.ErrJobState
+	}
+	return st.changedJob(id, job, err, logger)
+}
+
+func (st *Storage) CancelJob(id int64, now time.Time, logger *slog.Logger) (models.QueuedJob, error) {
+	logger.Info("op: storage.sqllite.CancelJob")
+
+	job, err := scanJob(stmt(st.tx, st.stmts.cancelJob).QueryRow(now.UTC(), now.UTC(), id))
+	return st.changedJob(id, job, err, logger)
+}
+
+func (st *Storage) PurgeJobs(before time.Time, logger *slog.Logger) (int64, error) {
+	logger.Info("op: storage.sqllite.PurgeJobs")
+
+	res, err := stmt(st.tx, st.stmts.purgeJobs).Exec(before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (st *Storage) changedJob(id int64, job models.QueuedJob, err error, logger *slog.Logger) (models.QueuedJob, error) {
+	if !errors.Is(err, sql.ErrNoRows) {
+		return job, err
+	}
+	if _, err := st.GetJob(id, logger); err != nil {
+		return models.QueuedJob{}, err
+	}
+	return models.QueuedJob{}, storage.ErrJobState
+}
+
+func scanJob(row rowScanner) (models.QueuedJob, error) {
+	var (
+		job         models.QueuedJob
+		payload     string
+		dedupeKey   sql.NullString
+		leaseHolder sql.NullString
+		lastError   sql.NullString
+	)
+	err := row.Scan(&job.ID, &job.Kind, &payload, &dedupeKey, &job.State, &job.RunAt, &job.Attempts, &job.MaxAttempts,
+		&leaseHolder, &job.LeaseExpiresAt, &lastError, &job.CreatedAt, &job.UpdatedAt, &job.FinishedAt)
+	job.Payload = []byte(payload)
+	job.DedupeKey = dedupeKey.String
+	job.LeaseHolder = leaseHolder.String
+	job.LastError = lastError.String
+	return job, err
+}
+
+func queryJobs(rows *sql.Rows, err error) ([]models.QueuedJob, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []models.QueuedJob
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
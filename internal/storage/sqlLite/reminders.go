@@ -0,0 +1,120 @@
+package sqllite
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+	"github.com/mattn/go-sqlite3"
+)
+
+func (st *Storage) CreateReminder(reminder models.Reminder, logger *slog.Logger) (models.Reminder, error) {
+	logger.Info("op: storage.sqllite.CreateReminder")
+
+	res, err := stmt(st.tx, st.stmts.createReminder).Exec(reminder.TaskID, reminder.LeadSeconds, utcOrNil(reminder.FireAt),
+		utcOrNil(reminder.SentAt), reminder.CreatedAt.UTC())
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return models.Reminder{}, storage.ErrReminderExists
+		}
+		return models.Reminder{}, err
+	}
+
+	reminder.ID, err = res.LastInsertId()
+	if err != nil {
+		return models.Reminder{}, err
+	}
+	return reminder, nil
+}
+
+func (st *Storage) UpdateReminder(reminder models.Reminder, logger *slog.Logger) error {
+	logger.Info("op: storage.sqllite.UpdateReminder")
+
+	res, err := stmt(st.tx, st.stmts.updateReminder).Exec(utcOrNil(reminder.FireAt), utcOrNil(reminder.SentAt), reminder.ID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res)
+}
+
+func (st *Storage) ClaimReminder(id int64, sentAt time.Time, logger *slog.Logger) (bool, error) {
+	logger.Info("op: storage.sqllite.ClaimReminder")
+
+	res, err := stmt(st.tx, st.stmts.claimReminder).Exec(sentAt.UTC(), id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (st *Storage) ReleaseReminder(id int64, sentAt time.Time, logger *slog.Logger) error {
+	logger.Info("op: storage.sqllite.ReleaseReminder")
+
+	_, err := stmt(st.tx, st.stmts.releaseReminder).Exec(id, sentAt.UTC())
+	return err
+}
+
+func (st *Storage) DeleteReminder(id int64, logger *slog.Logger) error {
+	logger.Info("op: storage.sqllite.DeleteReminder")
+
+	res, err := stmt(st.tx, st.stmts.deleteReminder).Exec(id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res)
+}
+
+func (st *Storage) GetTaskReminders(taskID int64, logger *slog.Logger) ([]models.Reminder, error) {
+	logger.Info("op: storage.sqllite.GetTaskReminders")
+
+	return queryReminders(stmt(st.tx, st.stmts.getTaskReminders).Query(taskID))
+}
+
+func (st *Storage) GetDueReminders(before time.Time, logger *slog.Logger) ([]models.Reminder, error) {
+	logger.Info("op: storage.sqllite.GetDueReminders")
+
+	return queryReminders(stmt(st.tx, st.stmts.getDueReminders).Query(before.UTC()))
+}
+
+func queryReminders(rows *sql.Rows, err error) ([]models.Reminder, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []models.Reminder
+	for rows.Next() {
+		var r models.Reminder
+		if err := rows.Scan(&r.ID, &r.TaskID, &r.LeadSeconds, &r.FireAt, &r.SentAt, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, r)
+	}
+	return reminders, rows.Err()
+}
+
+func utcOrNil(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+func requireAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
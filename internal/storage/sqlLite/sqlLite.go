@@ -2,98 +2,296 @@ package sqllite
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/gintokos/tasksrestapi/internal/domain/models"
 	"github.com/gintokos/tasksrestapi/internal/lib/id"
 	"github.com/gintokos/tasksrestapi/internal/storage"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	maxCreateAttempts = 5
+
+	memoryPath = ":memory:"
 )
 
 type Storage struct {
-	db *sql.DB
+	storage.ClockSource
+
+	db    *sql.DB
+	stmts *statements
+	idgen id.Generator
+	// tx is set on the view that WithinTx hands out.
+	tx *sql.Tx
 }
 
-func NewStorage(storagepath string) (*Storage, error) {
-	db, err := sql.Open("sqlite3", storagepath)
+type Options struct {
+	JournalMode     string
+	Synchronous     string
+	BusyTimeout     time.Duration
+	ForeignKeys     bool
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+func NewStorage(storagepath string, idgen id.Generator, opts Options) (*Storage, error) {
+	db, err := sql.Open("sqlite3", dsn(storagepath, opts))
 	if err != nil {
 		return nil, err
 	}
+	if storagepath == memoryPath {
+		// Every connection would open its own empty database, so keep exactly one for good.
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		db.SetConnMaxLifetime(0)
+	} else {
+		db.SetMaxOpenConns(opts.MaxOpenConns)
+		if opts.MaxIdleConns > 0 {
+			db.SetMaxIdleConns(opts.MaxIdleConns)
+		}
+		db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
 
-	query, err := db.Prepare(`
-	CREATE TABLE IF NOT EXISTS tasks(
-		id INTEGER PRIMARY KEY,
-		title TEXT,
-		description TEXT,
-		due_date DATETIME,
-		overdue BOOLEAN
-	)
-	`)
-	if err != nil {
+	if err := migrate(db); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	_, err = query.Exec()
+	stmts, err := prepareStatements(db)
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
 	return &Storage{
-		db: db,
+		db:    db,
+		stmts: stmts,
+		idgen: idgen,
 	}, nil
 }
 
+// dsn passes the pragmas as parameters so every pooled connection gets them.
+func dsn(storagepath string, opts Options) string {
+	params := url.Values{}
+	if opts.JournalMode != "" {
+		params.Set("_journal_mode", opts.JournalMode)
+	}
+	if opts.Synchronous != "" {
+		params.Set("_synchronous", opts.Synchronous)
+	}
+	if opts.BusyTimeout > 0 {
+		params.Set("_busy_timeout", strconv.FormatInt(opts.BusyTimeout.Milliseconds(), 10))
+	}
+	if opts.ForeignKeys {
+		params.Set("_foreign_keys", "1")
+	}
+	// Writers take the lock at BEGIN instead of failing on upgrade in the middle of a transaction.
+	params.Set("_txlock", "immediate")
+
+	return fileURI(storagepath, params)
+}
+
+// fileURI escapes path so characters such as '?' and '#' stay part of the file name.
+func fileURI(path string, params url.Values) string {
+	u := url.URL{Scheme: "file", Path: path, OmitHost: true, RawQuery: params.Encode()}
+	if path == memoryPath {
+		u = url.URL{Scheme: "file", Opaque: memoryPath, RawQuery: params.Encode()}
+	}
+	return u.String()
+}
+
+func (st *Storage) Close() error {
+	return errors.Join(st.stmts.close(), st.db.Close())
+}
+
 func (st *Storage) GetAllTasks(logger *slog.Logger) ([]models.Task, error) {
 	logger.Info("op: storage.sqllite.GetAllTasks")
 
-	rows, err := st.db.Query("SELECT id, title, description, due_date, overdue FROM tasks")
-	if err != nil {
-		return nil, err
+	return queryTasks(stmt(st.tx, st.stmts.getAllTasks))
+}
+
+func (st *Storage) GetTask(id int64, logger *slog.Logger) (models.Task, error) {
+	logger.Info("op: storage.sqllite.GetTask")
+
+	task, err := scanTask(stmt(st.tx, st.stmts.getTask).QueryRow(id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Task{}, storage.ErrNotFound
 	}
-	defer rows.Close()
+	return task, err
+}
 
-	var tasks []models.Task
+func (st *Storage) CreateTask(task models.Task, logger *slog.Logger) (models.Task, error) {
+	logger.Info("op: storage.sqllite.CreateTask")
 
-	for rows.Next() {
-		var task models.Task
-		if err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.DueDate, &task.OverDue); err != nil {
-			return nil, err
+	return st.createTask(st.tx, task)
+}
+
+func (st *Storage) UpdateTask(task models.Task, logger *slog.Logger) (models.Task, error) {
+	logger.Info("op: storage.sqllite.UpdateTask")
+
+	return st.updateTask(st.tx, task)
+}
+
+func (st *Storage) DeleteTask(id int64, logger *slog.Logger) error {
+	logger.Info("op: storage.sqllite.DeleteTask")
+
+	return st.deleteTask(st.tx, id)
+}
+
+func (st *Storage) ApplyBatch(ops []models.BatchOperation, atomic bool, logger *slog.Logger) ([]storage.BatchResult, error) {
+	logger.Info("op: storage.sqllite.ApplyBatch")
+
+	results := make([]storage.BatchResult, len(ops))
+	err := st.transact(func(tx *sql.Tx) error {
+		failed := false
+		for i, op := range ops {
+			if _, err := tx.Exec("SAVEPOINT batch_item"); err != nil {
+				return err
+			}
+
+			results[i] = st.applyOperation(tx, op)
+			if results[i].Err != nil {
+				failed = true
+				if _, err := tx.Exec("ROLLBACK TO batch_item"); err != nil {
+					return err
+				}
+				if atomic {
+					break
+				}
+			}
+
+			if _, err := tx.Exec("RELEASE batch_item"); err != nil {
+				return err
+			}
 		}
-		tasks = append(tasks, task)
+
+		if atomic && failed {
+			storage.MarkRolledBack(results)
+			return errRollback
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errRollback) {
+		return nil, err
 	}
+	return results, nil
+}
 
-	return tasks, nil
+func (st *Storage) WithinTx(fn func(tx storage.Storage) error, logger *slog.Logger) error {
+	logger.Info("op: storage.sqllite.WithinTx")
+
+	if st.tx != nil {
+		return fn(st)
+	}
+	return st.transact(func(tx *sql.Tx) error {
+		view := *st
+		view.tx = tx
+		return fn(&view)
+	})
 }
 
-func (st *Storage) CreateTask(task models.Task, logger *slog.Logger) (models.Task, error) {
-	logger.Info("op: storage.sqllite.CreateTask")
+// errRollback makes transact undo the writes of fn without failing.
+var errRollback = errors.New("rollback")
 
-	task.ID = id.GenerateRandomID()
+// transact runs fn in a new transaction, or in a savepoint when st is already bound to one.
+func (st *Storage) transact(fn func(tx *sql.Tx) error) error {
+	if st.tx != nil {
+		if _, err := st.tx.Exec("SAVEPOINT transact"); err != nil {
+			return err
+		}
+		if err := fn(st.tx); err != nil {
+			_, rbErr := st.tx.Exec("ROLLBACK TO transact")
+			_, relErr := st.tx.Exec("RELEASE transact")
+			return errors.Join(err, rbErr, relErr)
+		}
+		_, err := st.tx.Exec("RELEASE transact")
+		return err
+	}
 
-	stmt, err := st.db.Prepare("INSERT INTO tasks (id, title, description, due_date, overdue) VALUES (?, ?, ?, ?, ?)")
+	tx, err := st.db.Begin()
 	if err != nil {
-		return models.Task{}, err
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
 	}
-	defer stmt.Close()
+	return tx.Commit()
+}
+
+func stmt(tx *sql.Tx, s *sql.Stmt) *sql.Stmt {
+	if tx == nil {
+		return s
+	}
+	return tx.Stmt(s)
+}
 
+func (st *Storage) applyOperation(tx *sql.Tx, op models.BatchOperation) storage.BatchResult {
+	switch op.Op {
+	case models.BatchCreate:
+		task, err := st.createTask(tx, op.Task)
+		return storage.BatchResult{Task: task, Err: err}
+	case models.BatchUpdate:
+		task, err := st.updateTask(tx, op.Task)
+		return storage.BatchResult{Task: task, Err: err}
+	case models.BatchDelete:
+		return storage.BatchResult{Task: models.Task{ID: op.Task.ID}, Err: st.deleteTask(tx, op.Task.ID)}
+	default:
+		return storage.BatchResult{Err: storage.ErrUnknownOperation}
+	}
+}
+
+func (st *Storage) createTask(tx *sql.Tx, task models.Task) (models.Task, error) {
 	var dueDate interface{}
 	if task.DueDate != nil {
-		dueDate = *task.DueDate
+		dueDate = task.DueDate.UTC()
 	} else {
 		dueDate = nil
 	}
 
-	_, err = stmt.Exec(task.ID, task.Title, task.Description, dueDate, task.OverDue)
-	if err != nil {
-		return models.Task{}, err
+	var err error
+	for attempt := 0; attempt < maxCreateAttempts; attempt++ {
+		task.ID, err = st.idgen.NextID()
+		if err != nil {
+			return models.Task{}, err
+		}
+
+		_, err = stmt(tx, st.stmts.insertTask).Exec(task.ID, nullString(task.ExternalID), task.Title, task.Description, dueDate,
+			nullString(string(task.DueKind)), nullString(task.TimeZone), task.OverDue)
+		if err == nil {
+			return task, nil
+		}
+		if isUniqueConflict(err) {
+			return models.Task{}, storage.ErrExternalIDTaken
+		}
+		if !isPrimaryKeyConflict(err) {
+			return models.Task{}, err
+		}
 	}
 
-	return task, nil
+	return models.Task{}, fmt.Errorf("%w after %d attempts", storage.ErrIDConflict, maxCreateAttempts)
 }
 
-func (st *Storage) UpdateTask(task models.Task, logger *slog.Logger) (models.Task, error) {
-	logger.Info("op: storage.sqllite.UpdateTask")
+func isPrimaryKeyConflict(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+}
+
+// isUniqueConflict reports a clash on tasks_external_id, the only unique index on tasks besides the key.
+func isUniqueConflict(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
 
-	exists, err := st.isExistsByID(task.ID)
+func (st *Storage) updateTask(tx *sql.Tx, task models.Task) (models.Task, error) {
+	exists, err := st.isExistsByID(tx, task.ID)
 	if err != nil {
 		return models.Task{}, err
 	}
@@ -101,20 +299,18 @@ func (st *Storage) UpdateTask(task models.Task, logger *slog.Logger) (models.Tas
 		return models.Task{}, storage.ErrNotFound
 	}
 
-	stmt, err := st.db.Prepare("UPDATE tasks SET title = ?, description = ?, due_date = ?, overdue = ? WHERE id = ?")
-	if err != nil {
-		return models.Task{}, err
-	}
-	defer stmt.Close()
-
 	var dueDate interface{}
 	if task.DueDate != nil {
-		dueDate = *task.DueDate
+		dueDate = task.DueDate.UTC()
 	} else {
 		dueDate = nil
 	}
 
-	_, err = stmt.Exec(task.Title, task.Description, dueDate, task.OverDue, task.ID)
+	_, err = stmt(tx, st.stmts.updateTask).Exec(nullString(task.ExternalID), task.Title, task.Description, dueDate,
+		nullString(string(task.DueKind)), nullString(task.TimeZone), task.OverDue, task.ID)
+	if isUniqueConflict(err) {
+		return models.Task{}, storage.ErrExternalIDTaken
+	}
 	if err != nil {
 		return models.Task{}, err
 	}
@@ -122,10 +318,8 @@ func (st *Storage) UpdateTask(task models.Task, logger *slog.Logger) (models.Tas
 	return task, nil
 }
 
-func (st *Storage) DeleteTask(id int64, logger *slog.Logger) error {
-	logger.Info("op: storage.sqllite.DeleteTask")
-
-	exists, err := st.isExistsByID(id)
+func (st *Storage) deleteTask(tx *sql.Tx, id int64) error {
+	exists, err := st.isExistsByID(tx, id)
 	if err != nil {
 		return err
 	}
@@ -133,13 +327,7 @@ func (st *Storage) DeleteTask(id int64, logger *slog.Logger) error {
 		return storage.ErrNotFound
 	}
 
-	stmt, err := st.db.Prepare("DELETE FROM tasks WHERE id = ?")
-	if err != nil {
-		return  err
-	}
-	defer stmt.Close()
-
-	_, err = stmt.Exec(id)
+	_, err = stmt(tx, st.stmts.softDeleteTask).Exec(st.Clock().Now().UTC(), id)
 	if err != nil {
 		return err
 	}
@@ -147,11 +335,53 @@ func (st *Storage) DeleteTask(id int64, logger *slog.Logger) error {
 	return nil
 }
 
-func (st *Storage) isExistsByID(id int64) (bool, error) {
+func (st *Storage) isExistsByID(tx *sql.Tx, id int64) (bool, error) {
 	var exists bool
-	err := st.db.QueryRow("SELECT EXISTS(SELECT 1 FROM tasks WHERE id = ?)", id).Scan(&exists)
+	err := stmt(tx, st.stmts.taskExists).QueryRow(id).Scan(&exists)
 	if err != nil {
 		return false, err
 	}
 	return exists, nil
 }
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTask(row rowScanner) (models.Task, error) {
+	var (
+		task       models.Task
+		externalID sql.NullString
+		dueKind    sql.NullString
+		timeZone   sql.NullString
+	)
+	err := row.Scan(&task.ID, &externalID, &task.Title, &task.Description, &task.DueDate, &dueKind, &timeZone, &task.OverDue, &task.DeletedAt)
+	task.ExternalID = externalID.String
+	task.DueKind = models.DueKind(dueKind.String)
+	task.TimeZone = timeZone.String
+	return task, err
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func queryTasks(s *sql.Stmt, args ...interface{}) ([]models.Task, error) {
+	rows, err := s.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
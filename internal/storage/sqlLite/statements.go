@@ -0,0 +1,207 @@
+package sqllite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const (
+	taskColumns     = "id, external_id, title, description, due_date, due_kind, time_zone, overdue, deleted_at"
+	reminderColumns = "id, task_id, lead_seconds, fire_at, sent_at, created_at"
+	feedColumns     = "owner, token_hash, components, include_overdue, created_at, updated_at"
+	eventColumns    = "id, task_id, type, actor, at, changes, before, after, undone_event_id"
+	leaseColumns    = "name, holder, acquired_at, expires_at"
+	jobColumns      = "id, kind, payload, dedupe_key, state, run_at, attempts, max_attempts, lease_holder, lease_expires_at, last_error, created_at, updated_at, finished_at"
+)
+
+type statements struct {
+	getAllTasks    *sql.Stmt
+	getTask        *sql.Stmt
+	taskExists     *sql.Stmt
+	insertTask     *sql.Stmt
+	updateTask     *sql.Stmt
+	softDeleteTask *sql.Stmt
+
+	getTrash    *sql.Stmt
+	restoreTask *sql.Stmt
+	purgeTask   *sql.Stmt
+	purgeTrash  *sql.Stmt
+
+	appendEvent   *sql.Stmt
+	getTaskEvents *sql.Stmt
+	getEvent      *sql.Stmt
+	lastEventAt   *sql.Stmt
+
+	getFeedByOwner *sql.Stmt
+	getFeedByToken *sql.Stmt
+	saveFeed       *sql.Stmt
+	deleteFeed     *sql.Stmt
+
+	createReminder   *sql.Stmt
+	updateReminder   *sql.Stmt
+	claimReminder    *sql.Stmt
+	releaseReminder  *sql.Stmt
+	deleteReminder   *sql.Stmt
+	getTaskReminders *sql.Stmt
+	getDueReminders  *sql.Stmt
+
+	acquireLease *sql.Stmt
+	releaseLease *sql.Stmt
+	getLease     *sql.Stmt
+
+	enqueueJob        *sql.Stmt
+	getJobByDedupeKey *sql.Stmt
+	claimJobs         *sql.Stmt
+	finishJob         *sql.Stmt
+	getJob            *sql.Stmt
+	listJobs          *sql.Stmt
+	retryJob          *sql.Stmt
+	cancelJob         *sql.Stmt
+	purgeJobs         *sql.Stmt
+
+	reserveIdempotencyKey  *sql.Stmt
+	getIdempotencyKey      *sql.Stmt
+	completeIdempotencyKey *sql.Stmt
+	releaseIdempotencyKey  *sql.Stmt
+	purgeIdempotencyKeys   *sql.Stmt
+}
+
+func prepareStatements(db *sql.DB) (*statements, error) {
+	s := &statements{}
+	queries := []struct {
+		dst   **sql.Stmt
+		query string
+	}{
+		{&s.getAllTasks, "SELECT " + taskColumns + " FROM tasks WHERE deleted_at IS NULL ORDER BY id"},
+		{&s.getTask, "SELECT " + taskColumns + " FROM tasks WHERE id = ?"},
+		{&s.taskExists, "SELECT EXISTS(SELECT 1 FROM tasks WHERE id = ? AND deleted_at IS NULL)"},
+		{&s.insertTask, "INSERT INTO tasks (id, external_id, title, description, due_date, due_kind, time_zone, overdue) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"},
+		{&s.updateTask, "UPDATE tasks SET external_id = ?, title = ?, description = ?, due_date = ?, due_kind = ?, time_zone = ?, overdue = ? WHERE id = ?"},
+		{&s.softDeleteTask, "UPDATE tasks SET deleted_at = ? WHERE id = ?"},
+
+		{&s.getTrash, "SELECT " + taskColumns + " FROM tasks WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC"},
+		{&s.restoreTask, "UPDATE tasks SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL"},
+		{&s.purgeTask, "DELETE FROM tasks WHERE id = ? AND deleted_at IS NOT NULL"},
+		{&s.purgeTrash, "DELETE FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at <= ? RETURNING id"},
+
+		{&s.appendEvent, "INSERT INTO task_events (task_id, type, actor, at, changes, before, after, undone_event_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"},
+		{&s.getTaskEvents, "SELECT " + eventColumns + " FROM task_events WHERE task_id = ? ORDER BY id"},
+		{&s.getEvent, "SELECT " + eventColumns + " FROM task_events WHERE id = ?"},
+		{&s.lastEventAt, "SELECT at FROM task_events ORDER BY id DESC LIMIT 1"},
+
+		{&s.getFeedByOwner, "SELECT " + feedColumns + " FROM calendar_feeds WHERE owner = ?"},
+		{&s.getFeedByToken, "SELECT " + feedColumns + " FROM calendar_feeds WHERE token_hash = ?"},
+		{&s.saveFeed, `
+		INSERT INTO calendar_feeds (` + feedColumns + `) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(owner) DO UPDATE SET
+			token_hash = excluded.token_hash,
+			components = excluded.components,
+			include_overdue = excluded.include_overdue,
+			updated_at = excluded.updated_at
+		`},
+		{&s.deleteFeed, "DELETE FROM calendar_feeds WHERE owner = ?"},
+
+		{&s.createReminder, "INSERT INTO reminders (task_id, lead_seconds, fire_at, sent_at, created_at) VALUES (?, ?, ?, ?, ?)"},
+		{&s.updateReminder, "UPDATE reminders SET fire_at = ?, sent_at = ? WHERE id = ?"},
+		{&s.claimReminder, "UPDATE reminders SET sent_at = ? WHERE id = ? AND sent_at IS NULL"},
+		{&s.releaseReminder, "UPDATE reminders SET sent_at = NULL WHERE id = ? AND sent_at = ?"},
+		{&s.deleteReminder, "DELETE FROM reminders WHERE id = ?"},
+		{&s.getTaskReminders, "SELECT " + reminderColumns + " FROM reminders WHERE task_id = ? ORDER BY lead_seconds DESC"},
+		{&s.getDueReminders, "SELECT " + reminderColumns + " FROM reminders WHERE sent_at IS NULL AND fire_at IS NOT NULL AND fire_at <= ? ORDER BY fire_at"},
+
+		// A lease changes hands only once it has expired; renewing keeps the original acquired_at.
+		{&s.acquireLease, `
+		INSERT INTO leases (` + leaseColumns + `) VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			holder = excluded.holder,
+			acquired_at = CASE WHEN leases.holder = excluded.holder THEN leases.acquired_at ELSE excluded.acquired_at END,
+			expires_at = excluded.expires_at
+		WHERE leases.holder = excluded.holder OR leases.expires_at <= excluded.acquired_at
+		`},
+		{&s.releaseLease, "DELETE FROM leases WHERE name = ? AND holder = ?"},
+		{&s.getLease, "SELECT " + leaseColumns + " FROM leases WHERE name = ?"},
+
+		{&s.enqueueJob, `
+		INSERT INTO jobs (kind, payload, dedupe_key, state, run_at, attempts, max_attempts, created_at, updated_at)
+		VALUES (?, ?, ?, 'pending', ?, 0, ?, ?, ?)
+		ON CONFLICT(dedupe_key) WHERE state IN ('pending', 'running') DO NOTHING
+		RETURNING ` + jobColumns},
+		{&s.getJobByDedupeKey, "SELECT " + jobColumns + " FROM jobs WHERE dedupe_key = ? AND state IN ('pending', 'running')"},
+		// Claiming in a single statement keeps two workers from taking the same job.
+		{&s.claimJobs, `
+		UPDATE jobs SET state = 'running', attempts = attempts + 1, lease_holder = ?, lease_expires_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE (state = 'pending' AND run_at <= ?) OR (state = 'running' AND lease_expires_at <= ?)
+			ORDER BY run_at, id
+			LIMIT ?
+		)
+		RETURNING ` + jobColumns},
+		{&s.finishJob, `
+		UPDATE jobs SET state = ?, run_at = ?, attempts = ?, last_error = ?, finished_at = ?, updated_at = ?,
+			lease_holder = NULL, lease_expires_at = NULL
+		WHERE id = ? AND state = 'running' AND lease_holder = ?
+		`},
+		{&s.getJob, "SELECT " + jobColumns + " FROM jobs WHERE id = ?"},
+		{&s.listJobs, `
+		SELECT ` + jobColumns + ` FROM jobs
+		WHERE (? = '' OR state = ?) AND (? = '' OR kind = ?) AND id > ?
+		ORDER BY id
+		LIMIT ?
+		`},
+		{&s.retryJob, `
+		UPDATE jobs SET state = 'pending', run_at = ?, attempts = 0, finished_at = NULL, updated_at = ?
+		WHERE id = ? AND state IN ('dead', 'cancelled')
+		RETURNING ` + jobColumns},
+		{&s.cancelJob, `
+		UPDATE jobs SET state = 'cancelled', finished_at = ?, updated_at = ?, lease_holder = NULL, lease_expires_at = NULL
+		WHERE id = ? AND state IN ('pending', 'running', 'dead')
+		RETURNING ` + jobColumns},
+		{&s.purgeJobs, "DELETE FROM jobs WHERE state IN ('done', 'cancelled') AND finished_at <= ?"},
+
+		{&s.reserveIdempotencyKey, `
+		INSERT INTO idempotency_keys (key, fingerprint, status, body, created_at, expires_at) VALUES (?, ?, 0, NULL, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET
+			fingerprint = excluded.fingerprint,
+			status = 0,
+			body = NULL,
+			created_at = excluded.created_at,
+			expires_at = excluded.expires_at
+		WHERE idempotency_keys.expires_at <= excluded.created_at
+		`},
+		{&s.getIdempotencyKey, "SELECT key, fingerprint, status, content_type, body, created_at, expires_at FROM idempotency_keys WHERE key = ?"},
+		{&s.completeIdempotencyKey, "UPDATE idempotency_keys SET status = ?, content_type = ?, body = ? WHERE key = ?"},
+		{&s.releaseIdempotencyKey, "DELETE FROM idempotency_keys WHERE key = ? AND status = 0"},
+		{&s.purgeIdempotencyKeys, "DELETE FROM idempotency_keys WHERE expires_at <= ?"},
+	}
+
+	for _, q := range queries {
+		stmt, err := db.Prepare(q.query)
+		if err != nil {
+			s.close()
+			return nil, fmt.Errorf("preparing %q: %w", q.query, err)
+		}
+		*q.dst = stmt
+	}
+	return s, nil
+}
+
+func (s *statements) close() error {
+	var errs []error
+	for _, stmt := range []*sql.Stmt{
+		s.getAllTasks, s.getTask, s.taskExists, s.insertTask, s.updateTask, s.softDeleteTask,
+		s.getTrash, s.restoreTask, s.purgeTask, s.purgeTrash,
+		s.appendEvent, s.getTaskEvents, s.getEvent, s.lastEventAt,
+		s.getFeedByOwner, s.getFeedByToken, s.saveFeed, s.deleteFeed,
+		s.createReminder, s.updateReminder, s.claimReminder, s.releaseReminder, s.deleteReminder, s.getTaskReminders, s.getDueReminders,
+		s.acquireLease, s.releaseLease, s.getLease,
+		s.enqueueJob, s.getJobByDedupeKey, s.claimJobs, s.finishJob, s.getJob, s.listJobs, s.retryJob, s.cancelJob, s.purgeJobs,
+		s.reserveIdempotencyKey, s.getIdempotencyKey, s.completeIdempotencyKey, s.releaseIdempotencyKey, s.purgeIdempotencyKeys,
+	} {
+		if stmt != nil {
+			errs = append(errs, stmt.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,169 @@
+package sqllite_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+)
+
+func TestBackup_WhileWriting(t *testing.T) {
+	logger := slog.Default()
+	st := newStorage(t, tunedOptions)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if _, err := st.CreateTask(models.Task{Title: "concurrent"}, logger); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	path := filepath.Join(t.TempDir(), "backup.db")
+	err := st.Backup(path, logger)
+	close(stop)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("error on backup: %v", err)
+	}
+
+	if err := sqllite.ValidateBackup(path); err != nil {
+		t.Fatalf("expected valid backup, got %v", err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	logger := slog.Default()
+	dir := t.TempDir()
+	storagepath := filepath.Join(dir, "storage.db")
+
+	st, err := sqllite.NewStorage(storagepath, id.NewRandomGenerator(), tunedOptions)
+	if err != nil {
+		t.Fatalf("error on creating storage: %v", err)
+	}
+	kept, _ := st.CreateTask(models.Task{Title: "Kept"}, logger)
+
+	backupPath := filepath.Join(dir, "backup.db")
+	if err := st.Backup(backupPath, logger); err != nil {
+		t.Fatalf("error on backup: %v", err)
+	}
+	st.CreateTask(models.Task{Title: "Lost"}, logger)
+	st.Close()
+
+	if err := sqllite.Restore(backupPath, storagepath); err != nil {
+		t.Fatalf("error on restore: %v", err)
+	}
+
+	st, err = sqllite.NewStorage(storagepath, id.NewRandomGenerator(), tunedOptions)
+	if err != nil {
+		t.Fatalf("error on reopening storage: %v", err)
+	}
+	defer st.Close()
+
+	tasks, err := st.GetAllTasks(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != kept.ID {
+		t.Fatalf("expected only the backed up task, got %+v", tasks)
+	}
+}
+
+func TestRestore_RejectsNewerSchema(t *testing.T) {
+	logger := slog.Default()
+	dir := t.TempDir()
+	st := newStorage(t, sqllite.Options{})
+
+	backupPath := filepath.Join(dir, "backup.db")
+	if err := st.Backup(backupPath, logger); err != nil {
+		t.Fatalf("error on backup: %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	version := userVersion(t, db)
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+		t.Fatal(err)
+	}
+
+	err = sqllite.Restore(backupPath, filepath.Join(dir, "storage.db"))
+	if !errors.Is(err, sqllite.ErrSchemaVersion) {
+		t.Fatalf("expected ErrSchemaVersion, got %v", err)
+	}
+}
+
+func TestRestore_MigratesOlderSchema(t *testing.T) {
+	logger := slog.Default()
+	dir := t.TempDir()
+	st := newStorage(t, sqllite.Options{})
+	kept, _ := st.CreateTask(models.Task{Title: "Kept"}, logger)
+
+	backupPath := filepath.Join(dir, "backup.db")
+	if err := st.Backup(backupPath, logger); err != nil {
+		t.Fatalf("error on backup: %v", err)
+	}
+
+	// The latest migration can run again, so stepping the version back stands in for an older backup.
+	db, err := sql.Open("sqlite3", backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := userVersion(t, db)
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", version-1)); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	storagepath := filepath.Join(dir, "storage.db")
+	if err := sqllite.Restore(backupPath, storagepath); err != nil {
+		t.Fatalf("error on restore: %v", err)
+	}
+
+	db, err = sql.Open("sqlite3", storagepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if got := userVersion(t, db); got != version {
+		t.Fatalf("expected the restored storage migrated to version %d, got %d", version, got)
+	}
+
+	restored, err := sqllite.NewStorage(storagepath, id.NewRandomGenerator(), sqllite.Options{})
+	if err != nil {
+		t.Fatalf("error on reopening storage: %v", err)
+	}
+	defer restored.Close()
+	if _, err := restored.GetTask(kept.ID, logger); err != nil {
+		t.Fatalf("expected the backed up task, got %v", err)
+	}
+}
+
+func userVersion(t *testing.T, db *sql.DB) int {
+	t.Helper()
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	return version
+}
@@ -0,0 +1,80 @@
+package sqllite_test
+
+import (
+	"io"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+)
+
+var benchOptions = []struct {
+	name string
+	opts sqllite.Options
+}{
+	{"default", sqllite.Options{}},
+	{"tuned", tunedOptions},
+}
+
+func BenchmarkCreateTaskParallel(b *testing.B) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, bo := range benchOptions {
+		b.Run(bo.name, func(b *testing.B) {
+			st := newStorage(b, bo.opts)
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := st.CreateTask(models.Task{Title: "bench"}, logger); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkMixedParallel runs nine reads per write, roughly what the API sees with the Checker running.
+func BenchmarkMixedParallel(b *testing.B) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, bo := range benchOptions {
+		b.Run(bo.name, func(b *testing.B) {
+			st := newStorage(b, bo.opts)
+
+			ids := make([]int64, 100)
+			for i := range ids {
+				task, err := st.CreateTask(models.Task{Title: "seed " + strconv.Itoa(i)}, logger)
+				if err != nil {
+					b.Fatal(err)
+				}
+				ids[i] = task.ID
+			}
+
+			var n atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := n.Add(1)
+					id := ids[i%int64(len(ids))]
+
+					var err error
+					if i%10 == 0 {
+						_, err = st.UpdateTask(models.Task{ID: id, Title: "updated " + strconv.FormatInt(i, 10)}, logger)
+					} else {
+						_, err = st.GetTask(id, logger)
+					}
+					if err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package sqllite_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+	"github.com/gintokos/tasksrestapi/internal/storage/storagetest"
+)
+
+var tunedOptions = sqllite.Options{
+	JournalMode:  "WAL",
+	Synchronous:  "NORMAL",
+	BusyTimeout:  5 * time.Second,
+	ForeignKeys:  true,
+	MaxOpenConns: 8,
+	MaxIdleConns: 8,
+}
+
+func newStorage(tb testing.TB, opts sqllite.Options) *sqllite.Storage {
+	st, err := sqllite.NewStorage(filepath.Join(tb.TempDir(), "storage.db"), id.NewRandomGenerator(), opts)
+	if err != nil {
+		tb.Fatalf("error on creating storage: %v", err)
+	}
+	tb.Cleanup(func() { st.Close() })
+	return st
+}
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return newStorage(t, sqllite.Options{})
+	})
+}
+
+func TestConformance_Tuned(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return newStorage(t, tunedOptions)
+	})
+}
@@ -0,0 +1,62 @@
+package sqllite_test
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/storage"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+)
+
+func TestLeases(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	st := newStorage(t, sqllite.Options{})
+	now := time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC)
+	ttl := 15 * time.Second
+
+	if _, err := st.GetLease("checker", logger); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound before anyone took the lease, got %v", err)
+	}
+
+	lease, acquired, err := st.AcquireLease("checker", "a", now, now.Add(ttl), logger)
+	if err != nil || !acquired || lease.Holder != "a" {
+		t.Fatalf("expected a to take the free lease, got %+v acquired=%v err=%v", lease, acquired, err)
+	}
+
+	lease, acquired, err = st.AcquireLease("checker", "b", now.Add(5*time.Second), now.Add(5*time.Second+ttl), logger)
+	if err != nil || acquired || lease.Holder != "a" {
+		t.Fatalf("expected b to see a holding the lease, got %+v acquired=%v err=%v", lease, acquired, err)
+	}
+
+	renewedAt := now.Add(10 * time.Second)
+	lease, acquired, err = st.AcquireLease("checker", "a", renewedAt, renewedAt.Add(ttl), logger)
+	if err != nil || !acquired {
+		t.Fatalf("expected a to renew, got acquired=%v err=%v", acquired, err)
+	}
+	if !lease.AcquiredAt.Equal(now) || !lease.ExpiresAt.Equal(renewedAt.Add(ttl)) {
+		t.Fatalf("expected renewal to extend the lease and keep acquiredAt, got %+v", lease)
+	}
+
+	// a stops renewing; b takes over once the lease has run out.
+	expired := renewedAt.Add(ttl)
+	lease, acquired, err = st.AcquireLease("checker", "b", expired, expired.Add(ttl), logger)
+	if err != nil || !acquired || lease.Holder != "b" || !lease.AcquiredAt.Equal(expired) {
+		t.Fatalf("expected b to take the expired lease, got %+v acquired=%v err=%v", lease, acquired, err)
+	}
+
+	if err := st.ReleaseLease("checker", "a", logger); err != nil {
+		t.Fatal(err)
+	}
+	if lease, _ := st.GetLease("checker", logger); lease.Holder != "b" {
+		t.Fatalf("expected a stale release not to touch b's lease, got %+v", lease)
+	}
+	if err := st.ReleaseLease("checker", "b", logger); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.GetLease("checker", logger); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected the lease to be gone after release, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package sqllite_test
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+)
+
+func TestQueue_EnqueueDedupesUnfinishedJobs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	st := newStorage(t, sqllite.Options{})
+	now := time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC)
+
+	job := models.QueuedJob{Kind: "send-reminder", Payload: []byte(`{"reminderId":1}`), DedupeKey: "reminder:1", RunAt: now, CreatedAt: now}
+	first, created, err := st.EnqueueJob(job, logger)
+	if err != nil || !created || first.State != models.JobPending || first.Attempts != 0 || string(first.Payload) != `{"reminderId":1}` {
+		t.Fatalf("expected a pending job, got %+v created=%v err=%v", first, created, err)
+	}
+	again, created, err := st.EnqueueJob(job, logger)
+	if err != nil || created || again.ID != first.ID {
+		t.Fatalf("expected the queued job back for the same key, got %+v created=%v err=%v", again, created, err)
+	}
+
+	claimed, err := st.ClaimJobs("a", now, now.Add(time.Minute), 10, logger)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("expected to claim the job, got %+v err=%v", claimed, err)
+	}
+	done := claimed[0]
+	done.State = models.JobDone
+	done.FinishedAt = &now
+	done.UpdatedAt = now
+	if err := st.FinishJob(done, "a", logger); err != nil {
+		t.Fatal(err)
+	}
+
+	if next, created, err := st.EnqueueJob(job, logger); err != nil || !created || next.ID == first.ID {
+		t.Fatalf("expected a finished job to free its key, got %+v created=%v err=%v", next, created, err)
+	}
+}
+
+func TestQueue_ClaimLeasesJobsOnce(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	st := newStorage(t, sqllite.Options{})
+	now := time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 20; i++ {
+		if _, _, err := st.EnqueueJob(models.QueuedJob{Kind: "noop", RunAt: now.Add(-time.Duration(i) * time.Second), CreatedAt: now}, logger); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := st.EnqueueJob(models.QueuedJob{Kind: "later", RunAt: now.Add(time.Hour), CreatedAt: now}, logger); err != nil {
+		t.Fatal(err)
+	}
+
+	// Workers racing for the same jobs must never both get one.
+	var (
+		mu    sync.Mutex
+		seen  = map[int64]string{}
+		wg    sync.WaitGroup
+		total int
+	)
+	for _, worker := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				claimed, err := st.ClaimJobs(worker, now, now.Add(time.Minute), 3, logger)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if len(claimed) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, job := range claimed {
+					if other, ok := seen[job.ID]; ok {
+						t.Errorf("job %d claimed by %s and %s", job.ID, other, worker)
+					}
+					seen[job.ID] = worker
+					total++
+					if job.State != models.JobRunning || job.Attempts != 1 || job.LeaseHolder != worker {
+						t.Errorf("expected a running job leased to %s, got %+v", worker, job)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if total != 20 {
+		t.Fatalf("expected the 20 due jobs to be claimed, got %d", total)
+	}
+
+	// Nobody finished, so the jobs come back once their leases expire.
+	expired := now.Add(time.Minute)
+	reclaimed, err := st.ClaimJobs("e", expired, expired.Add(time.Minute), 100, logger)
+	if err != nil || len(reclaimed) != 20 {
+		t.Fatalf("expected the 20 expired jobs back, got %d err=%v", len(reclaimed), err)
+	}
+	for i, job := range reclaimed {
+		if job.Attempts != 2 {
+			t.Fatalf("expected the second claim to count a second attempt, got %+v", job)
+		}
+		if i > 0 && job.RunAt.Before(reclaimed[i-1].RunAt) {
+			t.Fatalf("expected jobs in run order, got %v before %v", reclaimed[i-1].RunAt, job.RunAt)
+		}
+	}
+
+	stale := reclaimed[0]
+	stale.State = models.JobDone
+	stale.UpdatedAt = expired
+	if err := st.FinishJob(stale, "a", logger); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected a worker that lost the lease not to finish the job, got %v", err)
+	}
+}
+
+func TestQueue_RetryCancelAndPurge(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	st := newStorage(t, sqllite.Options{})
+	now := time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC)
+
+	pending, _, err := st.EnqueueJob(models.QueuedJob{Kind: "noop", RunAt: now, CreatedAt: now}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.RetryJob(pending.ID, now, logger); !errors.Is(err, storage.ErrJobState) {
+		t.Fatalf("expected a pending job not to be retried, got %v", err)
+	}
+	if _, err := st.RetryJob(pending.ID+100, now, logger); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a missing job, got %v", err)
+	}
+
+	claimed, err := st.ClaimJobs("a", now, now.Add(time.Minute), 1, logger)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("expected to claim the job, got %+v err=%v", claimed, err)
+	}
+	dead := claimed[0]
+	dead.State = models.JobDead
+	dead.LastError = "boom"
+	dead.FinishedAt = &now
+	dead.UpdatedAt = now
+	if err := st.FinishJob(dead, "a", logger); err != nil {
+		t.Fatal(err)
+	}
+
+	deadJobs, err := st.ListJobs(models.QueuedJobFilter{State: models.JobDead}, logger)
+	if err != nil || len(deadJobs) != 1 || deadJobs[0].LastError != "boom" {
+		t.Fatalf("expected the dead letter in the list, got %+v err=%v", deadJobs, err)
+	}
+
+	later := now.Add(time.Hour)
+	retried, err := st.RetryJob(dead.ID, later, logger)
+	if err != nil || retried.State != models.JobPending || retried.Attempts != 0 || !retried.RunAt.Equal(later) || retried.FinishedAt != nil {
+		t.Fatalf("expected the dead job to be pending again, got %+v err=%v", retried, err)
+	}
+
+	cancelled, err := st.CancelJob(dead.ID, later, logger)
+	if err != nil || cancelled.State != models.JobCancelled || cancelled.FinishedAt == nil {
+		t.Fatalf("expected the job to be cancelled, got %+v err=%v", cancelled, err)
+	}
+	if _, err := st.CancelJob(dead.ID, later, logger); !errors.Is(err, storage.ErrJobState) {
+		t.Fatalf("expected a cancelled job not to be cancelled again, got %v", err)
+	}
+	if claimed, _ := st.ClaimJobs("a", later, later.Add(time.Minute), 1, logger); len(claimed) != 0 {
+		t.Fatalf("expected a cancelled job not to be claimed, got %+v", claimed)
+	}
+
+	if purged, err := st.PurgeJobs(now, logger); err != nil || purged != 0 {
+		t.Fatalf("expected nothing finished before the cancel to purge, got %d err=%v", purged, err)
+	}
+	if purged, err := st.PurgeJobs(later, logger); err != nil || purged != 1 {
+		t.Fatalf("expected the cancelled job to be purged, got %d err=%v", purged, err)
+	}
+	if _, err := st.GetJob(dead.ID, logger); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected the purged job to be gone, got %v", err)
+	}
+}
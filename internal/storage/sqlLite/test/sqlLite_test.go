@@ -0,0 +1,91 @@
+package sqllite_test
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+)
+
+type sequenceGenerator struct {
+	ids []int64
+}
+
+func (g *sequenceGenerator) NextID() (int64, error) {
+	next := g.ids[0]
+	if len(g.ids) > 1 {
+		g.ids = g.ids[1:]
+	}
+	return next, nil
+}
+
+func TestCreateTask_RetriesOnIDConflict(t *testing.T) {
+	logger := slog.Default()
+	gen := &sequenceGenerator{ids: []int64{1, 1, 1, 2}}
+
+	st, err := sqllite.NewStorage(filepath.Join(t.TempDir(), "storage.db"), gen, sqllite.Options{})
+	if err != nil {
+		t.Fatalf("error on creating storage: %v", err)
+	}
+
+	first, err := st.CreateTask(models.Task{Title: "First"}, logger)
+	if err != nil || first.ID != 1 {
+		t.Fatalf("expected task with id 1, got %d (err %v)", first.ID, err)
+	}
+
+	second, err := st.CreateTask(models.Task{Title: "Second"}, logger)
+	if err != nil || second.ID != 2 {
+		t.Fatalf("expected retried task with id 2, got %d (err %v)", second.ID, err)
+	}
+
+	_, err = st.CreateTask(models.Task{Title: "Third"}, logger)
+	if !errors.Is(err, storage.ErrIDConflict) {
+		t.Fatalf("expected id conflict error, got %v", err)
+	}
+}
+
+func TestNewStorage_Paths(t *testing.T) {
+	logger := slog.Default()
+
+	for _, path := range []string{
+		filepath.Join(t.TempDir(), "tasks?mode=ro#1 100%.db"),
+		":memory:",
+	} {
+		st, err := sqllite.NewStorage(path, id.NewRandomGenerator(), sqllite.Options{MaxOpenConns: 4})
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+
+		// Enough concurrent work to need several connections if the pool allowed them.
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := st.CreateTask(models.Task{Title: "Task"}, logger); err != nil {
+					t.Errorf("%s: %v", path, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		tasks, err := st.GetAllTasks(logger)
+		if err != nil || len(tasks) != 8 {
+			t.Errorf("%s: expected 8 tasks, got %d %v", path, len(tasks), err)
+		}
+		st.Close()
+
+		if path != ":memory:" {
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("expected the database at %s, got %v", path, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package sqllite_test
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+)
+
+func TestWithinTx_RollsBackTaskAndEvent(t *testing.T) {
+	logger := slog.Default()
+	st := newStorage(t, sqllite.Options{MaxOpenConns: 1})
+
+	failed := errors.New("event could not be written")
+	var created models.Task
+	err := st.WithinTx(func(tx storage.Storage) error {
+		var err error
+		if created, err = tx.CreateTask(models.Task{Title: "draft"}, logger); err != nil {
+			return err
+		}
+		if _, err := tx.GetTask(created.ID, logger); err != nil {
+			t.Fatalf("expected the transaction to see its own write, got %v", err)
+		}
+		if _, err := tx.(storage.EventStore).AppendEvent(models.TaskEvent{TaskID: created.ID, Type: models.EventCreated, After: &created}, logger); err != nil {
+			return err
+		}
+		return failed
+	}, logger)
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+
+	if _, err := st.GetTask(created.ID, logger); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected the task to be rolled back, got %v", err)
+	}
+	if events, err := st.GetTaskEvents(created.ID, logger); err != nil || len(events) != 0 {
+		t.Errorf("expected the event to be rolled back, got %v %v", events, err)
+	}
+
+	results, err := st.ApplyBatch([]models.BatchOperation{
+		{Op: models.BatchCreate, Task: models.Task{Title: "kept"}},
+	}, true, logger)
+	if err != nil || results[0].Err != nil {
+		t.Fatalf("expected the batch to commit after a rollback, got %v %v", err, results)
+	}
+	if _, err := st.GetTask(results[0].Task.ID, logger); err != nil {
+		t.Errorf("expected the batch task to be stored, got %v", err)
+	}
+}
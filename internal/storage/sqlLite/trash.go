@@ -0,0 +1,73 @@
+package sqllite
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+func (st *Storage) GetTrash(logger *slog.Logger) ([]models.Task, error) {
+	logger.Info("op: storage.sqllite.GetTrash")
+
+	return queryTasks(stmt(st.tx, st.stmts.getTrash))
+}
+
+func (st *Storage) RestoreTask(id int64, logger *slog.Logger) (models.Task, error) {
+	logger.Info("op: storage.sqllite.RestoreTask")
+
+	res, err := stmt(st.tx, st.stmts.restoreTask).Exec(id)
+	if isUniqueConflict(err) {
+		return models.Task{}, storage.ErrExternalIDTaken
+	}
+	if err != nil {
+		return models.Task{}, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return models.Task{}, err
+	}
+	if affected == 0 {
+		return models.Task{}, storage.ErrNotFound
+	}
+
+	return scanTask(stmt(st.tx, st.stmts.getTask).QueryRow(id))
+}
+
+func (st *Storage) PurgeTask(id int64, logger *slog.Logger) error {
+	logger.Info("op: storage.sqllite.PurgeTask")
+
+	res, err := stmt(st.tx, st.stmts.purgeTask).Exec(id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (st *Storage) PurgeTrash(before time.Time, logger *slog.Logger) ([]int64, error) {
+	logger.Info("op: storage.sqllite.PurgeTrash")
+
+	rows, err := stmt(st.tx, st.stmts.purgeTrash).Query(before.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -3,16 +3,144 @@ package storage
 import (
 	"errors"
 	"log/slog"
+	"time"
 
 	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/clock"
 )
 
-var ErrNotFound = errors.New("not found")
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrUnknownOperation = errors.New("unknown operation")
+	ErrRolledBack       = errors.New("rolled back because another operation in the batch failed")
+	ErrIDConflict       = errors.New("could not generate a unique id")
+	ErrReminderExists   = errors.New("reminder with this lead time already exists")
+	ErrJobState         = errors.New("job is not in a state that allows this")
+	ErrExternalIDTaken  = errors.New("external id is already used by another task")
+)
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLSaver
 type Storage interface {
 	GetAllTasks(logger *slog.Logger) ([]models.Task, error)
+	// GetTask also returns trashed tasks, with DeletedAt set.
+	GetTask(id int64, logger *slog.Logger) (models.Task, error)
 	CreateTask(task models.Task, logger *slog.Logger) (models.Task, error)
 	UpdateTask(task models.Task, logger *slog.Logger) (models.Task, error)
+	// DeleteTask moves a task to the trash.
 	DeleteTask(id int64, logger *slog.Logger) error
+	GetTrash(logger *slog.Logger) ([]models.Task, error)
+	RestoreTask(id int64, logger *slog.Logger) (models.Task, error)
+	PurgeTask(id int64, logger *slog.Logger) error
+	PurgeTrash(before time.Time, logger *slog.Logger) ([]int64, error)
+	// ApplyBatch runs ops in one transaction; with atomic set the first failure rolls back all of them.
+	ApplyBatch(ops []models.BatchOperation, atomic bool, logger *slog.Logger) ([]BatchResult, error)
+}
+
+type Clocked interface {
+	Clock() clock.Clock
+}
+
+// ClockSource implements Clocked; the zero value uses the real clock.
+type ClockSource struct {
+	clk clock.Clock
+}
+
+func (s *ClockSource) SetClock(c clock.Clock) {
+	s.clk = c
+}
+
+func (s *ClockSource) Clock() clock.Clock {
+	if s.clk == nil {
+		return clock.Real
+	}
+	return s.clk
+}
+
+type BatchResult struct {
+	Task models.Task
+	Err  error
+}
+
+func MarkRolledBack(results []BatchResult) {
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Err = ErrRolledBack
+			results[i].Task = models.Task{}
+		}
+	}
+}
+
+type IdempotencyStore interface {
+	// ReserveIdempotencyKey returns the unexpired record with rec's key, if any, instead of storing rec.
+	ReserveIdempotencyKey(rec IdempotencyRecord, logger *slog.Logger) (existing IdempotencyRecord, reserved bool, err error)
+	CompleteIdempotencyKey(key string, status int, contentType string, body []byte, logger *slog.Logger) error
+	ReleaseIdempotencyKey(key string, logger *slog.Logger) error
+	PurgeIdempotencyKeys(now time.Time, logger *slog.Logger) (int64, error)
+}
+
+// IdempotencyRecord has a zero Status while the original request is still being processed.
+type IdempotencyRecord struct {
+	Key         string
+	Fingerprint string
+	Status      int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+type LeaseStore interface {
+	// AcquireLease takes or renews the lease; while another holder has it, that lease is returned.
+	AcquireLease(name, holder string, now, expiresAt time.Time, logger *slog.Logger) (lease models.Lease, acquired bool, err error)
+	ReleaseLease(name, holder string, logger *slog.Logger) error
+	GetLease(name string, logger *slog.Logger) (models.Lease, error)
+}
+
+type QueueStore interface {
+	// EnqueueJob returns the pending or running job with the same DedupeKey instead of adding job.
+	EnqueueJob(job models.QueuedJob, logger *slog.Logger) (queued models.QueuedJob, created bool, err error)
+	// ClaimJobs also reclaims running jobs whose lease expired.
+	ClaimJobs(worker string, now, leaseUntil time.Time, limit int, logger *slog.Logger) ([]models.QueuedJob, error)
+	// FinishJob returns ErrNotFound when worker no longer holds the lease.
+	FinishJob(job models.QueuedJob, worker string, logger *slog.Logger) error
+	GetJob(id int64, logger *slog.Logger) (models.QueuedJob, error)
+	ListJobs(filter models.QueuedJobFilter, logger *slog.Logger) ([]models.QueuedJob, error)
+	RetryJob(id int64, now time.Time, logger *slog.Logger) (models.QueuedJob, error)
+	CancelJob(id int64, now time.Time, logger *slog.Logger) (models.QueuedJob, error)
+	PurgeJobs(before time.Time, logger *slog.Logger) (int64, error)
+}
+
+type DueIndex interface {
+	GetTasksDueBefore(before time.Time, logger *slog.Logger) ([]models.Task, error)
+}
+
+type EventStore interface {
+	// WithinTx commits fn's writes together; fn must only use the storage it is given.
+	WithinTx(fn func(tx Storage) error, logger *slog.Logger) error
+	AppendEvent(event models.TaskEvent, logger *slog.Logger) (models.TaskEvent, error)
+	GetTaskEvents(taskID int64, logger *slog.Logger) ([]models.TaskEvent, error)
+	GetEvent(id int64, logger *slog.Logger) (models.TaskEvent, error)
+	LastEventAt(logger *slog.Logger) (time.Time, error)
+}
+
+type FeedStore interface {
+	GetFeedByOwner(owner string, logger *slog.Logger) (models.CalendarFeed, error)
+	GetFeedByToken(tokenHash string, logger *slog.Logger) (models.CalendarFeed, error)
+	SaveFeed(feed models.CalendarFeed, logger *slog.Logger) error
+	DeleteFeed(owner string, logger *slog.Logger) error
+}
+
+type Backuper interface {
+	Backup(path string, logger *slog.Logger) error
+}
+
+type ReminderStore interface {
+	CreateReminder(reminder models.Reminder, logger *slog.Logger) (models.Reminder, error)
+	UpdateReminder(reminder models.Reminder, logger *slog.Logger) error
+	// ClaimReminder reports whether this call marked the reminder sent, so only one caller delivers it.
+	ClaimReminder(id int64, sentAt time.Time, logger *slog.Logger) (bool, error)
+	ReleaseReminder(id int64, sentAt time.Time, logger *slog.Logger) error
+	DeleteReminder(id int64, logger *slog.Logger) error
+	GetTaskReminders(taskID int64, logger *slog.Logger) ([]models.Reminder, error)
+	GetDueReminders(before time.Time, logger *slog.Logger) ([]models.Reminder, error)
 }
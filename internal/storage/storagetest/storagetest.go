@@ -0,0 +1,443 @@
+package storagetest
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+// Run checks the behaviour every storage.Storage implementation must share. newStorage must
+// return an empty storage on each call.
+func Run(t *testing.T, newStorage func(t *testing.T) storage.Storage) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, st storage.Storage)
+	}{
+		{"Empty", testEmpty},
+		{"CreateAndGet", testCreateAndGet},
+		{"DueDates", testDueDates},
+		{"AllDayDueDates", testAllDayDueDates},
+		{"Update", testUpdate},
+		{"NotFound", testNotFound},
+		{"Trash", testTrash},
+		{"PurgeTrash", testPurgeTrash},
+		{"AtomicBatch", testAtomicBatch},
+		{"BestEffortBatch", testBestEffortBatch},
+		{"Ordering", testOrdering},
+		{"Concurrency", testConcurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage(t))
+		})
+	}
+}
+
+var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func testEmpty(t *testing.T, st storage.Storage) {
+	tasks, err := st.GetAllTasks(logger)
+	if err != nil {
+		t.Fatalf("GetAllTasks: %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Fatalf("expected no tasks, got %+v", tasks)
+	}
+
+	trash, err := st.GetTrash(logger)
+	if err != nil {
+		t.Fatalf("GetTrash: %v", err)
+	}
+	if len(trash) != 0 {
+		t.Fatalf("expected empty trash, got %+v", trash)
+	}
+}
+
+func testCreateAndGet(t *testing.T, st storage.Storage) {
+	created := mustCreate(t, st, models.Task{ExternalID: "ext-1", Title: "Title", Description: "Description"})
+	if created.ID == 0 {
+		t.Fatalf("expected generated id, got 0")
+	}
+	if created.ExternalID != "ext-1" || created.Title != "Title" || created.Description != "Description" {
+		t.Fatalf("expected created task to keep its fields, got %+v", created)
+	}
+
+	got, err := st.GetTask(created.ID, logger)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	assertTask(t, created, got)
+
+	tasks, err := st.GetAllTasks(logger)
+	if err != nil {
+		t.Fatalf("GetAllTasks: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("expected 1 task, got %d", len(tasks))
+	}
+	assertTask(t, created, tasks[0])
+}
+
+func testDueDates(t *testing.T, st storage.Storage) {
+	withoutDue := mustCreate(t, st, models.Task{Title: "No due date"})
+	due := time.Date(2030, time.March, 4, 5, 6, 7, 0, time.UTC)
+	withDue := mustCreate(t, st, models.Task{Title: "Due", DueDate: &due})
+
+	got, err := st.GetTask(withoutDue.ID, logger)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.DueDate != nil {
+		t.Fatalf("expected nil due date, got %v", got.DueDate)
+	}
+
+	got, err = st.GetTask(withDue.ID, logger)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.DueDate == nil || !got.DueDate.Equal(due) {
+		t.Fatalf("expected due date %v, got %v", due, got.DueDate)
+	}
+
+	got.DueDate = nil
+	if _, err := st.UpdateTask(got, logger); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	got, err = st.GetTask(withDue.ID, logger)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.DueDate != nil {
+		t.Fatalf("expected due date to be cleared, got %v", got.DueDate)
+	}
+}
+
+func testAllDayDueDates(t *testing.T, st storage.Storage) {
+	due := time.Date(2030, time.March, 4, 0, 0, 0, 0, time.UTC)
+	created := mustCreate(t, st, models.Task{Title: "All day", DueDate: &due, DueKind: models.DueAllDay, TimeZone: "Pacific/Auckland"})
+
+	got, err := st.GetTask(created.ID, logger)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	assertTask(t, created, got)
+	if got.DueDate.UTC().Format(time.DateOnly) != "2030-03-04" {
+		t.Fatalf("expected the calendar date to survive, got %v", got.DueDate)
+	}
+
+	got.DueKind = models.DueInstant
+	got.TimeZone = ""
+	if _, err := st.UpdateTask(got, logger); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	updated, err := st.GetTask(created.ID, logger)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	assertTask(t, got, updated)
+}
+
+func testUpdate(t *testing.T, st storage.Storage) {
+	created := mustCreate(t, st, models.Task{Title: "Old", Description: "Old description"})
+
+	due := time.Date(2031, time.January, 1, 0, 0, 0, 0, time.UTC)
+	changed := models.Task{ID: created.ID, Title: "New", Description: "New description", DueDate: &due, OverDue: true}
+	updated, err := st.UpdateTask(changed, logger)
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	assertTask(t, changed, updated)
+
+	got, err := st.GetTask(created.ID, logger)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	assertTask(t, changed, got)
+}
+
+func testNotFound(t *testing.T, st storage.Storage) {
+	const missing = 4242
+
+	if _, err := st.GetTask(missing, logger); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetTask: expected ErrNotFound, got %v", err)
+	}
+	if _, err := st.UpdateTask(models.Task{ID: missing, Title: "Missing"}, logger); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("UpdateTask: expected ErrNotFound, got %v", err)
+	}
+	if err := st.DeleteTask(missing, logger); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("DeleteTask: expected ErrNotFound, got %v", err)
+	}
+	if _, err := st.RestoreTask(missing, logger); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("RestoreTask: expected ErrNotFound, got %v", err)
+	}
+	if err := st.PurgeTask(missing, logger); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("PurgeTask: expected ErrNotFound, got %v", err)
+	}
+}
+
+func testTrash(t *testing.T, st storage.Storage) {
+	live := mustCreate(t, st, models.Task{Title: "Live"})
+	trashed := mustCreate(t, st, models.Task{Title: "Trashed"})
+
+	if err := st.DeleteTask(trashed.ID, logger); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if err := st.DeleteTask(trashed.ID, logger); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("second DeleteTask: expected ErrNotFound, got %v", err)
+	}
+	if _, err := st.UpdateTask(trashed, logger); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("UpdateTask of trashed task: expected ErrNotFound, got %v", err)
+	}
+	if _, err := st.RestoreTask(live.ID, logger); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("RestoreTask of live task: expected ErrNotFound, got %v", err)
+	}
+	if err := st.PurgeTask(live.ID, logger); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("PurgeTask of live task: expected ErrNotFound, got %v", err)
+	}
+
+	assertIDs(t, "GetAllTasks", mustGetAll(t, st), live.ID)
+	assertIDs(t, "GetTrash", mustGetTrash(t, st), trashed.ID)
+
+	got, err := st.GetTask(trashed.ID, logger)
+	if err != nil {
+		t.Fatalf("GetTask of trashed task: %v", err)
+	}
+	if got.DeletedAt == nil {
+		t.Fatalf("expected DeletedAt on trashed task")
+	}
+
+	restored, err := st.RestoreTask(trashed.ID, logger)
+	if err != nil {
+		t.Fatalf("RestoreTask: %v", err)
+	}
+	if restored.DeletedAt != nil || restored.Title != "Trashed" {
+		t.Fatalf("expected restored live task, got %+v", restored)
+	}
+	assertIDs(t, "GetAllTasks after restore", mustGetAll(t, st), live.ID, trashed.ID)
+
+	if err := st.DeleteTask(trashed.ID, logger); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if err := st.PurgeTask(trashed.ID, logger); err != nil {
+		t.Fatalf("PurgeTask: %v", err)
+	}
+	if _, err := st.GetTask(trashed.ID, logger); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("GetTask of purged task: expected ErrNotFound, got %v", err)
+	}
+	assertIDs(t, "GetTrash after purge", mustGetTrash(t, st))
+}
+
+func testPurgeTrash(t *testing.T, st storage.Storage) {
+	live := mustCreate(t, st, models.Task{Title: "Live"})
+	trashed := mustCreate(t, st, models.Task{Title: "Trashed"})
+	if err := st.DeleteTask(trashed.ID, logger); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+
+	ids, err := st.PurgeTrash(time.Now().Add(-time.Hour), logger)
+	if err != nil {
+		t.Fatalf("PurgeTrash: %v", err)
+	}
+	if len(ids) != 0 {
+		t.Fatalf("expected nothing purged before deletion time, got %v", ids)
+	}
+
+	ids, err = st.PurgeTrash(time.Now().Add(time.Hour), logger)
+	if err != nil {
+		t.Fatalf("PurgeTrash: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != trashed.ID {
+		t.Fatalf("expected purged id %d, got %v", trashed.ID, ids)
+	}
+	assertIDs(t, "GetAllTasks", mustGetAll(t, st), live.ID)
+	assertIDs(t, "GetTrash", mustGetTrash(t, st))
+}
+
+func testAtomicBatch(t *testing.T, st storage.Storage) {
+	existing := mustCreate(t, st, models.Task{Title: "Existing"})
+
+	results, err := st.ApplyBatch([]models.BatchOperation{
+		{Op: models.BatchCreate, Task: models.Task{Title: "Created"}},
+		{Op: models.BatchUpdate, Task: models.Task{ID: existing.ID, Title: "Updated"}},
+		{Op: models.BatchDelete, Task: models.Task{ID: 4242}},
+	}, true, logger)
+	if err != nil {
+		t.Fatalf("ApplyBatch: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	for i, want := range []error{storage.ErrRolledBack, storage.ErrRolledBack, storage.ErrNotFound} {
+		if !errors.Is(results[i].Err, want) {
+			t.Errorf("result %d: expected %v, got %v", i, want, results[i].Err)
+		}
+	}
+
+	tasks := mustGetAll(t, st)
+	assertIDs(t, "GetAllTasks", tasks, existing.ID)
+	if tasks[0].Title != "Existing" {
+		t.Fatalf("expected rolled back title Existing, got %s", tasks[0].Title)
+	}
+}
+
+func testBestEffortBatch(t *testing.T, st storage.Storage) {
+	existing := mustCreate(t, st, models.Task{Title: "Existing"})
+	deleted := mustCreate(t, st, models.Task{Title: "Deleted"})
+
+	results, err := st.ApplyBatch([]models.BatchOperation{
+		{Op: models.BatchCreate, Task: models.Task{Title: "Created"}},
+		{Op: models.BatchUpdate, Task: models.Task{ID: existing.ID, Title: "Updated"}},
+		{Op: models.BatchDelete, Task: models.Task{ID: 4242}},
+		{Op: models.BatchDelete, Task: models.Task{ID: deleted.ID}},
+	}, false, logger)
+	if err != nil {
+		t.Fatalf("ApplyBatch: %v", err)
+	}
+	for i, want := range []error{nil, nil, storage.ErrNotFound, nil} {
+		if !errors.Is(results[i].Err, want) {
+			t.Errorf("result %d: expected %v, got %v", i, want, results[i].Err)
+		}
+	}
+	if results[0].Task.ID == 0 || results[1].Task.Title != "Updated" {
+		t.Fatalf("expected created and updated tasks in results, got %+v", results)
+	}
+
+	assertIDs(t, "GetAllTasks", mustGetAll(t, st), existing.ID, results[0].Task.ID)
+	assertIDs(t, "GetTrash", mustGetTrash(t, st), deleted.ID)
+}
+
+func testOrdering(t *testing.T, st storage.Storage) {
+	for i := 0; i < 20; i++ {
+		mustCreate(t, st, models.Task{Title: "Task"})
+	}
+
+	tasks := mustGetAll(t, st)
+	if len(tasks) != 20 {
+		t.Fatalf("expected 20 tasks, got %d", len(tasks))
+	}
+	if !sort.SliceIsSorted(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID }) {
+		t.Fatalf("expected GetAllTasks ordered by id")
+	}
+}
+
+func testConcurrency(t *testing.T, st storage.Storage) {
+	const (
+		workers = 8
+		perWork = 25
+	)
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		ids = make(map[int64]bool)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWork; i++ {
+				task, err := st.CreateTask(models.Task{Title: "Concurrent"}, logger)
+				if err != nil {
+					t.Errorf("CreateTask: %v", err)
+					return
+				}
+				task.Description = "Updated"
+				if _, err := st.UpdateTask(task, logger); err != nil {
+					t.Errorf("UpdateTask: %v", err)
+					return
+				}
+				if _, err := st.GetAllTasks(logger); err != nil {
+					t.Errorf("GetAllTasks: %v", err)
+					return
+				}
+
+				mu.Lock()
+				if ids[task.ID] {
+					t.Errorf("duplicate id %d", task.ID)
+				}
+				ids[task.ID] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	tasks := mustGetAll(t, st)
+	if len(tasks) != workers*perWork {
+		t.Fatalf("expected %d tasks, got %d", workers*perWork, len(tasks))
+	}
+	for _, task := range tasks {
+		if task.Description != "Updated" {
+			t.Fatalf("expected every task to be updated, got %+v", task)
+		}
+	}
+}
+
+func mustCreate(t *testing.T, st storage.Storage, task models.Task) models.Task {
+	t.Helper()
+
+	created, err := st.CreateTask(task, logger)
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	return created
+}
+
+func mustGetAll(t *testing.T, st storage.Storage) []models.Task {
+	t.Helper()
+
+	tasks, err := st.GetAllTasks(logger)
+	if err != nil {
+		t.Fatalf("GetAllTasks: %v", err)
+	}
+	return tasks
+}
+
+func mustGetTrash(t *testing.T, st storage.Storage) []models.Task {
+	t.Helper()
+
+	tasks, err := st.GetTrash(logger)
+	if err != nil {
+		t.Fatalf("GetTrash: %v", err)
+	}
+	return tasks
+}
+
+func assertTask(t *testing.T, want, got models.Task) {
+	t.Helper()
+
+	sameDue := (want.DueDate == nil && got.DueDate == nil) ||
+		(want.DueDate != nil && got.DueDate != nil && want.DueDate.Equal(*got.DueDate))
+	if want.ID != got.ID || want.ExternalID != got.ExternalID || want.Title != got.Title || want.Description != got.Description ||
+		want.OverDue != got.OverDue || !sameDue || want.DueKind != got.DueKind || want.TimeZone != got.TimeZone {
+		t.Fatalf("expected task %+v, got %+v", want, got)
+	}
+}
+
+func assertIDs(t *testing.T, what string, tasks []models.Task, want ...int64) {
+	t.Helper()
+
+	got := make([]int64, 0, len(tasks))
+	for _, task := range tasks {
+		got = append(got, task.ID)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	sort.Slice(want, func(i, j int) bool { return want[i] < want[j] })
+
+	if len(got) != len(want) {
+		t.Fatalf("%s: expected ids %v, got %v", what, want, got)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("%s: expected ids %v, got %v", what, want, got)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+var backupsNotConfigured = "backup directory is not configured"
+
+func CreateBackup(st storage.Storage, dir string, keep int, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "POST.admin.backups"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		if dir == "" {
+			WriteNewResponceWithError(w, backupsNotConfigured, http.StatusNotImplemented, logger)
+			return
+		}
+
+		backup, err := services.CreateBackup(dir, keep, services.ClockOf(st).Now(), st, logger)
+		if err != nil {
+			if errors.Is(err, services.ErrBackupUnsupported) {
+				logger.Info("backups are not supported by storage")
+				WriteNewResponceWithError(w, err.Error(), http.StatusNotImplemented, logger)
+				return
+			}
+			logger.Error("error on creating backup", sl.Err(err))
+			WriteNewResponceWithError(w, internalError, http.StatusInternalServerError, logger)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(backup); err != nil {
+			logger.Error("error on encoding backup to json", sl.Err(err))
+		}
+	}
+}
+
+func GetBackups(dir string, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "GET.admin.backups"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		if dir == "" {
+			WriteNewResponceWithError(w, backupsNotConfigured, http.StatusNotImplemented, logger)
+			return
+		}
+
+		backups, err := services.ListBackups(dir)
+		if err != nil {
+			logger.Error("error on listing backups", sl.Err(err))
+			WriteNewResponceWithError(w, internalError, http.StatusInternalServerError, logger)
+			return
+		}
+		if backups == nil {
+			backups = []models.Backup{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(backups); err != nil {
+			logger.Error("error on encoding backups to json", sl.Err(err))
+		}
+	}
+}
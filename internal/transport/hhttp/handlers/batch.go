@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/domain/server"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+const (
+	maxBatchSize      = 1000
+	maxBatchBodyBytes = 16 << 20
+)
+
+type batchOperationRequest struct {
+	Op   models.BatchOp  `json:"op"`
+	ID   int64           `json:"id"`
+	Task json.RawMessage `json:"task"`
+}
+
+func BatchTasks(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "POST.tasks:batch"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		atomic := true
+		if raw := r.URL.Query().Get("atomic"); raw != "" {
+			parsed, err := strconv.ParseBool(raw)
+			if err != nil {
+				logger.Info("putted wrong atomic option", slog.String("atomic", raw))
+				WriteNewResponceWithError(w, "atomic must be true or false", http.StatusBadRequest, logger)
+				return
+			}
+			atomic = parsed
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)
+		var reqs []batchOperationRequest
+		if ok := decodeJSON(w, r, &reqs, logger); !ok {
+			return
+		}
+		if len(reqs) == 0 || len(reqs) > maxBatchSize {
+			logger.Info("batch has wrong size", slog.Int("size", len(reqs)))
+			WriteNewResponceWithError(w, fmt.Sprintf("batch must contain between 1 and %d operations", maxBatchSize), http.StatusBadRequest, logger)
+			return
+		}
+
+		results := make([]server.BatchItemResult, len(reqs))
+		ops := make([]models.BatchOperation, 0, len(reqs))
+		indexes := make([]int, 0, len(reqs))
+		now := services.ClockOf(st).Now()
+		for i, req := range reqs {
+			results[i] = server.BatchItemResult{Index: i, Op: string(req.Op), ID: req.ID}
+
+			batchOp, errResult := parseBatchOperation(req, r.Header.Get(TimeZoneHeader), now)
+			if errResult != nil {
+				results[i].Status = http.StatusBadRequest
+				results[i].Err = errResult.Err
+				results[i].Violations = errResult.Violations
+				continue
+			}
+			ops = append(ops, batchOp)
+			indexes = append(indexes, i)
+		}
+
+		resp := server.BatchResponce{Atomic: atomic, Results: results}
+		if atomic && len(ops) != len(reqs) {
+			logger.Info("atomic batch rejected on validation")
+			markNotExecuted(results)
+			writeBatchResponce(w, resp, logger)
+			return
+		}
+
+		if len(ops) > 0 {
+			batchResults, err := services.ApplyBatch(ops, atomic, ActorFrom(r), st, logger)
+			if err != nil {
+				logger.Error("error on applying batch", sl.Err(err))
+				WriteNewResponceWithError(w, internalError, http.StatusInternalServerError, logger)
+				return
+			}
+
+			for j, res := range batchResults {
+				fillBatchItemResult(&results[indexes[j]], res)
+			}
+		}
+
+		resp.Committed = true
+		for _, res := range results {
+			if res.Status == http.StatusFailedDependency {
+				resp.Committed = false
+			}
+		}
+
+		writeBatchResponce(w, resp, logger)
+	}
+}
+
+func parseBatchOperation(req batchOperationRequest, zone string, now time.Time) (models.BatchOperation, *server.BatchItemResult) {
+	switch req.Op {
+	case models.BatchCreate, models.BatchUpdate:
+		if req.Op == models.BatchUpdate && req.ID == 0 {
+			return models.BatchOperation{}, &server.BatchItemResult{Err: "id is required"}
+		}
+		if req.Op == models.BatchCreate && req.ID != 0 {
+			return models.BatchOperation{}, &server.BatchItemResult{Err: "id is server-owned and cannot be set"}
+		}
+
+		var taskReq taskRequest
+		if err := decodeStrict(bytes.NewReader(req.Task), &taskReq); err != nil {
+			return models.BatchOperation{}, &server.BatchItemResult{Err: fmt.Sprintf("invalid task: %s", err.Error())}
+		}
+		task, violations := validateTask(taskReq, req.ID, zone, now)
+		if len(violations) > 0 {
+			return models.BatchOperation{}, &server.BatchItemResult{Err: "validation failed", Violations: violations}
+		}
+		return models.BatchOperation{Op: req.Op, Task: task}, nil
+	case models.BatchDelete:
+		if req.ID == 0 {
+			return models.BatchOperation{}, &server.BatchItemResult{Err: "id is required"}
+		}
+		return models.BatchOperation{Op: req.Op, Task: models.Task{ID: req.ID}}, nil
+	default:
+		return models.BatchOperation{}, &server.BatchItemResult{Err: "op must be one of create, update, delete"}
+	}
+}
+
+func fillBatchItemResult(result *server.BatchItemResult, res storage.BatchResult) {
+	switch {
+	case res.Err == nil:
+		result.Status = http.StatusOK
+		if result.Op == string(models.BatchCreate) {
+			result.Status = http.StatusCreated
+		}
+		result.ID = res.Task.ID
+		if result.Op != string(models.BatchDelete) {
+			task := res.Task
+			result.Task = &task
+		}
+	case errors.Is(res.Err, storage.ErrRolledBack):
+		result.Status = http.StatusFailedDependency
+		result.Err = res.Err.Error()
+	case errors.Is(res.Err, storage.ErrNotFound):
+		result.Status = http.StatusNotFound
+		result.Err = notFound
+	case errors.Is(res.Err, storage.ErrUnknownOperation):
+		result.Status = http.StatusBadRequest
+		result.Err = res.Err.Error()
+	default:
+		result.Status = http.StatusInternalServerError
+		result.Err = internalError
+	}
+}
+
+func markNotExecuted(results []server.BatchItemResult) {
+	for i := range results {
+		if results[i].Status == 0 {
+			results[i].Status = http.StatusFailedDependency
+			results[i].Err = storage.ErrRolledBack.Error()
+		}
+	}
+}
+
+func writeBatchResponce(w http.ResponseWriter, resp server.BatchResponce, logger *slog.Logger) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Error("error on encoding batch responce to json", sl.Err(err))
+	}
+}
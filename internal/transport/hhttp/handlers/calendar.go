@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/domain/server"
+	"github.com/gintokos/tasksrestapi/internal/lib/ical"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/lib/taskio"
+	"github.com/gintokos/tasksrestapi/internal/lib/validator"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+type calendarFeedRequest struct {
+	Components     []string `json:"components"`
+	IncludeOverdue bool     `json:"includeOverdue"`
+}
+
+var calendarFeedRules = []validator.Rule[calendarFeedRequest]{
+	{
+		Field: "components",
+		Check: func(req calendarFeedRequest) string {
+			for _, c := range req.Components {
+				if c != models.CalendarTodo && c != models.CalendarEvent {
+					return fmt.Sprintf("must contain only %s or %s", models.CalendarTodo, models.CalendarEvent)
+				}
+			}
+			return ""
+		},
+	},
+}
+
+func CalendarFeed(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "GET.calendar.token"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		token, ok := strings.CutSuffix(r.PathValue("file"), ".ics")
+		if !ok || token == "" {
+			WriteNewResponceWithError(w, notFound, http.StatusNotFound, logger)
+			return
+		}
+
+		feed, err := services.GetCalendarFeedByToken(token, st, logger)
+		if err != nil {
+			writeCalendarError(w, err, logger)
+			return
+		}
+
+		tasks, modified, err := services.FeedTasks(feed, st, logger)
+		if err != nil {
+			logger.Error("error on getting feed tasks", sl.Err(err))
+			WriteNewResponceWithError(w, internalError, http.StatusInternalServerError, logger)
+			return
+		}
+
+		body, err := renderCalendar(feed, tasks, modified)
+		if err != nil {
+			logger.Error("error on rendering calendar", sl.Err(err))
+			WriteNewResponceWithError(w, internalError, http.StatusInternalServerError, logger)
+			return
+		}
+
+		sum := sha256.Sum256(body)
+		w.Header().Set("Content-Type", taskio.FormatICS.ContentType())
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+		w.Header().Set("Cache-Control", "private, no-cache")
+		// ServeContent answers If-None-Match and If-Modified-Since with 304.
+		http.ServeContent(w, r, "", modified, bytes.NewReader(body))
+	}
+}
+
+// renderCalendar is deterministic for the same input so the ETag only changes with the content.
+func renderCalendar(feed models.CalendarFeed, tasks []models.Task, modified time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	w := ical.NewWriter(&buf)
+	stamp := ical.FormatTime(modified)
+
+	w.Begin("VCALENDAR")
+	w.Raw("VERSION", "2.0")
+	w.Raw("PRODID", taskio.ProdID)
+	w.Text("X-WR-CALNAME", "Tasks")
+	for _, task := range tasks {
+		for _, c := range feed.Components {
+			switch c {
+			case models.CalendarTodo:
+				taskio.WriteTodo(w, task, stamp)
+			case models.CalendarEvent:
+				taskio.WriteDueEvent(w, task, stamp)
+			}
+		}
+	}
+	w.End("VCALENDAR")
+
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func GetCalendarFeedSettings(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "GET.calendar.feed"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		feed, err := services.GetCalendarFeed(feedToken(r), st, logger)
+		if err != nil {
+			writeCalendarError(w, err, logger)
+			return
+		}
+		writeCalendarFeed(w, r, http.StatusOK, feed, "", logger)
+	}
+}
+
+func PutCalendarFeedSettings(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "PUT.calendar.feed"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+		var req calendarFeedRequest
+		if ok := decodeJSON(w, r, &req, logger); !ok {
+			return
+		}
+		if violations := validator.Validate(req, calendarFeedRules); len(violations) > 0 {
+			logger.Info("feed settings failed validation", slog.String("violations", violations.Error()))
+			WriteNewResponceWithViolations(w, violations, logger)
+			return
+		}
+		if len(req.Components) == 0 {
+			req.Components = []string{models.CalendarTodo}
+		}
+
+		feed, token, err := services.SaveCalendarFeed(models.CalendarFeed{
+			Owner:          ActorFrom(r),
+			Components:     req.Components,
+			IncludeOverdue: req.IncludeOverdue,
+		}, feedToken(r), services.ClockOf(st).Now(), st, logger)
+		if err != nil {
+			writeCalendarError(w, err, logger)
+			return
+		}
+
+		status := http.StatusOK
+		if token != "" {
+			status = http.StatusCreated
+		}
+		writeCalendarFeed(w, r, status, feed, token, logger)
+	}
+}
+
+func RegenerateCalendarToken(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "POST.calendar.feed.token"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		feed, token, err := services.RegenerateFeedToken(feedToken(r), services.ClockOf(st).Now(), st, logger)
+		if err != nil {
+			writeCalendarError(w, err, logger)
+			return
+		}
+		writeCalendarFeed(w, r, http.StatusOK, feed, token, logger)
+	}
+}
+
+func RevokeCalendarFeed(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "DELETE.calendar.feed"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		if err := services.RevokeCalendarFeed(ActorFrom(r), st, logger); err != nil {
+			writeCalendarError(w, err, logger)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func feedToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+func writeCalendarFeed(w http.ResponseWriter, r *http.Request, status int, feed models.CalendarFeed, token string, logger *slog.Logger) {
+	resp := server.CalendarFeedResponce{Feed: feed, Token: token}
+	if token != "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		resp.URL = fmt.Sprintf("%s://%s/calendar/%s.ics", scheme, r.Host, token)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Error("error on encoding calendar feed to json", sl.Err(err))
+	}
+}
+
+func writeCalendarError(w http.ResponseWriter, err error, logger *slog.Logger) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		logger.Info("Not found calendar feed")
+		WriteNewResponceWithError(w, notFound, http.StatusNotFound, logger)
+	case errors.Is(err, services.ErrFeedUnauthorized):
+		logger.Info("calendar feed management without a valid token")
+		w.Header().Set("WWW-Authenticate", `Bearer realm="calendar"`)
+		WriteNewResponceWithError(w, err.Error(), http.StatusUnauthorized, logger)
+	case errors.Is(err, services.ErrCalendarUnsupported):
+		logger.Info("calendar feeds are not supported by storage")
+		WriteNewResponceWithError(w, err.Error(), http.StatusNotImplemented, logger)
+	default:
+		logger.Error("error on handling calendar feed", sl.Err(err))
+		WriteNewResponceWithError(w, internalError, http.StatusInternalServerError, logger)
+	}
+}
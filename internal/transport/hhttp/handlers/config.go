@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+)
+
+// ConfigStatuser reports which configuration the running app has applied.
+type ConfigStatuser interface {
+	ConfigStatus() models.ConfigStatus
+}
+
+func GetConfigStatus(configs ConfigStatuser, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "GET.admin.config"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		if configs == nil {
+			WriteNewResponceWithError(w, "config reloading is not running", http.StatusNotImplemented, logger)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := json.NewEncoder(w).Encode(configs.ConfigStatus()); err != nil {
+			logger.Error("error on encoding config status to json", sl.Err(err))
+		}
+	}
+}
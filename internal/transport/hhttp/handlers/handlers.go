@@ -9,7 +9,6 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/gintokos/tasksrestapi/internal/domain/models"
 	"github.com/gintokos/tasksrestapi/internal/domain/server"
 	"github.com/gintokos/tasksrestapi/internal/lib/id"
 	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
@@ -55,15 +54,18 @@ func PostTask(st storage.Storage, logger *slog.Logger) func(w http.ResponseWrite
 		logger.Info(fmt.Sprintf("op: %s", op))
 		defer r.Body.Close()
 
-		var task models.Task
-		if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
-			logger.Warn("error on decoding body of request", sl.Err(err))
-			WriteNewResponceWithError(w, "invalid credentionals", http.StatusBadRequest, logger)
+		task, ok := decodeTask(w, r, 0, services.ClockOf(st).Now(), logger)
+		if !ok {
 			return
 		}
 
-		taskwithid, err := services.CreateNewTask(task, st, logger)
+		taskwithid, err := services.CreateNewTask(task, ActorFrom(r), st, logger)
 		if err != nil {
+			if errors.Is(err, storage.ErrExternalIDTaken) {
+				logger.Info("external id is taken")
+				WriteNewResponceWithError(w, err.Error(), http.StatusConflict, logger)
+				return
+			}
 			logger.Error("error on creating task", sl.Err(err))
 			WriteNewResponceWithError(w, internalError, http.StatusInternalServerError, logger)
 			return
@@ -97,21 +99,23 @@ func PutTask(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter
 			return
 		}
 
-		var task models.Task
-		if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
-			logger.Warn("error on decoding body of request", sl.Err(err))
-			WriteNewResponceWithError(w, "invalid credentionals", http.StatusBadRequest, logger)
+		task, ok := decodeTask(w, r, idint64, services.ClockOf(st).Now(), logger)
+		if !ok {
 			return
 		}
-		task.ID = idint64
 
-		modifiedtask, err := services.UpdateTask(task, st, logger)
+		modifiedtask, err := services.UpdateTask(task, ActorFrom(r), st, logger)
 		if err != nil {
 			if errors.Is(err, storage.ErrNotFound) {
 				logger.Info("Not found record with this id")
 				WriteNewResponceWithError(w, notFound, http.StatusBadRequest, logger)
 				return
 			}
+			if errors.Is(err, storage.ErrExternalIDTaken) {
+				logger.Info("external id is taken")
+				WriteNewResponceWithError(w, err.Error(), http.StatusConflict, logger)
+				return
+			}
 			logger.Error("error on updating task", sl.Err(err))
 			WriteNewResponceWithError(w, internalError, http.StatusInternalServerError, logger)
 			return
@@ -139,7 +143,7 @@ func DeleteTask(st storage.Storage, logger *slog.Logger) func(w http.ResponseWri
 			return
 		}
 
-		err := services.DeleteTask(idint64, st, logger)
+		err := services.DeleteTask(idint64, ActorFrom(r), st, logger)
 		if err != nil {
 			if errors.Is(err, storage.ErrNotFound) {
 				logger.Info("Not found record with this id")
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+const (
+	ActorHeader = "X-Actor"
+	maxActorLen = 200
+)
+
+func ActorFrom(r *http.Request) string {
+	actor := strings.TrimSpace(r.Header.Get(ActorHeader))
+	if actor == "" {
+		return services.ActorAnonymous
+	}
+	if len(actor) > maxActorLen {
+		actor = actor[:maxActorLen]
+	}
+	return actor
+}
+
+func GetTaskByID(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "GET.tasks.id"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		idint64, ok := id.ValidateID(r.PathValue("id"))
+		if !ok {
+			logger.Info("putted wrong id")
+			WriteNewResponceWithError(w, "invalid id", http.StatusBadRequest, logger)
+			return
+		}
+
+		var (
+			task models.Task
+			err  error
+		)
+		if raw := r.URL.Query().Get("as_of"); raw != "" {
+			asOf, parseErr := time.Parse(time.RFC3339Nano, raw)
+			if parseErr != nil {
+				logger.Info("putted wrong as_of", slog.String("as_of", raw))
+				WriteNewResponceWithError(w, "as_of must be an RFC 3339 timestamp", http.StatusBadRequest, logger)
+				return
+			}
+			task, err = services.GetTaskAsOf(idint64, asOf, st, logger)
+		} else {
+			task, err = services.GetTask(idint64, st, logger)
+			if err == nil && task.DeletedAt != nil {
+				err = storage.ErrNotFound
+			}
+		}
+		if err != nil {
+			writeTaskLookupError(w, err, logger)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(task); err != nil {
+			logger.Error("error on encoding task to json", sl.Err(err))
+		}
+	}
+}
+
+func GetTaskHistory(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "GET.tasks.id.history"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		idint64, ok := id.ValidateID(r.PathValue("id"))
+		if !ok {
+			logger.Info("putted wrong id")
+			WriteNewResponceWithError(w, "invalid id", http.StatusBadRequest, logger)
+			return
+		}
+
+		events, err := services.GetTaskHistory(idint64, st, logger)
+		if err != nil {
+			writeTaskLookupError(w, err, logger)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(events); err != nil {
+			logger.Error("error on encoding history to json", sl.Err(err))
+		}
+	}
+}
+
+func writeTaskLookupError(w http.ResponseWriter, err error, logger *slog.Logger) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		logger.Info("Not found record with this id")
+		WriteNewResponceWithError(w, notFound, http.StatusNotFound, logger)
+	case errors.Is(err, services.ErrHistoryUnsupported):
+		logger.Info("history is not supported by storage")
+		WriteNewResponceWithError(w, err.Error(), http.StatusNotImplemented, logger)
+	default:
+		logger.Error("error on getting task", sl.Err(err))
+		WriteNewResponceWithError(w, internalError, http.StatusInternalServerError, logger)
+	}
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/domain/server"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/lib/taskio"
+	"github.com/gintokos/tasksrestapi/internal/lib/validator"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+const (
+	maxImportRows      = 10000
+	maxImportBodyBytes = 16 << 20
+)
+
+func ExportTasks(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "GET.tasks.export"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		format := taskio.FormatJSONL
+		if raw := r.URL.Query().Get("format"); raw != "" {
+			parsed, err := taskio.ParseFormat(raw)
+			if err != nil {
+				logger.Info("putted wrong export format", slog.String("format", raw))
+				WriteNewResponceWithError(w, err.Error(), http.StatusBadRequest, logger)
+				return
+			}
+			format = parsed
+		}
+
+		tasks, err := services.GetallTasks(st, logger)
+		if err != nil {
+			logger.Error("error on getting all tasks", sl.Err(err))
+			WriteNewResponceWithError(w, internalError, http.StatusInternalServerError, logger)
+			return
+		}
+
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks.%s"`, format))
+
+		tw, err := taskio.NewWriter(format, w)
+		if err != nil {
+			logger.Error("error on creating export writer", sl.Err(err))
+			return
+		}
+		for _, task := range tasks {
+			if err := tw.Write(task); err != nil {
+				logger.Error("error on writing exported task", sl.Err(err))
+				return
+			}
+		}
+		if err := tw.Close(); err != nil {
+			logger.Error("error on finishing export", sl.Err(err))
+		}
+	}
+}
+
+func ImportTasks(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "POST.tasks.import"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		format, ok := importFormat(r)
+		if !ok {
+			logger.Info("putted wrong import format")
+			WriteNewResponceWithError(w, taskio.ErrUnknownFormat.Error(), http.StatusBadRequest, logger)
+			return
+		}
+
+		dryRun := false
+		if raw := r.URL.Query().Get("dry_run"); raw != "" {
+			parsed, err := strconv.ParseBool(raw)
+			if err != nil {
+				logger.Info("putted wrong dry_run option", slog.String("dry_run", raw))
+				WriteNewResponceWithError(w, "dry_run must be true or false", http.StatusBadRequest, logger)
+				return
+			}
+			dryRun = parsed
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportBodyBytes)
+		records, err := taskio.Read(format, r.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				WriteNewResponceWithError(w, fmt.Sprintf("body must not exceed %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge, logger)
+				return
+			}
+			logger.Info("error on reading import", sl.Err(err))
+			WriteNewResponceWithError(w, fmt.Sprintf("invalid %s: %s", format, err.Error()), http.StatusBadRequest, logger)
+			return
+		}
+		if len(records) == 0 || len(records) > maxImportRows {
+			logger.Info("import has wrong size", slog.Int("size", len(records)))
+			WriteNewResponceWithError(w, fmt.Sprintf("import must contain between 1 and %d tasks", maxImportRows), http.StatusBadRequest, logger)
+			return
+		}
+
+		resp := server.ImportResponce{Format: string(format), DryRun: dryRun, Rows: make([]server.ImportRowResult, len(records))}
+		tasks := make([]models.Task, len(records))
+		failed := false
+		seen := make(map[string]int, len(records))
+		now := services.ClockOf(st).Now()
+		for i, rec := range records {
+			row := &resp.Rows[i]
+			row.Row = rec.Row
+			row.ExternalID = rec.Task.ExternalID
+
+			if rec.Err != nil {
+				row.Err = rec.Err.Error()
+				failed = true
+				continue
+			}
+
+			task, violations := validateTask(taskRequest{
+				ExternalID:  rec.Task.ExternalID,
+				Title:       rec.Task.Title,
+				Description: rec.Task.Description,
+				DueDate:     dueDateFrom(rec.Task.DueDate),
+				DueKind:     rec.Task.DueKind,
+				TimeZone:    rec.Task.TimeZone,
+			}, 0, r.Header.Get(TimeZoneHeader), now)
+			if first, ok := seen[task.ExternalID]; ok && task.ExternalID != "" {
+				violations = append(violations, validator.Violation{Field: "externalId", Msg: fmt.Sprintf("duplicates row %d", first)})
+			}
+			seen[task.ExternalID] = rec.Row
+			if len(violations) > 0 {
+				row.Err = "validation failed"
+				row.Violations = violations
+				failed = true
+				continue
+			}
+			tasks[i] = task
+		}
+
+		if failed {
+			logger.Info("import rejected on validation")
+			writeImportResponce(w, http.StatusUnprocessableEntity, resp, logger)
+			return
+		}
+
+		ops, results, err := services.ImportTasks(tasks, dryRun, ActorFrom(r), st, logger)
+		if err != nil {
+			if errors.Is(err, services.ErrDuplicateExternalID) {
+				logger.Info("import has duplicate external ids", sl.Err(err))
+				WriteNewResponceWithError(w, err.Error(), http.StatusUnprocessableEntity, logger)
+				return
+			}
+			logger.Error("error on importing tasks", sl.Err(err))
+			WriteNewResponceWithError(w, internalError, http.StatusInternalServerError, logger)
+			return
+		}
+
+		for i, op := range ops {
+			resp.Rows[i].Action = string(op.Op)
+			resp.Rows[i].ID = op.Task.ID
+			if op.Op == models.BatchCreate {
+				resp.Created++
+			} else {
+				resp.Updated++
+			}
+		}
+		if dryRun {
+			writeImportResponce(w, http.StatusOK, resp, logger)
+			return
+		}
+
+		resp.Committed = true
+		for i, res := range results {
+			if res.Err != nil {
+				resp.Committed = false
+				resp.Rows[i].Err = res.Err.Error()
+				continue
+			}
+			resp.Rows[i].ID = res.Task.ID
+		}
+		if !resp.Committed {
+			// A task matched by external id was deleted between planning and applying the import.
+			logger.Info("import rolled back")
+			resp.Created, resp.Updated = 0, 0
+			writeImportResponce(w, http.StatusConflict, resp, logger)
+			return
+		}
+
+		writeImportResponce(w, http.StatusOK, resp, logger)
+	}
+}
+
+// importFormat takes the format from the query, falling back to the Content-Type of the body.
+func importFormat(r *http.Request) (taskio.Format, bool) {
+	if raw := r.URL.Query().Get("format"); raw != "" {
+		format, err := taskio.ParseFormat(raw)
+		return format, err == nil
+	}
+
+	mediatype, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediatype {
+	case "text/csv":
+		return taskio.FormatCSV, true
+	case "text/calendar":
+		return taskio.FormatICS, true
+	case "application/x-ndjson", "application/jsonl", "application/json", "":
+		return taskio.FormatJSONL, true
+	default:
+		return "", false
+	}
+}
+
+func writeImportResponce(w http.ResponseWriter, status int, resp server.ImportResponce, logger *slog.Logger) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Error("error on encoding import responce to json", sl.Err(err))
+	}
+}
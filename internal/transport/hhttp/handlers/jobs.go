@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+)
+
+// JobLister is the view of the background job scheduler the admin API needs.
+type JobLister interface {
+	Statuses() []models.JobStatus
+}
+
+func GetJobs(jobs JobLister, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "GET.admin.jobs"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		if jobs == nil {
+			WriteNewResponceWithError(w, "background jobs are not running", http.StatusNotImplemented, logger)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := json.NewEncoder(w).Encode(jobs.Statuses()); err != nil {
+			logger.Error("error on encoding job statuses to json", sl.Err(err))
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+const (
+	defaultQueuedJobsLimit = 100
+	maxQueuedJobsLimit     = 1000
+)
+
+func GetQueuedJobs(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "GET.admin.queue"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		query := r.URL.Query()
+		filter := models.QueuedJobFilter{
+			State: models.QueuedJobState(query.Get("state")),
+			Kind:  query.Get("kind"),
+			Limit: defaultQueuedJobsLimit,
+		}
+		switch filter.State {
+		case "", models.JobPending, models.JobRunning, models.JobDone, models.JobDead, models.JobCancelled:
+		default:
+			logger.Info("putted wrong state", slog.String("state", string(filter.State)))
+			WriteNewResponceWithError(w, "state must be one of pending, running, done, dead, cancelled", http.StatusBadRequest, logger)
+			return
+		}
+		if raw := query.Get("after_id"); raw != "" {
+			afterID, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || afterID < 0 {
+				logger.Info("putted wrong after_id", slog.String("after_id", raw))
+				WriteNewResponceWithError(w, "after_id must be a job id", http.StatusBadRequest, logger)
+				return
+			}
+			filter.AfterID = afterID
+		}
+		if raw := query.Get("limit"); raw != "" {
+			limit, err := strconv.Atoi(raw)
+			if err != nil || limit < 1 || limit > maxQueuedJobsLimit {
+				logger.Info("putted wrong limit", slog.String("limit", raw))
+				WriteNewResponceWithError(w, fmt.Sprintf("limit must be between 1 and %d", maxQueuedJobsLimit), http.StatusBadRequest, logger)
+				return
+			}
+			filter.Limit = limit
+		}
+
+		jobs, err := services.GetQueuedJobs(filter, st, logger)
+		if err != nil {
+			writeQueuedJobError(w, err, logger)
+			return
+		}
+		if jobs == nil {
+			jobs = []models.QueuedJob{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := json.NewEncoder(w).Encode(jobs); err != nil {
+			logger.Error("error on encoding queued jobs to json", sl.Err(err))
+		}
+	}
+}
+
+func GetQueuedJob(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return queuedJobHandler("GET.admin.queue.id", services.GetQueuedJob, st, logger)
+}
+
+func RetryQueuedJob(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return queuedJobHandler("POST.admin.queue.id.retry", services.RetryQueuedJob, st, logger)
+}
+
+func CancelQueuedJob(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return queuedJobHandler("POST.admin.queue.id.cancel", services.CancelQueuedJob, st, logger)
+}
+
+// queuedJobHandler serves the endpoints that act on one job and answer with its new state.
+func queuedJobHandler(op string, action func(int64, storage.Storage, *slog.Logger) (models.QueuedJob, error), st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		idint64, ok := id.ValidateID(r.PathValue("id"))
+		if !ok {
+			logger.Info("putted wrong id")
+			WriteNewResponceWithError(w, "invalid id", http.StatusBadRequest, logger)
+			return
+		}
+
+		job, err := action(idint64, st, logger)
+		if err != nil {
+			writeQueuedJobError(w, err, logger)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := json.NewEncoder(w).Encode(job); err != nil {
+			logger.Error("error on encoding queued job to json", sl.Err(err))
+		}
+	}
+}
+
+func writeQueuedJobError(w http.ResponseWriter, err error, logger *slog.Logger) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		logger.Info("Not found queued job with this id")
+		WriteNewResponceWithError(w, notFound, http.StatusNotFound, logger)
+	case errors.Is(err, storage.ErrJobState):
+		logger.Info("queued job is in the wrong state")
+		WriteNewResponceWithError(w, err.Error(), http.StatusConflict, logger)
+	case errors.Is(err, services.ErrQueueUnsupported):
+		logger.Info("job queue is not supported by storage")
+		WriteNewResponceWithError(w, err.Error(), http.StatusNotImplemented, logger)
+	default:
+		logger.Error("error on handling queued job", sl.Err(err))
+		WriteNewResponceWithError(w, internalError, http.StatusInternalServerError, logger)
+	}
+}
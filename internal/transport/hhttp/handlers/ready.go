@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/server"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+func Ready(st storage.Storage, instanceID string, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "GET.readyz"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		leadership, err := services.GetLeadership(instanceID, services.ClockOf(st).Now(), st, logger)
+		if err != nil {
+			logger.Error("error on reading leadership", sl.Err(err))
+			WriteNewResponceWithError(w, "storage unavailable", http.StatusServiceUnavailable, logger)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := json.NewEncoder(w).Encode(server.ReadyResponce{Status: "ready", Leader: leadership}); err != nil {
+			logger.Error("error on encoding readiness to json", sl.Err(err))
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/lib/validator"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+const maxReminderLead = 365 * 24 * 60 * 60
+
+type reminderRequest struct {
+	LeadSeconds int64 `json:"leadSeconds"`
+}
+
+var reminderRules = []validator.Rule[reminderRequest]{
+	{
+		Field: "leadSeconds",
+		Check: func(req reminderRequest) string {
+			if req.LeadSeconds <= 0 || req.LeadSeconds > maxReminderLead {
+				return fmt.Sprintf("must be between 1 and %d", maxReminderLead)
+			}
+			return ""
+		},
+	},
+}
+
+func GetTaskReminders(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "GET.tasks.id.reminders"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		idint64, ok := id.ValidateID(r.PathValue("id"))
+		if !ok {
+			logger.Info("putted wrong id")
+			WriteNewResponceWithError(w, "invalid id", http.StatusBadRequest, logger)
+			return
+		}
+
+		reminders, err := services.GetTaskReminders(idint64, st, logger)
+		if err != nil {
+			writeReminderError(w, err, logger)
+			return
+		}
+		if reminders == nil {
+			reminders = []models.Reminder{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(reminders); err != nil {
+			logger.Error("error on encoding reminders to json", sl.Err(err))
+		}
+	}
+}
+
+func PostTaskReminder(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "POST.tasks.id.reminders"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		idint64, ok := id.ValidateID(r.PathValue("id"))
+		if !ok {
+			logger.Info("putted wrong id")
+			WriteNewResponceWithError(w, "invalid id", http.StatusBadRequest, logger)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+		var req reminderRequest
+		if ok := decodeJSON(w, r, &req, logger); !ok {
+			return
+		}
+		if violations := validator.Validate(req, reminderRules); len(violations) > 0 {
+			logger.Info("reminder failed validation", slog.String("violations", violations.Error()))
+			WriteNewResponceWithViolations(w, violations, logger)
+			return
+		}
+
+		reminder, err := services.CreateReminder(idint64, req.LeadSeconds, services.ClockOf(st).Now(), st, logger)
+		if err != nil {
+			writeReminderError(w, err, logger)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(reminder); err != nil {
+			logger.Error("error on encoding reminder to json", sl.Err(err))
+		}
+	}
+}
+
+func DeleteTaskReminder(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "DELETE.tasks.id.reminders.id"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		taskID, ok := id.ValidateID(r.PathValue("id"))
+		if !ok {
+			logger.Info("putted wrong id")
+			WriteNewResponceWithError(w, "invalid id", http.StatusBadRequest, logger)
+			return
+		}
+		reminderID, ok := id.ValidateID(r.PathValue("reminderId"))
+		if !ok {
+			logger.Info("putted wrong reminder id")
+			WriteNewResponceWithError(w, "invalid reminder id", http.StatusBadRequest, logger)
+			return
+		}
+
+		if err := services.DeleteReminder(taskID, reminderID, st, logger); err != nil {
+			writeReminderError(w, err, logger)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeReminderError(w http.ResponseWriter, err error, logger *slog.Logger) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		logger.Info("Not found record with this id")
+		WriteNewResponceWithError(w, notFound, http.StatusNotFound, logger)
+	case errors.Is(err, storage.ErrReminderExists):
+		logger.Info("reminder already exists")
+		WriteNewResponceWithError(w, err.Error(), http.StatusConflict, logger)
+	case errors.Is(err, services.ErrRemindersUnsupported):
+		logger.Info("reminders are not supported by storage")
+		WriteNewResponceWithError(w, err.Error(), http.StatusNotImplemented, logger)
+	default:
+		logger.Error("error on handling reminder", sl.Err(err))
+		WriteNewResponceWithError(w, internalError, http.StatusInternalServerError, logger)
+	}
+}
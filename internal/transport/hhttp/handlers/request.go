@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/domain/server"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/lib/validator"
+	"github.com/gintokos/tasksrestapi/internal/services"
+)
+
+const (
+	maxBodyBytes      = 1 << 20
+	maxTitleLen       = 200
+	maxExternalIDLen  = 255
+	maxDescriptionLen = 10000
+	maxDueDateAhead   = 100 * 365 * 24 * time.Hour
+)
+
+var minDueDate = time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// TimeZoneHeader gives the IANA zone used for tasks that do not name one themselves.
+const TimeZoneHeader = "X-Timezone"
+
+type taskRequest struct {
+	ID          *int64         `json:"id"`
+	ExternalID  string         `json:"externalId"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	DueDate     *dueDateValue  `json:"dueDate"`
+	DueKind     models.DueKind `json:"dueKind"`
+	TimeZone    string         `json:"timeZone"`
+	OverDue     *bool          `json:"overDue"`
+
+	pathID int64
+	now    time.Time
+}
+
+// dueDateValue accepts RFC 3339 timestamps and plain dates.
+type dueDateValue struct {
+	time.Time
+	dateOnly bool
+}
+
+func (d *dueDateValue) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		d.Time, d.dateOnly = t, true
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return fmt.Errorf("dueDate must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+	}
+	d.Time = t
+	return nil
+}
+
+func dueDateFrom(t *time.Time) *dueDateValue {
+	if t == nil {
+		return nil
+	}
+	return &dueDateValue{Time: *t}
+}
+
+func (tr taskRequest) toTask() models.Task {
+	task := models.Task{
+		ID:          tr.pathID,
+		ExternalID:  tr.ExternalID,
+		Title:       tr.Title,
+		Description: tr.Description,
+		DueKind:     tr.DueKind,
+		TimeZone:    tr.TimeZone,
+	}
+	if tr.DueDate != nil {
+		due := tr.DueDate.Time
+		task.DueDate = &due
+		if task.DueKind == "" && tr.DueDate.dateOnly {
+			task.DueKind = models.DueAllDay
+		}
+	}
+	return task
+}
+
+var taskRules = []validator.Rule[taskRequest]{
+	validator.ReadOnly("id", func(tr taskRequest) bool {
+		return tr.ID != nil && *tr.ID != 0 && *tr.ID != tr.pathID
+	}),
+	validator.MaxLen("externalId", maxExternalIDLen, func(tr taskRequest) string { return tr.ExternalID }),
+	validator.Required("title", func(tr taskRequest) string { return tr.Title }),
+	validator.MaxLen("title", maxTitleLen, func(tr taskRequest) string { return tr.Title }),
+	validator.MaxLen("description", maxDescriptionLen, func(tr taskRequest) string { return tr.Description }),
+	{
+		Field: "dueKind",
+		Check: func(tr taskRequest) string {
+			switch tr.DueKind {
+			case "", models.DueInstant, models.DueAllDay:
+				return ""
+			default:
+				return fmt.Sprintf("must be %s or %s", models.DueInstant, models.DueAllDay)
+			}
+		},
+	},
+	{
+		Field: "timeZone",
+		Check: func(tr taskRequest) string {
+			if tr.TimeZone == "" {
+				return ""
+			}
+			if _, err := time.LoadLocation(tr.TimeZone); err != nil {
+				return "must be an IANA time zone such as Europe/Berlin"
+			}
+			return ""
+		},
+	},
+	{
+		Field: "dueDate",
+		Check: func(tr taskRequest) string {
+			if tr.DueDate == nil {
+				return ""
+			}
+			if tr.DueDate.Before(minDueDate) {
+				return fmt.Sprintf("must not be before %s", minDueDate.Format(time.DateOnly))
+			}
+			if tr.DueDate.After(tr.now.Add(maxDueDateAhead)) {
+				return "must not be more than 100 years in the future"
+			}
+			return ""
+		},
+	},
+	{
+		Field: "overDue",
+		Check: func(tr taskRequest) string {
+			// Echoing back the derived value is fine, anything else is an attempt to set it.
+			if tr.OverDue != nil && *tr.OverDue != services.IsOverdue(tr.toTask(), tr.now) {
+				return "is derived from dueDate and cannot be set"
+			}
+			return ""
+		},
+	},
+}
+
+// decodeTask writes the error response itself on failure.
+func decodeTask(w http.ResponseWriter, r *http.Request, pathID int64, now time.Time, logger *slog.Logger) (models.Task, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+	var req taskRequest
+	if ok := decodeJSON(w, r, &req, logger); !ok {
+		return models.Task{}, false
+	}
+
+	task, violations := validateTask(req, pathID, r.Header.Get(TimeZoneHeader), now)
+	if len(violations) > 0 {
+		logger.Info("task failed validation", slog.String("violations", violations.Error()))
+		WriteNewResponceWithViolations(w, violations, logger)
+		return models.Task{}, false
+	}
+
+	return task, true
+}
+
+func validateTask(req taskRequest, pathID int64, zone string, now time.Time) (models.Task, validator.Violations) {
+	req.pathID = pathID
+	req.now = now
+	if req.TimeZone == "" {
+		req.TimeZone = strings.TrimSpace(zone)
+	}
+	return req.toTask(), validator.Validate(req, taskRules)
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}, logger *slog.Logger) bool {
+	err := decodeStrict(r.Body, dst)
+	if err == nil {
+		return true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		logger.Warn("request body is too large", sl.Err(err))
+		WriteNewResponceWithError(w, fmt.Sprintf("body must not exceed %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge, logger)
+		return false
+	}
+
+	logger.Warn("error on decoding body of request", sl.Err(err))
+	WriteNewResponceWithError(w, fmt.Sprintf("invalid credentionals: %s", err.Error()), http.StatusBadRequest, logger)
+	return false
+}
+
+func decodeStrict(r io.Reader, dst interface{}) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(dst)
+	if errors.Is(err, io.EOF) {
+		return errors.New("body must not be empty")
+	}
+	if err == nil && decoder.More() {
+		return errors.New("body must contain a single JSON value")
+	}
+	return err
+}
+
+func WriteNewResponceWithViolations(w http.ResponseWriter, violations validator.Violations, logger *slog.Logger) {
+	logger.Info("http-server.handlers.WriteNewResponceWithViolations")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	resp := server.ResponceWithViolations{
+		Msg:        "error",
+		Err:        "validation failed",
+		Violations: violations,
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Error("error on encoding violations responce to json", sl.Err(err))
+	}
+}
@@ -0,0 +1,131 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/config.go"
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/domain/server"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+	"github.com/gintokos/tasksrestapi/internal/transport/hhttp"
+	"github.com/gintokos/tasksrestapi/internal/transport/hhttp/handlers"
+)
+
+func TestCalendarFeed(t *testing.T) {
+	logger := slog.Default()
+
+	st, err := sqllite.NewStorage(filepath.Join(t.TempDir(), "storage.db"), id.NewRandomGenerator(), sqllite.Options{})
+	if err != nil {
+		t.Fatalf("error on creating storage: %v", err)
+	}
+	defer st.Close()
+
+	due := time.Now().Add(24 * time.Hour)
+	if _, err := services.CreateNewTask(models.Task{Title: "Due soon", DueDate: &due}, "alice", st, logger); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := services.CreateNewTask(models.Task{Title: "Someday"}, "alice", st, logger); err != nil {
+		t.Fatal(err)
+	}
+
+	router := hhttp.NewRouter(st, nil, nil, logger, config.Config{})
+	do := func(method, target string, body []byte, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewReader(body))
+		req.Header.Set(handlers.ActorHeader, "alice")
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodPut, "/calendar/feed", []byte(`{"components": ["VTODO", "VEVENT"]}`), nil)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body)
+	}
+	var created server.CalendarFeedResponce
+	if err := json.NewDecoder(rr.Body).Decode(&created); err != nil {
+		t.Fatalf("error decoding response: %v", err)
+	}
+	if created.Token == "" || !strings.HasSuffix(created.URL, "/calendar/"+created.Token+".ics") {
+		t.Fatalf("expected token and subscription url, got %+v", created)
+	}
+
+	bearer := http.Header{"Authorization": {"Bearer " + created.Token}}
+	if rr := do(http.MethodGet, "/calendar/feed", nil, nil); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status %d without the feed token, got %d", http.StatusUnauthorized, rr.Code)
+	}
+	if rr := do(http.MethodGet, "/calendar/feed", nil, bearer); rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d with the feed token, got %d", http.StatusOK, rr.Code)
+	}
+	// Anyone can send X-Actor: alice, so it must not be enough to touch her feed.
+	if rr := do(http.MethodPut, "/calendar/feed", []byte(`{"components": ["VTODO"]}`), nil); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status %d for changing an existing feed without its token, got %d", http.StatusUnauthorized, rr.Code)
+	}
+	if rr := do(http.MethodPost, "/calendar/feed/token", nil, nil); rr.Code != http.StatusUnauthorized || strings.Contains(rr.Body.String(), `"token"`) {
+		t.Fatalf("expected rotation without the feed token to be refused, got %d: %s", rr.Code, rr.Body)
+	}
+
+	feedPath := "/calendar/" + created.Token + ".ics"
+	rr = do(http.MethodGet, feedPath, nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, "SUMMARY:Due soon") || strings.Contains(body, "Someday") {
+		t.Fatalf("expected only the task with a due date, got:\n%s", body)
+	}
+	if !strings.Contains(body, "BEGIN:VTODO") || !strings.Contains(body, "BEGIN:VEVENT") {
+		t.Fatalf("expected both VTODO and VEVENT, got:\n%s", body)
+	}
+	etag := rr.Header().Get("ETag")
+	if etag == "" || rr.Header().Get("Last-Modified") == "" {
+		t.Fatalf("expected ETag and Last-Modified headers, got %v", rr.Header())
+	}
+
+	rr = do(http.MethodGet, feedPath, nil, http.Header{"If-None-Match": {etag}})
+	if rr.Code != http.StatusNotModified {
+		t.Fatalf("expected status %d for matching ETag, got %d", http.StatusNotModified, rr.Code)
+	}
+
+	rr = do(http.MethodPost, "/calendar/feed/token", nil, bearer)
+	var regenerated server.CalendarFeedResponce
+	if err := json.NewDecoder(rr.Body).Decode(&regenerated); err != nil {
+		t.Fatalf("error decoding response: %v", err)
+	}
+	if regenerated.Token == "" || regenerated.Token == created.Token {
+		t.Fatalf("expected a new token, got %+v", regenerated)
+	}
+	if rr := do(http.MethodGet, feedPath, nil, nil); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected old token to stop working, got %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/calendar/"+regenerated.Token+".ics", nil, nil); rr.Code != http.StatusOK {
+		t.Fatalf("expected new token to work, got %d", rr.Code)
+	}
+
+	// An owner who lost the token revokes the feed by actor and starts over with a new one.
+	if rr := do(http.MethodDelete, "/calendar/feed", nil, nil); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d for revoking the feed, got %d: %s", http.StatusNoContent, rr.Code, rr.Body)
+	}
+	if rr := do(http.MethodGet, "/calendar/"+regenerated.Token+".ics", nil, nil); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected the revoked token to stop working, got %d", rr.Code)
+	}
+	if rr := do(http.MethodDelete, "/calendar/feed", nil, nil); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d for revoking a missing feed, got %d", http.StatusNotFound, rr.Code)
+	}
+	rr = do(http.MethodPut, "/calendar/feed", []byte(`{"components": ["VTODO"]}`), nil)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected a new feed after revoking, got %d: %s", rr.Code, rr.Body)
+	}
+}
@@ -7,10 +7,20 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gintokos/tasksrestapi/internal/config.go"
 	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/domain/server"
+	"github.com/gintokos/tasksrestapi/internal/lib/clock/fakeclock"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/services"
 	mocks "github.com/gintokos/tasksrestapi/internal/storage/mock"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+	"github.com/gintokos/tasksrestapi/internal/transport/hhttp"
 	"github.com/gintokos/tasksrestapi/internal/transport/hhttp/handlers"
 )
 
@@ -53,7 +63,6 @@ func TestPostTask(t *testing.T) {
 	handler := handlers.PostTask(mockStorage, logger)
 
 	newTask := models.Task{
-		ID:          1,
 		Title:       "New Task",
 		Description: "Description for new task",
 	}
@@ -185,3 +194,507 @@ func TestGetTask_NotFound(t *testing.T) {
 		t.Fatalf("expected status %d, got %d", http.StatusNotFound, rr.Code)
 	}
 }
+
+func TestPostTask_Validation(t *testing.T) {
+	logger := slog.Default()
+
+	mockStorage := mocks.NewMockStorage([]models.Task{})
+
+	handler := handlers.PostTask(mockStorage, logger)
+
+	body := []byte(`{"id": 5, "title": " ", "description": "", "overDue": true}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	handler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	var resp server.ResponceWithViolations
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("error decoding response: %v", err)
+	}
+
+	fields := map[string]bool{}
+	for _, v := range resp.Violations {
+		fields[v.Field] = true
+	}
+	for _, field := range []string{"id", "title", "overDue"} {
+		if !fields[field] {
+			t.Errorf("expected violation for field %q, got %+v", field, resp.Violations)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewReader([]byte(`{"title": "Task", "priority": 1}`)))
+	rr = httptest.NewRecorder()
+
+	handler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for unknown field, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	huge := fmt.Sprintf(`{"title": "Task", "description": %q}`, strings.Repeat("a", 2<<20))
+	req = httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(huge))
+	rr = httptest.NewRecorder()
+
+	handler(rr, req)
+
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status %d for huge body, got %d", http.StatusRequestEntityTooLarge, rr.Code)
+	}
+}
+
+func TestBatchTasks(t *testing.T) {
+	logger := slog.Default()
+
+	mockStorage := mocks.NewMockStorage([]models.Task{
+		{ID: 1, Title: "Task 1"},
+	})
+
+	router := hhttp.NewRouter(mockStorage, nil, nil, logger, config.Config{})
+
+	body := []byte(`[
+		{"op": "create", "task": {"title": "New Task"}},
+		{"op": "update", "id": 1, "task": {"title": "Updated Task"}},
+		{"op": "delete", "id": 42}
+	]`)
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks:batch?atomic=true", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var resp server.BatchResponce
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("error decoding response: %v", err)
+	}
+
+	if resp.Committed {
+		t.Fatalf("expected atomic batch to be rolled back")
+	}
+	wantStatuses := []int{http.StatusFailedDependency, http.StatusFailedDependency, http.StatusNotFound}
+	for i, want := range wantStatuses {
+		if resp.Results[i].Status != want {
+			t.Errorf("result %d: expected status %d, got %d", i, want, resp.Results[i].Status)
+		}
+	}
+
+	tasks, _ := mockStorage.GetAllTasks(logger)
+	if len(tasks) != 1 || tasks[0].Title != "Task 1" {
+		t.Fatalf("expected storage to be untouched, got %+v", tasks)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/tasks:batch?atomic=false", bytes.NewReader(body))
+	rr = httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	resp = server.BatchResponce{}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("error decoding response: %v", err)
+	}
+
+	wantStatuses = []int{http.StatusCreated, http.StatusOK, http.StatusNotFound}
+	for i, want := range wantStatuses {
+		if resp.Results[i].Status != want {
+			t.Errorf("result %d: expected status %d, got %d", i, want, resp.Results[i].Status)
+		}
+	}
+
+	tasks, _ = mockStorage.GetAllTasks(logger)
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks after non-atomic batch, got %d", len(tasks))
+	}
+}
+
+func TestTrash(t *testing.T) {
+	logger := slog.Default()
+
+	mockStorage := mocks.NewMockStorage([]models.Task{
+		{ID: 1, Title: "Task 1"},
+		{ID: 2, Title: "Task 2"},
+	})
+
+	router := hhttp.NewRouter(mockStorage, nil, nil, logger, config.Config{})
+
+	do := func(method, target string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, target, nil))
+		return rr
+	}
+
+	if rr := do(http.MethodDelete, "/tasks/1"); rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var trash []models.Task
+	if err := json.NewDecoder(do(http.MethodGet, "/trash").Body).Decode(&trash); err != nil {
+		t.Fatalf("error decoding response: %v", err)
+	}
+	if len(trash) != 1 || trash[0].ID != 1 || trash[0].DeletedAt == nil {
+		t.Fatalf("expected task 1 in trash, got %+v", trash)
+	}
+
+	if rr := do(http.MethodPost, "/tasks/1/restore"); rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	tasks, _ := mockStorage.GetAllTasks(logger)
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks after restore, got %d", len(tasks))
+	}
+
+	if rr := do(http.MethodDelete, "/trash/2"); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d for live task, got %d", http.StatusNotFound, rr.Code)
+	}
+	do(http.MethodDelete, "/tasks/2")
+	if rr := do(http.MethodDelete, "/trash/2"); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+	if rr := do(http.MethodPost, "/tasks/2/restore"); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d for purged task, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestImportTasks(t *testing.T) {
+	logger := slog.Default()
+
+	mockStorage := mocks.NewMockStorage([]models.Task{
+		{ID: 1, ExternalID: "ext-1", Title: "Old title"},
+	})
+
+	router := hhttp.NewRouter(mockStorage, nil, nil, logger, config.Config{})
+
+	do := func(target, contentType, body string) (*httptest.ResponseRecorder, server.ImportResponce) {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		var resp server.ImportResponce
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("error decoding response: %v", err)
+		}
+		return rr, resp
+	}
+
+	csv := "externalId,title\next-1,New title\next-2,Created\n"
+
+	rr, resp := do("/tasks/import?dry_run=true", "text/csv", csv)
+	if rr.Code != http.StatusOK || resp.Committed || resp.Created != 1 || resp.Updated != 1 {
+		t.Fatalf("unexpected dry run result %d %+v", rr.Code, resp)
+	}
+	if resp.Rows[0].Action != "update" || resp.Rows[0].ID != 1 || resp.Rows[1].Action != "create" {
+		t.Fatalf("unexpected dry run plan %+v", resp.Rows)
+	}
+	if tasks, _ := mockStorage.GetAllTasks(logger); len(tasks) != 1 || tasks[0].Title != "Old title" {
+		t.Fatalf("expected dry run to leave storage untouched, got %+v", tasks)
+	}
+
+	rr, resp = do("/tasks/import", "text/csv", "externalId,title\next-3,Fine\next-4,\n")
+	if rr.Code != http.StatusUnprocessableEntity || resp.Committed {
+		t.Fatalf("expected invalid row to reject the import, got %d %+v", rr.Code, resp)
+	}
+	if resp.Rows[0].Err != "" || resp.Rows[1].Row != 2 || len(resp.Rows[1].Violations) == 0 {
+		t.Fatalf("expected a violation on row 2 only, got %+v", resp.Rows)
+	}
+
+	rr, resp = do("/tasks/import", "text/csv", csv)
+	if rr.Code != http.StatusOK || !resp.Committed {
+		t.Fatalf("expected import to commit, got %d %+v", rr.Code, resp)
+	}
+	tasks, _ := mockStorage.GetAllTasks(logger)
+	if len(tasks) != 2 || tasks[0].Title != "New title" {
+		t.Fatalf("expected ext-1 updated and ext-2 created, got %+v", tasks)
+	}
+}
+
+func TestExportTasks(t *testing.T) {
+	logger := slog.Default()
+
+	mockStorage := mocks.NewMockStorage([]models.Task{
+		{ID: 1, Title: "Task 1"},
+		{ID: 2, Title: "Task 2"},
+	})
+
+	router := hhttp.NewRouter(mockStorage, nil, nil, logger, config.Config{})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/tasks/export?format=ics", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+		t.Fatalf("expected text/calendar, got %s", ct)
+	}
+	if n := strings.Count(rr.Body.String(), "BEGIN:VTODO"); n != 2 {
+		t.Fatalf("expected 2 VTODOs, got %d", n)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/tasks/export?format=xml", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for unknown format, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestPutTask_OverdueIsDerived(t *testing.T) {
+	logger := slog.Default()
+
+	fc := fakeclock.New(time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC))
+	past := fc.Now().Add(-time.Hour)
+	mockStorage := mocks.NewMockStorage([]models.Task{
+		{ID: 1, Title: "Task 1", DueDate: &past, OverDue: true},
+	})
+	mockStorage.SetClock(fc)
+
+	router := hhttp.NewRouter(mockStorage, nil, nil, logger, config.Config{})
+	put := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/tasks/1", strings.NewReader(body)))
+		return rr
+	}
+
+	future := fc.Now().Add(24 * time.Hour).Format(time.RFC3339)
+	if rr := put(fmt.Sprintf(`{"title": "Task 1", "dueDate": %q, "overDue": true}`, future)); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d when setting overDue, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	rr := put(fmt.Sprintf(`{"title": "Task 1", "dueDate": %q, "overDue": false}`, future))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d when echoing the derived value, got %d: %s", http.StatusOK, rr.Code, rr.Body)
+	}
+
+	task, _ := mockStorage.GetTask(1, logger)
+	if task.OverDue {
+		t.Fatalf("expected overdue to be cleared after moving the due date forward")
+	}
+}
+
+func TestPostTask_AllDayDueDate(t *testing.T) {
+	logger := slog.Default()
+	mockStorage := mocks.NewMockStorage(nil)
+	router := hhttp.NewRouter(mockStorage, nil, nil, logger, config.Config{})
+
+	post := func(body, zone string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+		if zone != "" {
+			req.Header.Set(handlers.TimeZoneHeader, zone)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := post(`{"title": "Report", "dueDate": "2030-06-01"}`, "Europe/Berlin")
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d for a date-only due date, got %d: %s", http.StatusCreated, rr.Code, rr.Body)
+	}
+	var created models.Task
+	if err := json.NewDecoder(rr.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if created.DueKind != models.DueAllDay || created.TimeZone != "Europe/Berlin" || created.DueDate.UTC().Format(time.DateOnly) != "2030-06-01" {
+		t.Fatalf("expected an all-day task on 2030-06-01 in Europe/Berlin, got %+v", created)
+	}
+
+	if rr := post(`{"title": "Report", "dueDate": "2030-06-01", "timeZone": "Mars/Olympus"}`, ""); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for an unknown time zone, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if rr := post(`{"title": "Report", "dueDate": "2030-06-01T10:00:00+02:00", "dueKind": "week"}`, ""); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for an unknown due kind, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	logger := slog.Default()
+	router := hhttp.NewRouter(mocks.NewMockStorage(nil), nil, nil, logger, config.Config{Leader: config.LeaderConfig{ID: "node-1"}})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var resp server.ReadyResponce
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != "ready" || !resp.Leader.Leader || resp.Leader.ID != "node-1" {
+		t.Fatalf("expected a single instance to be ready and lead, got %+v", resp)
+	}
+}
+
+type staticJobs []models.JobStatus
+
+func (s staticJobs) Statuses() []models.JobStatus {
+	return s
+}
+
+func TestGetJobs(t *testing.T) {
+	logger := slog.Default()
+
+	rr := httptest.NewRecorder()
+	hhttp.NewRouter(mocks.NewMockStorage(nil), nil, nil, logger, config.Config{}).
+		ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/jobs", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Fatalf("expected status %d without a scheduler, got %d", http.StatusNotImplemented, rr.Code)
+	}
+
+	jobs := staticJobs{{Name: "overdue", Interval: 60, LeaderOnly: true, Runs: 3, LastError: "database is locked"}}
+	rr = httptest.NewRecorder()
+	hhttp.NewRouter(mocks.NewMockStorage(nil), jobs, nil, logger, config.Config{}).
+		ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/jobs", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var got []models.JobStatus
+	if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "overdue" || got[0].Runs != 3 || got[0].LastError != "database is locked" {
+		t.Fatalf("expected the scheduler's statuses, got %+v", got)
+	}
+}
+
+type staticConfig models.ConfigStatus
+
+func (s staticConfig) ConfigStatus() models.ConfigStatus {
+	return models.ConfigStatus(s)
+}
+
+func TestGetConfigStatus(t *testing.T) {
+	logger := slog.Default()
+
+	rr := httptest.NewRecorder()
+	hhttp.NewRouter(mocks.NewMockStorage(nil), nil, nil, logger, config.Config{}).
+		ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/config", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Fatalf("expected status %d without config reloading, got %d", http.StatusNotImplemented, rr.Code)
+	}
+
+	configs := staticConfig{Version: 3, Checksum: "abc", RestartRequired: []string{"serverConfig.port"}}
+	rr = httptest.NewRecorder()
+	hhttp.NewRouter(mocks.NewMockStorage(nil), nil, configs, logger, config.Config{}).
+		ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/config", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var got models.ConfigStatus
+	if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 3 || got.Checksum != "abc" || len(got.RestartRequired) != 1 {
+		t.Fatalf("expected the app's config status, got %+v", got)
+	}
+}
+
+func TestAdminQueue(t *testing.T) {
+	logger := slog.Default()
+
+	rr := httptest.NewRecorder()
+	hhttp.NewRouter(mocks.NewMockStorage(nil), nil, nil, logger, config.Config{}).
+		ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/queue", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Fatalf("expected status %d without a queue store, got %d", http.StatusNotImplemented, rr.Code)
+	}
+
+	st, err := sqllite.NewStorage(filepath.Join(t.TempDir(), "storage.db"), id.NewRandomGenerator(), sqllite.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	now := time.Now()
+	pending, _, err := services.EnqueueJob("send-reminder", map[string]int64{"reminderId": 1}, now, "", st, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead, _, err := services.EnqueueJob("deliver-webhook", nil, now, "", st, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.ClaimJobs("worker-1", now, now.Add(time.Minute), 2, logger); err != nil {
+		t.Fatal(err)
+	}
+	for _, job := range []models.QueuedJob{pending, dead} {
+		job.State, job.Attempts, job.UpdatedAt = models.JobPending, 1, now
+		if job.ID == dead.ID {
+			job.State, job.LastError, job.FinishedAt = models.JobDead, "connection refused", &now
+		}
+		if err := st.FinishJob(job, "worker-1", logger); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	router := hhttp.NewRouter(st, nil, nil, logger, config.Config{})
+	do := func(method, target string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, target, nil))
+		return rr
+	}
+
+	rr = do(http.MethodGet, "/admin/queue?state=dead")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body)
+	}
+	var listed []models.QueuedJob
+	if err := json.NewDecoder(rr.Body).Decode(&listed); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0].ID != dead.ID || listed[0].LastError != "connection refused" {
+		t.Fatalf("expected only the dead letter, got %+v", listed)
+	}
+
+	rr = do(http.MethodGet, fmt.Sprintf("/admin/queue?after_id=%d&limit=10", pending.ID))
+	if err := json.NewDecoder(rr.Body).Decode(&listed); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0].ID != dead.ID {
+		t.Fatalf("expected the page after the first job, got %+v", listed)
+	}
+
+	for _, target := range []string{"/admin/queue?state=stuck", "/admin/queue?limit=0", "/admin/queue?after_id=x"} {
+		if rr := do(http.MethodGet, target); rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected status %d for %s, got %d", http.StatusBadRequest, target, rr.Code)
+		}
+	}
+
+	if rr := do(http.MethodPost, fmt.Sprintf("/admin/queue/%d/retry", pending.ID)); rr.Code != http.StatusConflict {
+		t.Fatalf("expected status %d retrying a pending job, got %d", http.StatusConflict, rr.Code)
+	}
+	rr = do(http.MethodPost, fmt.Sprintf("/admin/queue/%d/retry", dead.ID))
+	var retried models.QueuedJob
+	if err := json.NewDecoder(rr.Body).Decode(&retried); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Code != http.StatusOK || retried.State != models.JobPending || retried.Attempts != 0 {
+		t.Fatalf("expected the dead job to be pending again, got %d %+v", rr.Code, retried)
+	}
+
+	rr = do(http.MethodPost, fmt.Sprintf("/admin/queue/%d/cancel", pending.ID))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body)
+	}
+	rr = do(http.MethodGet, fmt.Sprintf("/admin/queue/%d", pending.ID))
+	var got models.QueuedJob
+	if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.State != models.JobCancelled {
+		t.Fatalf("expected the job to be cancelled, got %+v", got)
+	}
+	if rr := do(http.MethodPost, "/admin/queue/999/cancel"); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d for a missing job, got %d", http.StatusNotFound, rr.Code)
+	}
+}
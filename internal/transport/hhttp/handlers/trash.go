@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+func GetTrash(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "GET.trash"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		tasks, err := services.GetTrash(st, logger)
+		if err != nil {
+			logger.Error("error on getting trash", sl.Err(err))
+			WriteNewResponceWithError(w, internalError, http.StatusInternalServerError, logger)
+			return
+		}
+		if tasks == nil {
+			tasks = []models.Task{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(tasks); err != nil {
+			logger.Error("error on encoding trash to json", sl.Err(err))
+		}
+	}
+}
+
+func RestoreTask(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "POST.tasks.id.restore"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		idint64, ok := id.ValidateID(r.PathValue("id"))
+		if !ok {
+			logger.Info("putted wrong id")
+			WriteNewResponceWithError(w, "invalid id", http.StatusBadRequest, logger)
+			return
+		}
+
+		task, err := services.RestoreTask(idint64, ActorFrom(r), st, logger)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				logger.Info("Not found trashed record with this id")
+				WriteNewResponceWithError(w, notFound, http.StatusNotFound, logger)
+				return
+			}
+			if errors.Is(err, storage.ErrExternalIDTaken) {
+				logger.Info("external id of trashed task is taken")
+				WriteNewResponceWithError(w, err.Error(), http.StatusConflict, logger)
+				return
+			}
+			logger.Error("error on restoring task", sl.Err(err))
+			WriteNewResponceWithError(w, internalError, http.StatusInternalServerError, logger)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(task); err != nil {
+			logger.Error("error on encoding restored task to json", sl.Err(err))
+		}
+	}
+}
+
+func PurgeTask(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "DELETE.trash.id"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		idint64, ok := id.ValidateID(r.PathValue("id"))
+		if !ok {
+			logger.Info("putted wrong id")
+			WriteNewResponceWithError(w, "invalid id", http.StatusBadRequest, logger)
+			return
+		}
+
+		err := services.PurgeTask(idint64, ActorFrom(r), st, logger)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				logger.Info("Not found trashed record with this id")
+				WriteNewResponceWithError(w, notFound, http.StatusNotFound, logger)
+				return
+			}
+			logger.Error("error on purging task", sl.Err(err))
+			WriteNewResponceWithError(w, internalError, http.StatusInternalServerError, logger)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gintokos/tasksrestapi/internal/domain/models"
+	"github.com/gintokos/tasksrestapi/internal/domain/server"
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/services"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+)
+
+func UndoTask(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "POST.tasks.id.undo"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		idint64, ok := id.ValidateID(r.PathValue("id"))
+		if !ok {
+			logger.Info("putted wrong id")
+			WriteNewResponceWithError(w, "invalid id", http.StatusBadRequest, logger)
+			return
+		}
+
+		task, event, err := services.UndoLast(idint64, ActorFrom(r), st, logger)
+		writeUndoResult(w, task, event, err, logger)
+	}
+}
+
+func UndoEvent(st storage.Storage, logger *slog.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op := "POST.undo.eventId"
+		logger.Info(fmt.Sprintf("op: %s", op))
+		defer r.Body.Close()
+
+		eventID, ok := id.ValidateID(r.PathValue("eventId"))
+		if !ok {
+			logger.Info("putted wrong event id")
+			WriteNewResponceWithError(w, "invalid event id", http.StatusBadRequest, logger)
+			return
+		}
+
+		task, event, err := services.UndoEvent(eventID, ActorFrom(r), st, logger)
+		writeUndoResult(w, task, event, err, logger)
+	}
+}
+
+func writeUndoResult(w http.ResponseWriter, task models.Task, event models.TaskEvent, err error, logger *slog.Logger) {
+	var conflictErr *services.UndoConflictError
+	switch {
+	case err == nil:
+	case errors.As(err, &conflictErr):
+		logger.Info("undo conflicts with a later event", slog.Int64("event", conflictErr.Conflicting.ID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		resp := server.ResponceWithConflict{
+			Msg:      "error",
+			Err:      services.ErrUndoConflict.Error(),
+			Conflict: conflictErr.Conflicting,
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			logger.Error("error on encoding conflict responce to json", sl.Err(err))
+		}
+		return
+	case errors.Is(err, services.ErrNotUndoable):
+		logger.Info("event cannot be undone", sl.Err(err))
+		WriteNewResponceWithError(w, err.Error(), http.StatusUnprocessableEntity, logger)
+		return
+	default:
+		writeTaskLookupError(w, err, logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(server.UndoResponce{Task: task, Event: event}); err != nil {
+		logger.Error("error on encoding undo responce to json", sl.Err(err))
+	}
+}
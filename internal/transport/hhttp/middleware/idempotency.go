@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/lib/logger/sl"
+	"github.com/gintokos/tasksrestapi/internal/storage"
+	"github.com/gintokos/tasksrestapi/internal/transport/hhttp/handlers"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLen      = 255
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+func Idempotent(store storage.IdempotencyStore, ttl time.Duration, logger *slog.Logger, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			handlers.WriteNewResponceWithError(w, fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLen), http.StatusBadRequest, logger)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestBytes))
+		r.Body.Close()
+		if err != nil {
+			logger.Warn("error on reading body of request", sl.Err(err))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				handlers.WriteNewResponceWithError(w, fmt.Sprintf("request body must be at most %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge, logger)
+				return
+			}
+			handlers.WriteNewResponceWithError(w, "error on reading request body", http.StatusBadRequest, logger)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		if c, ok := store.(storage.Clocked); ok {
+			now = c.Clock().Now()
+		}
+		// Keys are scoped by actor, so two clients picking the same key do not see each other's responses.
+		actor := handlers.ActorFrom(r)
+		key = fmt.Sprintf("%d:%s:%s", len(actor), actor, key)
+		rec := storage.IdempotencyRecord{
+			Key:         key,
+			Fingerprint: fingerprint(r, actor, body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+
+		existing, reserved, err := store.ReserveIdempotencyKey(rec, logger)
+		if err != nil {
+			logger.Error("error on reserving idempotency key", sl.Err(err))
+			handlers.WriteNewResponceWithError(w, "internal error", http.StatusInternalServerError, logger)
+			return
+		}
+		if !reserved {
+			replay(w, rec, existing, logger)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			if p := recover(); p != nil {
+				if err := store.ReleaseIdempotencyKey(key, logger); err != nil {
+					logger.Error("error on releasing idempotency key", sl.Err(err))
+				}
+				panic(p)
+			}
+		}()
+		next(recorder, r)
+
+		if recorder.status >= http.StatusInternalServerError {
+			if err := store.ReleaseIdempotencyKey(key, logger); err != nil {
+				logger.Error("error on releasing idempotency key", sl.Err(err))
+			}
+			return
+		}
+		// Without a Content-Type net/http sniffs one, so store what the client was sent.
+		contentType := recorder.Header().Get("Content-Type")
+		if contentType == "" && recorder.body.Len() > 0 {
+			contentType = http.DetectContentType(recorder.body.Bytes())
+		}
+		if err := store.CompleteIdempotencyKey(key, recorder.status, contentType, recorder.body.Bytes(), logger); err != nil {
+			logger.Error("error on completing idempotency key", sl.Err(err))
+		}
+	}
+}
+
+func replay(w http.ResponseWriter, rec, existing storage.IdempotencyRecord, logger *slog.Logger) {
+	switch {
+	case existing.Fingerprint != rec.Fingerprint:
+		logger.Info("idempotency key reused with a different request", slog.String("key", rec.Key))
+		handlers.WriteNewResponceWithError(w, "idempotency key was already used for a different request", http.StatusUnprocessableEntity, logger)
+	case existing.Status == 0:
+		logger.Info("idempotency key is still in progress", slog.String("key", rec.Key))
+		handlers.WriteNewResponceWithError(w, "a request with this idempotency key is still in progress", http.StatusConflict, logger)
+	default:
+		logger.Info("replaying stored response", slog.String("key", rec.Key))
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		if existing.ContentType != "" {
+			w.Header().Set("Content-Type", existing.ContentType)
+		}
+		w.WriteHeader(existing.Status)
+		if _, err := w.Write(existing.Body); err != nil {
+			logger.Error("error on writing response to client", sl.Err(err))
+		}
+	}
+}
+
+// fingerprint covers everything that changes what the handler does with the request.
+func fingerprint(r *http.Request, actor string, body []byte) string {
+	h := sha256.New()
+	for _, part := range []string{r.Method, r.URL.Path, actor, r.Header.Get(handlers.TimeZoneHeader)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if !rr.wroteHeader {
+		rr.status = status
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.wroteHeader = true
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
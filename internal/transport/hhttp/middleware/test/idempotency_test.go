@@ -0,0 +1,138 @@
+package middleware_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gintokos/tasksrestapi/internal/lib/id"
+	sqllite "github.com/gintokos/tasksrestapi/internal/storage/sqlLite"
+	"github.com/gintokos/tasksrestapi/internal/transport/hhttp/handlers"
+	"github.com/gintokos/tasksrestapi/internal/transport/hhttp/middleware"
+)
+
+func TestIdempotent(t *testing.T) {
+	logger := slog.Default()
+
+	st, err := sqllite.NewStorage(filepath.Join(t.TempDir(), "storage.db"), id.NewRandomGenerator(), sqllite.Options{})
+	if err != nil {
+		t.Fatalf("error on creating storage: %v", err)
+	}
+
+	handler := middleware.Idempotent(st, time.Hour, logger, handlers.PostTask(st, logger))
+
+	post := func(key, body string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewReader([]byte(body)))
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+
+	first := post("key-1", `{"title": "Task"}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, first.Code)
+	}
+
+	retry := post("key-1", `{"title": "Task"}`)
+	if retry.Code != http.StatusCreated {
+		t.Fatalf("expected replayed status %d, got %d", http.StatusCreated, retry.Code)
+	}
+	if retry.Header().Get(middleware.IdempotentReplayedHeader) != "true" {
+		t.Errorf("expected %s header on replay", middleware.IdempotentReplayedHeader)
+	}
+	if !bytes.Equal(first.Body.Bytes(), retry.Body.Bytes()) {
+		t.Errorf("expected replayed body %q, got %q", first.Body.String(), retry.Body.String())
+	}
+	contentType := first.Header().Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(first.Body.Bytes())
+	}
+	if got := retry.Header().Get("Content-Type"); got != contentType {
+		t.Errorf("expected the replay to keep Content-Type %q, got %q", contentType, got)
+	}
+
+	tasks, err := st.GetAllTasks(logger)
+	if err != nil {
+		t.Fatalf("error on getting tasks: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("expected 1 task after retry, got %d", len(tasks))
+	}
+
+	if rr := post("key-1", `{"title": "Task"}`, handlers.TimeZoneHeader, "Europe/Berlin"); rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected a different time zone to count as a different request, got %d", rr.Code)
+	}
+	if rr := post("key-1", `{"title": "Task"}`, handlers.ActorHeader, "bob"); rr.Code != http.StatusCreated || rr.Header().Get(middleware.IdempotentReplayedHeader) != "" {
+		t.Fatalf("expected another actor's key to run the request, got %d", rr.Code)
+	}
+
+	tooLarge := post("key-2", `{"title": "`+strings.Repeat("a", 1<<20)+`"}`)
+	if tooLarge.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status %d for an oversized body, got %d", http.StatusRequestEntityTooLarge, tooLarge.Code)
+	}
+
+	mismatch := post("key-1", `{"title": "Other task"}`)
+	if mismatch.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d, got %d", http.StatusUnprocessableEntity, mismatch.Code)
+	}
+
+	purged, err := st.PurgeIdempotencyKeys(time.Now().Add(2*time.Hour), logger)
+	if err != nil {
+		t.Fatalf("error on purging keys: %v", err)
+	}
+	if purged != 2 {
+		t.Fatalf("expected 2 purged keys, got %d", purged)
+	}
+
+	again := post("key-1", `{"title": "Other task"}`)
+	if again.Code != http.StatusCreated {
+		t.Fatalf("expected status %d after purge, got %d", http.StatusCreated, again.Code)
+	}
+}
+
+func TestIdempotent_ReleasesKeyOnPanic(t *testing.T) {
+	logger := slog.Default()
+
+	st, err := sqllite.NewStorage(filepath.Join(t.TempDir(), "storage.db"), id.NewRandomGenerator(), sqllite.Options{})
+	if err != nil {
+		t.Fatalf("error on creating storage: %v", err)
+	}
+
+	panics := true
+	handler := middleware.Idempotent(st, time.Hour, logger, func(w http.ResponseWriter, r *http.Request) {
+		if panics {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{}`))
+		req.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected the panic to reach the server")
+			}
+		}()
+		post()
+	}()
+
+	panics = false
+	if rr := post(); rr.Code != http.StatusCreated {
+		t.Fatalf("expected the retry to run after a panic, got %d", rr.Code)
+	}
+}
@@ -4,17 +4,51 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/gintokos/tasksrestapi/internal/config.go"
 	"github.com/gintokos/tasksrestapi/internal/storage"
 	"github.com/gintokos/tasksrestapi/internal/transport/hhttp/handlers"
+	"github.com/gintokos/tasksrestapi/internal/transport/hhttp/middleware"
 )
 
-func NewRouter(storage storage.Storage, logger *slog.Logger) *http.ServeMux {
+func NewRouter(st storage.Storage, jobs handlers.JobLister, configs handlers.ConfigStatuser, logger *slog.Logger, cfg config.Config) *http.ServeMux {
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("GET /tasks", handlers.GetTask(storage, logger))
-	mux.HandleFunc("POST /tasks", handlers.PostTask(storage, logger))
-	mux.HandleFunc("PUT /tasks/{id}", handlers.PutTask(storage, logger))
-	mux.HandleFunc("DELETE /tasks/{id}", handlers.DeleteTask(storage, logger))
+	postTask := handlers.PostTask(st, logger)
+	if store, ok := st.(storage.IdempotencyStore); ok {
+		postTask = middleware.Idempotent(store, cfg.Server.IdempotencyTTL.Duration(), logger, postTask)
+	}
+
+	mux.HandleFunc("GET /tasks", handlers.GetTask(st, logger))
+	mux.HandleFunc("POST /tasks", postTask)
+	mux.HandleFunc("POST /tasks:batch", handlers.BatchTasks(st, logger))
+	mux.HandleFunc("GET /tasks/export", handlers.ExportTasks(st, logger))
+	mux.HandleFunc("POST /tasks/import", handlers.ImportTasks(st, logger))
+	mux.HandleFunc("GET /tasks/{id}", handlers.GetTaskByID(st, logger))
+	mux.HandleFunc("GET /tasks/{id}/history", handlers.GetTaskHistory(st, logger))
+	mux.HandleFunc("PUT /tasks/{id}", handlers.PutTask(st, logger))
+	mux.HandleFunc("DELETE /tasks/{id}", handlers.DeleteTask(st, logger))
+	mux.HandleFunc("GET /tasks/{id}/reminders", handlers.GetTaskReminders(st, logger))
+	mux.HandleFunc("POST /tasks/{id}/reminders", handlers.PostTaskReminder(st, logger))
+	mux.HandleFunc("DELETE /tasks/{id}/reminders/{reminderId}", handlers.DeleteTaskReminder(st, logger))
+	mux.HandleFunc("POST /tasks/{id}/restore", handlers.RestoreTask(st, logger))
+	mux.HandleFunc("POST /tasks/{id}/undo", handlers.UndoTask(st, logger))
+	mux.HandleFunc("POST /undo/{eventId}", handlers.UndoEvent(st, logger))
+	mux.HandleFunc("GET /trash", handlers.GetTrash(st, logger))
+	mux.HandleFunc("DELETE /trash/{id}", handlers.PurgeTask(st, logger))
+	mux.HandleFunc("GET /calendar/{file}", handlers.CalendarFeed(st, logger))
+	mux.HandleFunc("GET /calendar/feed", handlers.GetCalendarFeedSettings(st, logger))
+	mux.HandleFunc("PUT /calendar/feed", handlers.PutCalendarFeedSettings(st, logger))
+	mux.HandleFunc("DELETE /calendar/feed", handlers.RevokeCalendarFeed(st, logger))
+	mux.HandleFunc("POST /calendar/feed/token", handlers.RegenerateCalendarToken(st, logger))
+	mux.HandleFunc("GET /admin/backups", handlers.GetBackups(cfg.Backup.Dir, logger))
+	mux.HandleFunc("POST /admin/backups", handlers.CreateBackup(st, cfg.Backup.Dir, cfg.Backup.Keep, logger))
+	mux.HandleFunc("GET /admin/jobs", handlers.GetJobs(jobs, logger))
+	mux.HandleFunc("GET /admin/config", handlers.GetConfigStatus(configs, logger))
+	mux.HandleFunc("GET /admin/queue", handlers.GetQueuedJobs(st, logger))
+	mux.HandleFunc("GET /admin/queue/{id}", handlers.GetQueuedJob(st, logger))
+	mux.HandleFunc("POST /admin/queue/{id}/retry", handlers.RetryQueuedJob(st, logger))
+	mux.HandleFunc("POST /admin/queue/{id}/cancel", handlers.CancelQueuedJob(st, logger))
+	mux.HandleFunc("GET /readyz", handlers.Ready(st, cfg.Leader.ID, logger))
 
 	return mux
 }